@@ -11,6 +11,7 @@ import (
 	"github.com/pastelnetwork/gonode/common/version"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pkg/errors"
+	urfave "github.com/urfave/cli/v2"
 )
 
 const (
@@ -47,10 +48,39 @@ func NewApp(args []string) *cli.App {
 		setupShowCommand(configs.InitConfig(args)),
 		setupInfoCommand(configs.InitConfig(args)),
 		setupPingCommand(configs.InitConfig(args)),
+		setupMasternodeCommand(configs.InitConfig(args)),
+		setupConfigCommand(configs.InitConfig(args)),
+		setupPortsCommand(configs.InitConfig(args)),
+		setupFirewallCommand(configs.InitConfig(args)),
+		setupDockerCommand(configs.InitConfig(args)),
+		setupUninstallCommand(configs.InitConfig(args)),
+		setupVerifyCommand(configs.InitConfig(args)),
+		setupSelfUpdateCommand(configs.InitConfig(args)),
+		setupVersionsCommand(configs.InitConfig(args)),
+		setupReleasesCommand(configs.InitConfig(args)),
+		setupNodeCommand(configs.InitConfig(args)),
+		setupRemoteCommand(configs.InitConfig(args)),
+		setupDiagnoseCommand(configs.InitConfig(args)),
+		setupLogsCommand(configs.InitConfig(args)),
 	)
+
+	updateCheckConfig := configs.InitConfig(args)
+	app.SetBeforeFunc(func() error {
+		warnIfUpdateAvailable(updateCheckConfig, args)
+		return nil
+	})
 	return app
 }
 
+// setActionFuncWithArgs sets the action of the command which gets all positional arguments,
+// cli.Command.SetActionFunc passes them without the first one
+func setActionFuncWithArgs(command *cli.Command, argsUsage string, actionFn cli.ActionFn) {
+	command.ArgsUsage = argsUsage
+	command.Action = func(c *urfave.Context) error {
+		return actionFn(c.Context, c.Args().Slice())
+	}
+}
+
 func addLogFlags(command *cli.Command, config *configs.Config) {
 	command.AddFlags(
 		// Main
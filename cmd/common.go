@@ -9,10 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/go-errors/errors"
 	ps "github.com/mitchellh/go-ps"
@@ -98,9 +96,9 @@ func ParsePastelConf(ctx context.Context, config *configs.Config) error {
 }
 
 // GetProcessCmdInput gets the arguments of a process. returns true if it was running and false if it wasnt or there was an error
-func GetProcessCmdInput(toolType constants.ToolType) (bool, []string) {
+func GetProcessCmdInput(config *configs.Config, toolType constants.ToolType) (bool, []string) {
 	var cmdArgs []string
-	pid, err := GetRunningProcessPid(toolType)
+	pid, err := GetRunningProcessPid(config, toolType)
 	if err != nil {
 		return false, cmdArgs
 	}
@@ -117,16 +115,16 @@ func GetProcessCmdInput(toolType constants.ToolType) (bool, []string) {
 	return true, cmdArgs
 }
 
-// CheckProcessRunning checks if the process is running
-func CheckProcessRunning(toolType constants.ToolType) bool {
-	if pid, err := GetRunningProcessPid(toolType); pid != 0 && err == nil {
+// CheckProcessRunning checks if the process of the instance is running
+func CheckProcessRunning(config *configs.Config, toolType constants.ToolType) bool {
+	if pid, err := GetRunningProcessPid(config, toolType); pid != 0 && err == nil {
 		return true
 	}
 	return false
 }
 
-// GetRunningProcessPid returns process id, if the pastel service is running
-func GetRunningProcessPid(toolType constants.ToolType) (int, error) {
+// GetRunningProcessPid returns process id, if the pastel service of the instance is running
+func GetRunningProcessPid(config *configs.Config, toolType constants.ToolType) (int, error) {
 	execName := constants.ServiceName[toolType][utils.GetOS()]
 	proc, err := ps.Processes()
 	if err != nil {
@@ -139,7 +137,7 @@ func GetRunningProcessPid(toolType constants.ToolType) (int, error) {
 			length = len(execName)
 		}
 		nameForTest := execName[:length]
-		if nameForTest == p.Executable() {
+		if nameForTest == p.Executable() && isInstanceProcess(config, p.Pid()) {
 			pid = p.Pid()
 			break
 		}
@@ -148,6 +146,19 @@ func GetRunningProcessPid(toolType constants.ToolType) (int, error) {
 	return pid, nil
 }
 
+// findSystemTool checks if the tool is installed, system tools are usually in sbin which may be not in PATH of the user
+func findSystemTool(name string) bool {
+	if _, err := exec.LookPath(name); err == nil {
+		return true
+	}
+	for _, dir := range []string{"/usr/sbin", "/sbin", "/usr/local/sbin"} {
+		if utils.CheckFileExist(filepath.Join(dir, name)) {
+			return true
+		}
+	}
+	return false
+}
+
 // KillProcessByPid kills process by its pid
 func KillProcessByPid(ctx context.Context, pid int) error {
 	process, err := os.FindProcess(pid)
@@ -162,10 +173,10 @@ func KillProcessByPid(ctx context.Context, pid int) error {
 	return nil
 }
 
-// KillProcess kills pastel service if it is running
-func KillProcess(ctx context.Context, toolType constants.ToolType) error {
+// KillProcess kills pastel service of the instance if it is running
+func KillProcess(ctx context.Context, config *configs.Config, toolType constants.ToolType) error {
 
-	if pid, err := GetRunningProcessPid(toolType); err != nil {
+	if pid, err := GetRunningProcessPid(config, toolType); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to check running processes")
 		return err
 	} else if pid != 0 {
@@ -269,6 +280,8 @@ func FindRunningProcessPid(ctx context.Context, searchTerm string) (int, error)
 				}
 			}
 		}
+	} else if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// grep exits with 1 when nothing matches
 	} else if err != nil {
 		return 0, err
 	}
@@ -321,14 +334,29 @@ func FindRunningProcess(searchTerm string) (string, error) {
 	return "", err
 }
 
-// GetSNPortList returns array of SuperNode ports for network
+// GetSNPortList returns array of SuperNode ports for network, with custom ports applied
 func GetSNPortList(config *configs.Config) []int {
-	if config.Network == constants.NetworkTestnet {
+	ports := getComponentPorts(config)
+	return []int{
+		ports[constants.PortNameNode],
+		ports[constants.PortNameNodeRPC],
+		ports[constants.PortNameSuperNode],
+		ports[constants.PortNameP2P],
+		ports[constants.PortNameMetaDB],
+		ports[constants.PortNameRaft],
+	}
+}
+
+// getNetworkPortList returns default ports of pasteld and supernode for the network
+func getNetworkPortList(network string) []int {
+	switch network {
+	case constants.NetworkTestnet:
 		return constants.TestnetPortList
-	} else if config.Network == constants.NetworkRegTest {
+	case constants.NetworkRegTest:
 		return constants.RegTestPortList
+	default:
+		return constants.MainnetPortList
 	}
-	return constants.MainnetPortList
 }
 
 // GetMNSyncInfo gets result of "mnsync status"
@@ -364,21 +392,15 @@ func GetPastelInfo(ctx context.Context, config *configs.Config) (structure.RPCGe
 
 // WaitingForPastelDToStart whether pasteld is running
 func WaitingForPastelDToStart(ctx context.Context, config *configs.Config) bool {
-	log.WithContext(ctx).Info("Waiting the pasteld to be started...")
-	var attempts = 0
-	for attempts < 10 {
-		_, err := GetPastelInfo(ctx, config)
-		if err == nil {
-			log.WithContext(ctx).Info("pasteld was started successfully")
-			return true
-		}
-		time.Sleep(10 * time.Second)
-		attempts++
+	if err := waitForComponentReady(ctx, config, constants.PastelD); err != nil {
+		log.WithContext(ctx).WithError(err).Error("pasteld is not ready")
+		return false
 	}
-	return false
+	log.WithContext(ctx).Info("pasteld was started successfully")
+	return true
 }
 
-// StopPastelDAndWait sends stop command to pasteld and waits 10 seconds
+// StopPastelDAndWait sends stop command to pasteld and waits until it exits
 func StopPastelDAndWait(ctx context.Context, config *configs.Config) error {
 	log.WithContext(ctx).Info("Stopping local pasteld...")
 	var resp map[string]interface{}
@@ -387,47 +409,18 @@ func StopPastelDAndWait(ctx context.Context, config *configs.Config) error {
 		log.WithContext(ctx).Errorf("unable to stop pastel: %v", err)
 		return err
 	}
-	time.Sleep(10 * time.Second)
+	if err = waitForComponentStopped(ctx, config, constants.PastelD); err != nil {
+		log.WithContext(ctx).WithError(err).Error("pasteld didn't stop")
+		return err
+	}
 	log.WithContext(ctx).Infof("Stopped local pasteld: %+v", resp)
 	return nil
 }
 
-// CheckMasterNodeSync checks and waits until mnsync is "Finished", return number of synced blocks
+// CheckMasterNodeSync checks and waits until mnsync is "Finished", return number of synced blocks.
+// It fails if the node isn't synced within config.SyncTimeout (if set)
 func CheckMasterNodeSync(ctx context.Context, config *configs.Config) (int, error) {
-	var getinfo structure.RPCGetInfo
-	var err error
-	t := time.Now()
-	fmt.Println() // add some space for loading line
-	for {
-		getinfo, err = GetPastelInfo(ctx, config)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("master node getinfo call has failed")
-			return 0, err
-		}
-		// Checking mnsync status
-		mnstatus, err := GetMNSyncInfo(ctx, config)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("master node mnsync status call has failed")
-			return 0, err
-		}
-		// line overwrites itself to avoid abundant loggin
-		fmt.Printf("Waiting for sync... Loading blocks - block #%d; Node has %d connection; mnstatus=%v, isSynced=%v (elapsed: %v)\r", getinfo.Result.Blocks, getinfo.Result.Connections, mnstatus.Result.AssetName, mnstatus.Result.IsSynced, time.Since(t))
-		if mnstatus.Result.AssetName == "Initial" {
-			var output interface{}
-			err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"reset"}, &output)
-			if err != nil {
-				log.WithContext(ctx).WithError(err).Error("\nmaster node reset has failed")
-				return 0, err
-			}
-			time.Sleep(10 * time.Second)
-		}
-		if mnstatus.Result.IsSynced {
-			log.WithContext(ctx).Info("\nmasternodes lists are synced!")
-			break
-		}
-		time.Sleep(10 * time.Second)
-	}
-	return getinfo.Result.Blocks, nil
+	return newSyncMonitor(config).wait(ctx)
 }
 
 // CheckZksnarkParams validates Zksnark files
@@ -453,50 +446,26 @@ func CheckZksnarkParams(ctx context.Context, config *configs.Config) error {
 	return nil
 }
 
-func copyPastelUpToRemote(ctx context.Context, client *utils.Client, version string, remotePastelUp string) error {
-	// Check if the current os is linux
-	if runtime.GOOS == "linux" {
-		log.WithContext(ctx).Infof("copying pastelup to remote")
-		var localPastelupPath string
-
-		// Get local pastelup path
-		ex, err := os.Executable()
-		if err != nil {
-			return fmt.Errorf("failed to get path of executable file %s", err)
-		}
-
-		// Check if localPastelupPath is a symlink file
-		if localPastelupPath, err = filepath.EvalSymlinks(ex); err != nil {
-			return fmt.Errorf("local pastelup is symbol link:  %s", err)
-		}
-
-		// Copy pastelup to remote
-		if err := client.Scp(localPastelupPath, remotePastelUp, "0777"); err != nil {
-			return fmt.Errorf("failed to copy pastelup to remote %s", err)
-		}
-
-	} else {
-		log.WithContext(ctx).Infof("current OS is not linux, skipping pastelup copy")
-
-		// Download PastelUpExecName from remote and save to remotePastelUp
-		downloadURL := fmt.Sprintf("%s/%s/%s", constants.DownloadBaseURL, version, constants.PastelUpExecName["Linux"])
-		log.WithContext(ctx).Infof("downloading pastelup from %s", downloadURL)
-
-		if _, err := client.Cmd(fmt.Sprintf("wget %s -O %s", downloadURL, remotePastelUp)).Output(); err != nil {
-			return fmt.Errorf("failed to download pastelup from remote: %s", err.Error())
-		}
-		log.WithContext(ctx).Infof("pastelup downloaded from Pastel download portal")
+func prepareRemoteSession(ctx context.Context, config *configs.Config) (*utils.Client, error) {
+	client, err := dialRemoteHost(ctx, config)
+	if err != nil {
+		return nil, err
 	}
 
-	// chmod +x remote pastelup
-	if _, err := client.Cmd(fmt.Sprintf("chmod +x %s", remotePastelUp)).Output(); err != nil {
-		return fmt.Errorf("failed to chmod +x pastelup at remote: %s", err.Error())
+	// Transfer pastelup to remote
+	log.WithContext(ctx).Info("installing pastelup to remote host...")
+	if err := copyPastelUpToRemote(ctx, client, config.Version, constants.RemotePastelupPath); err != nil {
+		log.WithContext(ctx).Errorf("Failed to copy pastelup to remote at %s - %v", constants.RemotePastelupPath, err)
+		client.Close()
+		return nil, fmt.Errorf("failed to install pastelup at %s - %v", constants.RemotePastelupPath, err)
 	}
+	log.WithContext(ctx).Info("successfully install pastelup executable to remote host")
 
-	return nil
+	return client, nil
 }
 
-func prepareRemoteSession(ctx context.Context, config *configs.Config) (*utils.Client, error) {
+// dialRemoteHost connects to the remote host of config
+func dialRemoteHost(ctx context.Context, config *configs.Config) (*utils.Client, error) {
 	var err error
 
 	// Validate config
@@ -522,20 +491,12 @@ func prepareRemoteSession(ctx context.Context, config *configs.Config) (*utils.C
 	}
 
 	log.WithContext(ctx).Info("connected successfully")
-
-	// Transfer pastelup to remote
-	log.WithContext(ctx).Info("installing pastelup to remote host...")
-	if err := copyPastelUpToRemote(ctx, client, config.Version, constants.RemotePastelupPath); err != nil {
-		log.WithContext(ctx).Errorf("Failed to copy pastelup to remote at %s - %v", constants.RemotePastelupPath, err)
-		client.Close()
-		return nil, fmt.Errorf("failed to install pastelup at %s - %v", constants.RemotePastelupPath, err)
-	}
-	log.WithContext(ctx).Info("successfully install pastelup executable to remote host")
-
 	return client, nil
 }
 
-func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Config, commands []string, tryStop bool) error {
+// executeRemoteCommandWithInventory executes the command on the remote host or all hosts from inventory,
+// getCommand is called for every host after its inventory variables are applied to config, its error fails the host
+func executeRemoteCommandWithInventory(ctx context.Context, config *configs.Config, getCommand func() (string, error), tryStop bool) error {
 	if len(config.InventoryFile) > 0 {
 
 		var inv Inventory
@@ -544,12 +505,17 @@ func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Con
 			log.WithContext(ctx).WithError(err).Error("Failed to load inventory file")
 			return err
 		}
-		if err := inv.ExecuteCommands(ctx, config, commands); err != nil {
+		if err := inv.ExecuteCommand(ctx, config, getCommand, tryStop); err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to execute command on remote host from inventory")
 			return err
 		}
 	} else {
-		if err := executeRemoteCommands(ctx, config, commands, tryStop); err != nil {
+		command, err := getCommand()
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to get command for remote host")
+			return err
+		}
+		if err := executeRemoteCommands(ctx, config, []string{command}, tryStop); err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to execute command on remote host")
 			return err
 		}
@@ -557,29 +523,14 @@ func executeRemoteCommandsWithInventory(ctx context.Context, config *configs.Con
 	return nil
 }
 
+// executeRemoteCommands executes commands on the remote host one by one, output is streamed with the host prefix,
+// fails if any command fails
 func executeRemoteCommands(ctx context.Context, config *configs.Config, commands []string, tryStop bool) error {
-	// Connect to remote
-	client, err := prepareRemoteSession(ctx, config)
+	results, err := runRemoteCommands(ctx, config, config.RemoteIP, commands, tryStop, false)
 	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to prepare remote session")
-		return fmt.Errorf("failed to prepare remote session: %v", err)
-	}
-	defer client.Close()
-
-	if tryStop {
-		if err = checkAndStopRemoteServices(ctx, config, client); err != nil {
-			return err
-		}
-	}
-
-	for _, command := range commands {
-		err = client.ShellCmd(ctx, command)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("Failed while executing remote command")
-			return err
-		}
+		return err
 	}
-	return nil
+	return getRemoteResultsError(results)
 }
 
 func checkAndStopRemoteServices(ctx context.Context, config *configs.Config, client *utils.Client) error {
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+// compatManifest defines which versions of the components go together in the release, it is published as
+// constants.CompatManifestName next to the release files
+type compatManifest struct {
+	Release    string                       `json:"release"`
+	Components map[string]compatRequirement `json:"components"`
+
+	// channel or version the manifest was downloaded for
+	requested string
+}
+
+// compatRequirement is the version of the component in the release and the range of its versions
+// compatible with the release, if the range is not set only the exact version is compatible
+type compatRequirement struct {
+	Version    string `json:"version"`
+	MinVersion string `json:"min_version,omitempty"`
+	MaxVersion string `json:"max_version,omitempty"`
+}
+
+// releaseManifest is the manifest of the release being installed, nil if it isn't published or the check is skipped
+var releaseManifest *compatManifest
+
+// getCompatKey returns the name of the component in the manifest, gonode tools are released together
+func getCompatKey(tool constants.ToolType) string {
+	switch tool {
+	case constants.WalletNode, constants.SuperNode, constants.Hermes, constants.Bridge:
+		return string(constants.GoNode)
+	}
+	return string(tool)
+}
+
+// getInstallComponents returns components downloaded by runServicesInstall
+func getInstallComponents(installCommand constants.ToolType, withDependencies bool) []constants.ToolType {
+	switch installCommand {
+	case constants.WalletNode:
+		if withDependencies {
+			return []constants.ToolType{constants.PastelD, constants.RQService, constants.WalletNode, constants.Bridge}
+		}
+		return []constants.ToolType{constants.WalletNode, constants.Bridge}
+	case constants.SuperNode:
+		if withDependencies {
+			return []constants.ToolType{constants.PastelD, constants.RQService, constants.SuperNode, constants.Hermes, constants.DDService}
+		}
+		return []constants.ToolType{constants.SuperNode, constants.Hermes}
+	}
+	return []constants.ToolType{installCommand}
+}
+
+// loadCompatManifest downloads the manifest of the release, returns nil if it isn't published
+func loadCompatManifest(ctx context.Context, version string) (*compatManifest, error) {
+	url := fmt.Sprintf("%s/%s/%s", constants.DownloadBaseURL, constants.GetVersionSubURL(version), constants.CompatManifestName)
+	log.WithContext(ctx).Debugf("Downloading compatibility manifest from %s", url)
+
+	data, err := utils.GetURL(url, releaseIndexTimeout)
+	if err == utils.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Errorf("failed to download compatibility manifest %s: %v", url, err)
+	}
+
+	manifest := &compatManifest{requested: version}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Errorf("failed to parse compatibility manifest %s: %v", url, err)
+	}
+	for name, req := range manifest.Components {
+		if _, err := utils.CompareVersions(req.Version, req.Version); err != nil {
+			return nil, errors.Errorf("invalid version of %s in compatibility manifest %s: %v", name, url, err)
+		}
+	}
+	return manifest, nil
+}
+
+// isCompatible checks that the version is in the compatible range of the requirement
+func (r compatRequirement) isCompatible(version string) (bool, error) {
+	if len(r.MinVersion) == 0 && len(r.MaxVersion) == 0 {
+		cmp, err := utils.CompareVersions(version, r.Version)
+		return cmp == 0, err
+	}
+	if len(r.MinVersion) != 0 {
+		if cmp, err := utils.CompareVersions(version, r.MinVersion); err != nil || cmp < 0 {
+			return false, err
+		}
+	}
+	if len(r.MaxVersion) != 0 {
+		if cmp, err := utils.CompareVersions(version, r.MaxVersion); err != nil || cmp > 0 {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (r compatRequirement) String() string {
+	switch {
+	case len(r.MinVersion) != 0 && len(r.MaxVersion) != 0:
+		return fmt.Sprintf(">= %s and <= %s", r.MinVersion, r.MaxVersion)
+	case len(r.MinVersion) != 0:
+		return ">= " + r.MinVersion
+	case len(r.MaxVersion) != 0:
+		return "<= " + r.MaxVersion
+	}
+	return r.Version
+}
+
+// getReleaseComponentVersion returns version of the tool from the manifest of the release being installed
+func getReleaseComponentVersion(ctx context.Context, tool constants.ToolType, version string) string {
+	if releaseManifest == nil || releaseManifest.requested != version {
+		return version
+	}
+	req, ok := releaseManifest.Components[getCompatKey(tool)]
+	if !ok {
+		return version
+	}
+	if req.Version != version {
+		log.WithContext(ctx).Infof("Release %s includes %s %s", version, tool, req.Version)
+	}
+	return req.Version
+}
+
+// checkReleaseCompatibility checks that the components which are installed and not updated
+// are compatible with the release, the components of the release are downloaded in the versions from its manifest
+func checkReleaseCompatibility(ctx context.Context, config *configs.Config, installCommand constants.ToolType, withDependencies bool) error {
+	releaseManifest = nil
+	if config.IgnoreCompat {
+		log.WithContext(ctx).Warn("Compatibility check is skipped, all components are downloaded from release " + config.Version)
+		return nil
+	}
+
+	manifest, err := loadCompatManifest(ctx, config.Version)
+	if err != nil {
+		return errors.Errorf("%v, use --ignore-compat to install without the compatibility check", err)
+	}
+	if manifest == nil {
+		log.WithContext(ctx).Warnf("Release %s has no compatibility manifest, compatibility of components is not checked", config.Version)
+		return nil
+	}
+
+	installing := getInstallComponents(installCommand, withDependencies)
+	var problems []string
+	for _, v := range getComponentVersions(ctx, config) {
+		tool := constants.ToolType(v.Component)
+		if tool == constants.Pastelup || v.Version == notInstalled || utils.ContainsToolType(installing, tool) {
+			continue
+		}
+		req, ok := manifest.Components[getCompatKey(tool)]
+		if !ok {
+			continue
+		}
+		compatible, err := req.isCompatible(v.Version)
+		if err != nil {
+			log.WithContext(ctx).Warnf("Unable to check compatibility of installed %s %s: %v", tool, v.Version, err)
+			continue
+		}
+		if !compatible {
+			problems = append(problems, fmt.Sprintf("%s %s (release %s requires %s)", tool, v.Version, manifest.Release, req))
+		}
+	}
+	if len(problems) != 0 {
+		return errors.Errorf("installed components are incompatible with release %s:\n\t%s\n"+
+			"update them together (e.g. 'pastelup update supernode') or use --ignore-compat",
+			config.Version, strings.Join(problems, "\n\t"))
+	}
+
+	releaseManifest = manifest
+	log.WithContext(ctx).Infof("Installed components are compatible with release %s", manifest.Release)
+	return nil
+}
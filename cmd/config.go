@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+type configCommand uint8
+
+const (
+	configRender configCommand = iota
+	configValidate
+)
+
+var (
+	configCmdName = map[configCommand]string{
+		configRender:   "render",
+		configValidate: "validate",
+	}
+	configCmdMessage = map[configCommand]string{
+		configRender:   "Render %s config with user overrides applied",
+		configValidate: "Validate configs of Pastel components",
+	}
+
+	// configTools are components which configs are generated by pastelup
+	configTools = []constants.ToolType{
+		constants.SuperNode,
+		constants.Hermes,
+		constants.WalletNode,
+		constants.Bridge,
+		constants.RQService,
+	}
+)
+
+func setupConfigSubCommand(config *configs.Config,
+	configCmd configCommand, tool constants.ToolType,
+	f func(context.Context, *configs.Config, constants.ToolType) error,
+) *cli.Command {
+
+	commonFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("network", &config.Network).SetAliases("n").
+			SetUsage(green("Optional, network type, can be - \"mainnet\", \"testnet\" or \"regtest\", if omitted, will be read from pastel.conf")),
+	}
+
+	// commands for all components have no component subcommand
+	commandName := configCmdName[configCmd]
+	commandMessage := configCmdMessage[configCmd]
+	if len(tool) != 0 {
+		commandName = string(tool)
+		commandMessage = fmt.Sprintf(configCmdMessage[configCmd], tool)
+	}
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commonFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return fmt.Errorf("failed to configure logging option - %v", err)
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sys.RegisterInterruptHandler(cancel, func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+
+			if len(config.Network) == 0 {
+				if err = ParsePastelConf(ctx, config); err != nil {
+					log.WithContext(ctx).Warnf("pastel.conf not found, using %s network", constants.NetworkMainnet)
+					config.Network = constants.NetworkMainnet
+				}
+			} else if !utils.IsValidNetworkOpt(config.Network) {
+				return fmt.Errorf("invalid --network provided. valid opts: %s", strings.Join(constants.NetworkModes, ","))
+			}
+
+			return f(ctx, config, tool)
+		})
+	}
+	return subCommand
+}
+
+func setupConfigCommand(config *configs.Config) *cli.Command {
+
+	configRenderSubCommand := cli.NewCommand(configCmdName[configRender])
+	configRenderSubCommand.SetUsage(cyan(fmt.Sprintf(configCmdMessage[configRender], "component")))
+	for _, tool := range configTools {
+		configRenderSubCommand.AddSubcommands(setupConfigSubCommand(config, configRender, tool, runConfigRender))
+	}
+
+	configCommand := cli.NewCommand("config")
+	configCommand.SetUsage(blue("Perform operations with configs of Pastel components"))
+	configCommand.AddSubcommands(configRenderSubCommand,
+		setupConfigSubCommand(config, configValidate, "", runConfigValidate),
+	)
+
+	return configCommand
+}
+
+func runConfigRender(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	toolConfig, err := renderComponentConfig(ctx, config, tool)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to render %s config", tool)
+		return err
+	}
+
+	fmt.Println(toolConfig)
+	return nil
+}
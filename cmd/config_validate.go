@@ -0,0 +1,591 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+var txidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// pastelConfMultiKeys are pastel.conf options which can be set more than once
+var pastelConfMultiKeys = []string{"addnode", "connect", "seednode", "bind", "whitebind", "rpcallowip", "rpcbind", "externalip"}
+
+// yamlConfigSchema describes what is checked in the YAML config of the component
+type yamlConfigSchema struct {
+	tool     constants.ToolType
+	path     string
+	required []string
+	// listenPorts are ports the component listens on, they must not collide with the ports of other components
+	listenPorts map[string]int
+	dirs        []string
+	pastelIDs   []string
+}
+
+// portRef describes port in the config which must be equal to the listen port of another component
+type portRef struct {
+	tool       constants.ToolType
+	key        string
+	targetTool constants.ToolType
+	targetKey  string
+}
+
+type configDiagnostic struct {
+	file     string
+	line     int
+	severity string
+	message  string
+}
+
+func (d configDiagnostic) String() string {
+	if d.line > 0 {
+		return fmt.Sprintf("%s:%d: %s: %s", d.file, d.line, d.severity, d.message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.file, d.severity, d.message)
+}
+
+type configLocation struct {
+	file string
+	line int
+	key  string
+	port int
+}
+
+type configValidator struct {
+	config      *configs.Config
+	network     string
+	diagnostics []configDiagnostic
+	errorsCount int
+	// listenPorts maps port to the config option which uses it
+	listenPorts map[int]configLocation
+	// ports keeps port options by "tool:key"
+	ports map[string]configLocation
+}
+
+func newConfigValidator(config *configs.Config) *configValidator {
+	return &configValidator{
+		config:      config,
+		network:     constants.NetworkMainnet,
+		listenPorts: make(map[int]configLocation),
+		ports:       make(map[string]configLocation),
+	}
+}
+
+func (v *configValidator) errorf(file string, line int, format string, args ...interface{}) {
+	v.errorsCount++
+	v.diagnostics = append(v.diagnostics, configDiagnostic{file, line, "error", fmt.Sprintf(format, args...)})
+}
+
+func (v *configValidator) warnf(file string, line int, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, configDiagnostic{file, line, "warning", fmt.Sprintf(format, args...)})
+}
+
+func runConfigValidate(ctx context.Context, config *configs.Config, _ constants.ToolType) error {
+	v := newConfigValidator(config)
+	v.validateAll()
+
+	for _, d := range v.diagnostics {
+		fmt.Println(d)
+	}
+	if v.errorsCount > 0 {
+		return errors.Errorf("found %d error(s) and %d warning(s) in configs", v.errorsCount, len(v.diagnostics)-v.errorsCount)
+	}
+	log.WithContext(ctx).Infof("Configs are valid, %d warning(s)", len(v.diagnostics))
+	return nil
+}
+
+func (v *configValidator) validateAll() {
+	// network is taken from pastel.conf, so it goes first
+	v.validatePastelConf(filepath.Join(v.config.WorkingDir, constants.PastelConfName))
+	v.validateMasternodeConf(getMasternodeConfPath(v.config, v.config.WorkingDir, "masternode.conf"))
+	v.validateRQServiceConf(v.config.Configurer.GetRQServiceConfFile(v.config.WorkingDir))
+
+	for _, schema := range v.yamlConfigSchemas() {
+		v.validateYAMLConf(schema)
+	}
+
+	v.validateDDServiceConf(getDDConfigFilePath(v.config))
+
+	v.validatePortRefs()
+}
+
+func (v *configValidator) yamlConfigSchemas() []yamlConfigSchema {
+	workDir := v.config.WorkingDir
+	return []yamlConfigSchema{
+		{
+			tool: constants.SuperNode,
+			path: v.config.Configurer.GetSuperNodeConfFile(workDir),
+			required: []string{"log-config.log-file", "temp-dir", "work-dir", "rq-files-dir", "dd-service-dir",
+				"node.pastel_id", "node.pass_phrase", "node.server.port", "p2p.port", "p2p.data_dir",
+				"metadb.http_port", "metadb.raft_port", "metadb.data_dir", "raptorq.port", "dd-server.port"},
+			listenPorts: map[string]int{
+				"node.server.port": constants.SNPort,
+				"p2p.port":         constants.P2PPort,
+				"metadb.http_port": constants.MDLPort,
+				"metadb.raft_port": constants.RAFTPort,
+			},
+			dirs:      []string{"temp-dir", "work-dir", "rq-files-dir", "dd-service-dir", "p2p.data_dir", "metadb.data_dir"},
+			pastelIDs: []string{"node.pastel_id"},
+		},
+		{
+			tool: constants.Hermes,
+			path: v.config.Configurer.GetHermesConfFile(workDir),
+			required: []string{"log-config.log-file", "temp-dir", "work-dir", "dd-service-dir",
+				"pastel_id", "pass_phrase", "sn_host", "sn_port"},
+			dirs:      []string{"temp-dir", "work-dir", "dd-service-dir"},
+			pastelIDs: []string{"pastel_id"},
+		},
+		{
+			tool: constants.WalletNode,
+			path: v.config.Configurer.GetWalletNodeConfFile(workDir),
+			required: []string{"log-config.log-file", "temp-dir", "work-dir", "rq-files-dir",
+				"node.api.port", "node.burn_address", "raptorq.port"},
+			listenPorts: map[string]int{"node.api.port": -1},
+			dirs:        []string{"temp-dir", "work-dir", "rq-files-dir"},
+		},
+		{
+			tool: constants.Bridge,
+			path: v.config.Configurer.GetBridgeConfFile(workDir),
+			required: []string{"log-config.log-level", "log-config.log-file", "temp-dir", "work-dir",
+				"download.pastel_id", "download.passphrase", "server.port"},
+			listenPorts: map[string]int{"server.port": -1},
+			dirs:        []string{"temp-dir", "work-dir"},
+			pastelIDs:   []string{"download.pastel_id"},
+		},
+	}
+}
+
+// checkPortNetwork warns if the port is the default port of another network
+func (v *configValidator) checkPortNetwork(file string, line int, key string, port int, portIndex int) {
+	if portIndex < 0 || getNetworkPortList(v.network)[portIndex] == port {
+		return
+	}
+	for _, network := range constants.NetworkModes {
+		if network != v.network && getNetworkPortList(network)[portIndex] == port {
+			v.warnf(file, line, "%s %d is the default %s port, but node is configured for %s", key, port, network, v.network)
+			return
+		}
+	}
+}
+
+// addPort checks port range, registers listen port and checks it doesn't collide with other components
+func (v *configValidator) addPort(tool constants.ToolType, file string, line int, key string, value string, listen bool) (int, bool) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || port < 1 || port > 65535 {
+		v.errorf(file, line, "%s: invalid port %q, must be in range 1-65535", key, value)
+		return 0, false
+	}
+	location := configLocation{file: file, line: line, key: string(tool) + " " + key, port: port}
+	v.ports[string(tool)+":"+key] = location
+	if !listen {
+		return port, true
+	}
+	if other, ok := v.listenPorts[port]; ok {
+		v.errorf(file, line, "%s: port %d is already used by %s (%s:%d)", key, port, other.key, other.file, other.line)
+		return port, true
+	}
+	v.listenPorts[port] = location
+	return port, true
+}
+
+func (v *configValidator) checkDir(file string, line int, key string, dir string) {
+	if len(dir) == 0 {
+		v.errorf(file, line, "%s: directory is not set", key)
+		return
+	}
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		v.warnf(file, line, "%s: directory %s does not exist", key, dir)
+		return
+	} else if err != nil {
+		v.errorf(file, line, "%s: cannot access directory %s - %v", key, dir, err)
+		return
+	}
+	if !info.IsDir() {
+		v.errorf(file, line, "%s: %s is not a directory", key, dir)
+		return
+	}
+	if !utils.IsDirWritable(dir) {
+		v.errorf(file, line, "%s: directory %s is not writable", key, dir)
+	}
+}
+
+///// pastel.conf
+
+func (v *configValidator) validatePastelConf(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		v.errorf(path, 0, "cannot read pastel.conf - %v", err)
+		return
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	lines := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			v.errorf(path, lineNum, "invalid line %q, expected key=value", line)
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if prev, ok := lines[key]; ok && !utils.Contains(pastelConfMultiKeys, key) {
+			v.warnf(path, lineNum, "%s is already set at line %d, last value is used", key, prev)
+		}
+		values[key] = value
+		lines[key] = lineNum
+	}
+	if err = scanner.Err(); err != nil {
+		v.errorf(path, 0, "cannot read pastel.conf - %v", err)
+		return
+	}
+
+	for _, key := range []string{"rpcuser", "rpcpassword", "rpcport"} {
+		if len(values[key]) == 0 {
+			v.errorf(path, lines[key], "%s is required", key)
+		}
+	}
+	for _, key := range []string{"testnet", "regtest", "server", "listen", "txindex", "masternode"} {
+		if value, ok := values[key]; ok && value != "0" && value != "1" {
+			v.errorf(path, lines[key], "%s must be 0 or 1, got %q", key, value)
+		}
+	}
+
+	switch {
+	case values["testnet"] == "1" && values["regtest"] == "1":
+		v.errorf(path, lines["regtest"], "testnet=1 and regtest=1 cannot be set together")
+	case values["testnet"] == "1":
+		v.network = constants.NetworkTestnet
+	case values["regtest"] == "1":
+		v.network = constants.NetworkRegTest
+	}
+	v.config.Network = v.network
+
+	nodePort := strconv.Itoa(getNetworkPortList(v.network)[constants.NodePort])
+	if value, ok := values["port"]; ok {
+		nodePort = value
+	}
+	if port, ok := v.addPort(constants.PastelD, path, lines["port"], "port", nodePort, true); ok {
+		v.checkPortNetwork(path, lines["port"], "port", port, constants.NodePort)
+	}
+	if value, ok := values["rpcport"]; ok {
+		if port, ok := v.addPort(constants.PastelD, path, lines["rpcport"], "rpcport", value, true); ok {
+			v.checkPortNetwork(path, lines["rpcport"], "rpcport", port, constants.NodeRPCPort)
+		}
+	}
+	if values["masternode"] == "1" && values["txindex"] != "1" {
+		v.errorf(path, lines["masternode"], "masternode=1 requires txindex=1")
+	}
+}
+
+///// masternode.conf
+
+func (v *configValidator) validateMasternodeConf(path string) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		v.errorf(path, 0, "cannot read masternode.conf - %v", err)
+		return
+	}
+
+	var conf map[string]masterNodeConf
+	if err = json.Unmarshal(data, &conf); err != nil {
+		line := 0
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line = lineAtOffset(data, syntaxErr.Offset)
+		}
+		v.errorf(path, line, "invalid JSON - %v", err)
+		return
+	}
+
+	var aliases []string
+	for alias := range conf {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		mn := conf[alias]
+		line := lineOfText(data, strconv.Quote(alias))
+		if len(mn.MnPrivKey) == 0 {
+			v.errorf(path, line, "%s: mnPrivKey is required", alias)
+		}
+		if !txidRegexp.MatchString(mn.Txid) {
+			v.errorf(path, line, "%s: txid %q must be 64 hex characters", alias, mn.Txid)
+		}
+		if _, err := strconv.Atoi(mn.OutIndex); err != nil {
+			v.errorf(path, line, "%s: outIndex %q must be a number", alias, mn.OutIndex)
+		}
+		v.checkHostPort(path, line, alias+": mnAddress", mn.MnAddress, constants.NodePort)
+		v.checkHostPort(path, line, alias+": extAddress", mn.ExtAddress, constants.SNPort)
+		v.checkHostPort(path, line, alias+": extP2P", mn.ExtP2P, constants.P2PPort)
+		if len(mn.ExtKey) != 0 && !utils.IsValidPastelID(mn.ExtKey) {
+			v.errorf(path, line, "%s: extKey %q is not a valid PastelID", alias, mn.ExtKey)
+		}
+	}
+}
+
+func (v *configValidator) checkHostPort(file string, line int, key string, value string, portIndex int) {
+	host, portStr, err := net.SplitHostPort(value)
+	if err != nil || len(host) == 0 {
+		v.errorf(file, line, "%s %q must be in host:port format", key, value)
+		return
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		v.errorf(file, line, "%s: invalid port %q, must be in range 1-65535", key, portStr)
+		return
+	}
+	v.checkPortNetwork(file, line, key, port, portIndex)
+}
+
+// lineAtOffset returns line number of the byte offset in the data
+func lineAtOffset(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return strings.Count(string(data[:offset]), "\n") + 1
+}
+
+// lineOfText returns line number of the first occurrence of the text in the data or 0 if not found
+func lineOfText(data []byte, text string) int {
+	idx := strings.Index(string(data), text)
+	if idx < 0 {
+		return 0
+	}
+	return lineAtOffset(data, int64(idx))
+}
+
+///// rq-service
+
+func (v *configValidator) validateRQServiceConf(path string) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		v.errorf(path, 0, "cannot read rq-service config - %v", err)
+		return
+	}
+
+	found := false
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "grpc-service") {
+			continue
+		}
+		found = true
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			v.errorf(path, i+1, "invalid line %q, expected grpc-service = \"host:port\"", line)
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		_, port, err := net.SplitHostPort(value)
+		if err != nil {
+			v.errorf(path, i+1, "grpc-service %q must be in host:port format", value)
+			continue
+		}
+		v.addPort(constants.RQService, path, i+1, "grpc-service", port, true)
+	}
+	if !found {
+		v.errorf(path, 0, "grpc-service is required")
+	}
+}
+
+///// YAML configs
+
+func (v *configValidator) validateYAMLConf(schema yamlConfigSchema) {
+	data, err := ioutil.ReadFile(schema.path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		v.errorf(schema.path, 0, "cannot read %s config - %v", schema.tool, err)
+		return
+	}
+
+	var doc yamlv3.Node
+	if err = yamlv3.Unmarshal(data, &doc); err != nil {
+		// yaml errors already contain line number
+		v.errorf(schema.path, 0, "invalid YAML - %v", err)
+		return
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		v.errorf(schema.path, 0, "config must be a YAML mapping")
+		return
+	}
+	root := doc.Content[0]
+
+	for _, key := range schema.required {
+		keyNode, valueNode, parentLine := lookupYAMLNode(root, key)
+		if keyNode == nil {
+			v.errorf(schema.path, parentLine, "%s is required", key)
+		} else if valueNode.Tag == "!!null" && !utils.Contains(schema.pastelIDs, key) {
+			v.warnf(schema.path, keyNode.Line, "%s is empty", key)
+		}
+	}
+
+	var listenKeys []string
+	for key := range schema.listenPorts {
+		listenKeys = append(listenKeys, key)
+	}
+	sort.Strings(listenKeys)
+	for _, key := range listenKeys {
+		portIndex := schema.listenPorts[key]
+		if keyNode, valueNode, _ := lookupYAMLNode(root, key); keyNode != nil {
+			if port, ok := v.addPort(schema.tool, schema.path, keyNode.Line, key, valueNode.Value, true); ok {
+				v.checkPortNetwork(schema.path, keyNode.Line, key, port, portIndex)
+			}
+		}
+	}
+	for _, ref := range configPortRefs {
+		if ref.tool != schema.tool {
+			continue
+		}
+		if keyNode, valueNode, _ := lookupYAMLNode(root, ref.key); keyNode != nil {
+			v.addPort(schema.tool, schema.path, keyNode.Line, ref.key, valueNode.Value, false)
+		}
+	}
+
+	for _, key := range schema.dirs {
+		if keyNode, valueNode, _ := lookupYAMLNode(root, key); keyNode != nil {
+			v.checkDir(schema.path, keyNode.Line, key, valueNode.Value)
+		}
+	}
+
+	for _, key := range schema.pastelIDs {
+		keyNode, valueNode, _ := lookupYAMLNode(root, key)
+		if keyNode == nil {
+			continue
+		}
+		if valueNode.Tag == "!!null" || len(valueNode.Value) == 0 {
+			v.warnf(schema.path, keyNode.Line, "%s is not set yet", key)
+		} else if !utils.IsValidPastelID(valueNode.Value) {
+			v.errorf(schema.path, keyNode.Line, "%s %q is not a valid PastelID", key, valueNode.Value)
+		}
+	}
+
+	if schema.tool == constants.WalletNode {
+		if keyNode, valueNode, _ := lookupYAMLNode(root, "node.burn_address"); keyNode != nil && valueNode.Value != getBurnAddress(v.config) {
+			v.errorf(schema.path, keyNode.Line, "node.burn_address %s is not the %s burn address %s", valueNode.Value, v.network, getBurnAddress(v.config))
+		}
+	}
+}
+
+// lookupYAMLNode returns key and value nodes by the dotted path,
+// if key is not found returns line of the deepest found parent key
+func lookupYAMLNode(node *yamlv3.Node, path string) (*yamlv3.Node, *yamlv3.Node, int) {
+	parentLine := 0
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if node.Kind != yamlv3.MappingNode {
+			return nil, nil, parentLine
+		}
+		var keyNode, valueNode *yamlv3.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				keyNode, valueNode = node.Content[j], node.Content[j+1]
+				break
+			}
+		}
+		if keyNode == nil {
+			return nil, nil, parentLine
+		}
+		if i == len(keys)-1 {
+			return keyNode, valueNode, keyNode.Line
+		}
+		parentLine = keyNode.Line
+		node = valueNode
+	}
+	return nil, nil, parentLine
+}
+
+///// dd-service config.ini
+
+func (v *configValidator) validateDDServiceConf(path string) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		v.errorf(path, 0, "cannot read dd-service config - %v", err)
+		return
+	}
+
+	required := []string{"input_files_path", "support_files_path", "output_files_path", "processed_files_path",
+		"internet_rareness_downloaded_images_path", "nsfw_model_path"}
+	found := make(map[string]bool)
+	section := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			v.errorf(path, i+1, "invalid line %q, expected key = value", line)
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if section != "DUPEDETECTIONCONFIG" {
+			v.warnf(path, i+1, "%s is outside of [DUPEDETECTIONCONFIG] section", key)
+			continue
+		}
+		if utils.Contains(required, key) {
+			found[key] = true
+			v.checkDir(path, i+1, key, value)
+		}
+	}
+	for _, key := range required {
+		if !found[key] {
+			v.errorf(path, 0, "%s is required in [DUPEDETECTIONCONFIG] section", key)
+		}
+	}
+}
+
+///// cross-config checks
+
+// configPortRefs are ports which point to the listen ports of other components
+var configPortRefs = []portRef{
+	{constants.Hermes, "sn_port", constants.SuperNode, "node.server.port"},
+	{constants.SuperNode, "raptorq.port", constants.RQService, "grpc-service"},
+	{constants.WalletNode, "raptorq.port", constants.RQService, "grpc-service"},
+	{constants.WalletNode, "bridge.port", constants.Bridge, "server.port"},
+}
+
+func (v *configValidator) validatePortRefs() {
+	for _, ref := range configPortRefs {
+		source, ok := v.ports[string(ref.tool)+":"+ref.key]
+		if !ok {
+			continue
+		}
+		target, ok := v.ports[string(ref.targetTool)+":"+ref.targetKey]
+		if ok && source.port != target.port {
+			v.errorf(source.file, source.line, "%s %d doesn't match %s %d (%s:%d)",
+				ref.key, source.port, target.key, target.port, target.file, target.line)
+		}
+	}
+}
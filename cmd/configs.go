@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -18,15 +19,24 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+// configOverrideTools are components which config can be overridden by user override file
+var configOverrideTools = []string{
+	string(constants.SuperNode),
+	string(constants.Hermes),
+	string(constants.WalletNode),
+	string(constants.Bridge),
+}
+
 // GetSNConfigs returns SN configs
 func GetSNConfigs(config *configs.Config) (string, error) {
 	portList := GetSNPortList(config)
+	ports := getComponentPorts(config)
 
 	snTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
 	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
 	p2pDataPath := filepath.Join(config.WorkingDir, constants.P2PDataDir)
 	mdlDataPath := filepath.Join(config.WorkingDir, constants.MDLDataDir)
-	ddDirPath := filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir)
+	ddDirPath := getDDServiceDir(config)
 
 	toolConfig, err := utils.GetServiceConfig(string(constants.SuperNode), configs.SupernodeDefaultConfig, &configs.SuperNodeConfig{
 		LogFilePath:                     config.Configurer.GetSuperNodeLogFile(config.WorkingDir),
@@ -48,7 +58,8 @@ func GetSNConfigs(config *configs.Config) (string, error) {
 		MDLPort:                         portList[constants.MDLPort],
 		RAFTPort:                        portList[constants.RAFTPort],
 		MDLDataDir:                      mdlDataPath,
-		RaptorqPort:                     constants.RQServiceDefaultPort,
+		RaptorqPort:                     ports[constants.PortNameRQService],
+		DDServerPort:                    ports[constants.PortNameDDServer],
 		NumberOfChallengeReplicas:       constants.NumberOfChallengeReplicas,
 		StorageChallengeExpiredDuration: constants.StorageChallengeExpiredDuration,
 	})
@@ -64,7 +75,7 @@ func GetHermesConfigs(config *configs.Config) (string, error) {
 	portList := GetSNPortList(config)
 
 	snTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
-	ddDirPath := filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir)
+	ddDirPath := getDDServiceDir(config)
 
 	toolConfig, err := utils.GetServiceConfig(string(constants.Hermes), configs.HermesDefaultConfig, &configs.HermesConfig{
 		LogFilePath:    config.Configurer.GetHermesLogFile(config.WorkingDir),
@@ -89,6 +100,247 @@ func GetHermesConfigs(config *configs.Config) (string, error) {
 	return toolConfig, nil
 }
 
+// GetWNConfigs returns walletnode configs
+func GetWNConfigs(config *configs.Config, bridgeOn bool) (string, error) {
+	wnTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
+	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
+	ports := getComponentPorts(config)
+
+	toolConfig, err := utils.GetServiceConfig(string(constants.WalletNode), configs.WalletDefaultConfig, &configs.WalletNodeConfig{
+		LogLevel:      constants.WalletNodeDefaultLogLevel,
+		LogFilePath:   config.Configurer.GetWalletNodeLogFile(config.WorkingDir),
+		LogCompress:   constants.LogConfigDefaultCompress,
+		LogMaxSizeMB:  constants.LogConfigDefaultMaxSizeMB,
+		LogMaxAgeDays: constants.LogConfigDefaultMaxAgeDays,
+		LogMaxBackups: constants.LogConfigDefaultMaxBackups,
+		WNTempDir:     wnTempDirPath,
+		WNWorkDir:     config.WorkingDir,
+		RQDir:         rqWorkDirPath,
+		BurnAddress:   getBurnAddress(config),
+		RaptorqPort:   ports[constants.PortNameRQService],
+		BridgePort:    ports[constants.PortNameBridge],
+		APIPort:       ports[constants.PortNameWalletNodeAPI],
+		BridgeOn:      bridgeOn,
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get walletnode config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+// GetBridgeConfigs returns bridge configs
+func GetBridgeConfigs(config *configs.Config) (string, error) {
+	wnTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
+
+	toolConfig, err := utils.GetServiceConfig(string(constants.Bridge), configs.BridgeDefaultConfig, &configs.BridgeConfig{
+		LogLevel:           constants.WalletNodeDefaultLogLevel,
+		LogFilePath:        config.Configurer.GetBridgeLogFile(config.WorkingDir),
+		LogCompress:        constants.LogConfigDefaultCompress,
+		LogMaxSizeMB:       constants.LogConfigDefaultMaxSizeMB,
+		LogMaxAgeDays:      constants.LogConfigDefaultMaxAgeDays,
+		LogMaxBackups:      constants.LogConfigDefaultMaxBackups,
+		WNTempDir:          wnTempDirPath,
+		WNWorkDir:          config.WorkingDir,
+		BurnAddress:        getBurnAddress(config),
+		ConnRefreshTimeout: 300,
+		Connections:        10,
+		ListenAddress:      "127.0.0.1",
+		Port:               getComponentPort(config, constants.PortNameBridge),
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get bridge config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+// GetRQServiceConfigs returns rq-service configs
+func GetRQServiceConfigs(config *configs.Config) (string, error) {
+	toolConfig, err := utils.GetServiceConfig(string(constants.RQService), configs.RQServiceDefaultConfig, &configs.RQServiceConfig{
+		HostName: "127.0.0.1",
+		Port:     getComponentPort(config, constants.PortNameRQService),
+	})
+	if err != nil {
+		return "", errors.Errorf("failed to get rqservice config: %v", err)
+	}
+
+	return toolConfig, nil
+}
+
+func getBurnAddress(config *configs.Config) string {
+	if config.Network == constants.NetworkTestnet || config.Network == constants.NetworkRegTest {
+		return constants.BurnAddressTestnet
+	}
+	return constants.BurnAddressMainnet
+}
+
+// renderComponentConfig returns config of the component rendered from the default template with user overrides applied
+func renderComponentConfig(ctx context.Context, config *configs.Config, tool constants.ToolType) (string, error) {
+	var toolConfig string
+	var err error
+
+	switch tool {
+	case constants.SuperNode:
+		toolConfig, err = GetSNConfigs(config)
+	case constants.Hermes:
+		toolConfig, err = GetHermesConfigs(config)
+	case constants.WalletNode:
+		toolConfig, err = GetWNConfigs(config, utils.CheckFileExist(config.Configurer.GetBridgeConfFile(config.WorkingDir)))
+	case constants.Bridge:
+		toolConfig, err = GetBridgeConfigs(config)
+	case constants.RQService:
+		toolConfig, err = GetRQServiceConfigs(config)
+	default:
+		return "", errors.Errorf("unknown component - %s", tool)
+	}
+	if err != nil {
+		return "", err
+	}
+	if version, ok := configs.ConfigVersions[string(tool)]; ok {
+		toolConfig = setConfigVersion(toolConfig, version)
+	}
+
+	return applyConfigOverrides(ctx, config, string(tool), toolConfig)
+}
+
+// getConfigOverridePath returns path of the user override file of the component config
+func getConfigOverridePath(config *configs.Config, toolName string) string {
+	return filepath.Join(config.WorkingDir, constants.ConfigOverridesDir, toolName+".yml")
+}
+
+// applyConfigOverrides deep merges user override file (if exists) onto the component config
+func applyConfigOverrides(ctx context.Context, config *configs.Config, toolName string, toolConfig string) (string, error) {
+	if config.DefaultConfigs {
+		return toolConfig, nil
+	}
+
+	overridePath := getConfigOverridePath(config, toolName)
+	if !utils.Contains(configOverrideTools, toolName) {
+		// e.g. rq-service config is TOML, so it can't be merged with YAML override
+		if utils.CheckFileExist(overridePath) {
+			err := errors.Errorf("config overrides are not supported for %s, remove %s and edit its config directly", toolName, overridePath)
+			log.WithContext(ctx).WithError(err).Error("Failed to apply config overrides")
+			return "", err
+		}
+		return toolConfig, nil
+	}
+
+	override, err := ioutil.ReadFile(overridePath)
+	if os.IsNotExist(err) {
+		return toolConfig, nil
+	} else if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to read config override file - %s", overridePath)
+		return "", err
+	}
+
+	merged, err := utils.MergeYAML([]byte(toolConfig), override)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to apply config override file - %s", overridePath)
+		return "", err
+	}
+	log.WithContext(ctx).Infof("Applied config overrides from %s", overridePath)
+
+	// comments are lost during merge, so keep the template version
+	if version, ok := getConfigVersion(toolConfig); ok {
+		return setConfigVersion(string(merged), version), nil
+	}
+	return string(merged), nil
+}
+
+// applyConfigOverridesToFile deep merges user override file (if exists) onto the existing component config file
+func applyConfigOverridesToFile(ctx context.Context, config *configs.Config, toolName string, configFilePath string) error {
+	if !utils.CheckFileExist(getConfigOverridePath(config, toolName)) || !utils.CheckFileExist(configFilePath) {
+		return nil
+	}
+
+	toolConfig, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to read %s file", configFilePath)
+		return err
+	}
+
+	merged, err := applyConfigOverrides(ctx, config, toolName, string(toolConfig))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.WriteFile(configFilePath, merged); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to write config to %s file", configFilePath)
+		return err
+	}
+	return nil
+}
+
+// getConfigVersion returns template version from the header of the component config
+func getConfigVersion(toolConfig string) (int, bool) {
+	for _, line := range strings.Split(toolConfig, "\n") {
+		if strings.HasPrefix(line, configs.ConfigVersionHeader) {
+			version, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, configs.ConfigVersionHeader)))
+			return version, err == nil
+		}
+	}
+	return 0, false
+}
+
+// setConfigVersion puts template version header to the component config, replacing existing one
+func setConfigVersion(toolConfig string, version int) string {
+	var lines []string
+	for _, line := range strings.Split(toolConfig, "\n") {
+		if !strings.HasPrefix(line, configs.ConfigVersionHeader) {
+			lines = append(lines, line)
+		}
+	}
+	return fmt.Sprintf("%s%d\n%s", configs.ConfigVersionHeader, version, strings.TrimLeft(strings.Join(lines, "\n"), "\n"))
+}
+
+// migrateComponentConfigFile migrates existing component config file to the current template version:
+// moves renamed keys and adds new keys with default values from toolConfig. Old file is kept as a backup
+func migrateComponentConfigFile(ctx context.Context, config *configs.Config, toolName string, configFilePath string, toolConfig string) error {
+	currentVersion, ok := configs.ConfigVersions[toolName]
+	if !ok || !utils.CheckFileExist(configFilePath) {
+		return nil
+	}
+
+	oldConfig, err := ioutil.ReadFile(configFilePath)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to read %s file", configFilePath)
+		return err
+	}
+
+	// configs generated before versioning have no header
+	fileVersion, _ := getConfigVersion(string(oldConfig))
+	if fileVersion >= currentVersion {
+		return nil
+	}
+
+	migrated, changes, err := utils.MigrateYAML(oldConfig, []byte(toolConfig), configs.GetConfigRenames(toolName, fileVersion))
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to migrate %s file", configFilePath)
+		return err
+	}
+	newConfig := setConfigVersion(string(migrated), currentVersion)
+
+	log.WithContext(ctx).Infof("Migrating %s config from version %d to version %d", toolName, fileVersion, currentVersion)
+	for _, change := range changes {
+		log.WithContext(ctx).Infof("  %s", change)
+	}
+	fmt.Println(strings.Join(utils.DiffLines(string(oldConfig), newConfig), "\n"))
+
+	backupPath := fmt.Sprintf("%s.bak.%s", configFilePath, time.Now().Format("20060102-150405"))
+	if err = ioutil.WriteFile(backupPath, oldConfig, 0644); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to backup %s file", configFilePath)
+		return err
+	}
+
+	if err = utils.WriteFile(configFilePath, newConfig); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to write config to %s file", configFilePath)
+		return err
+	}
+	log.WithContext(ctx).Infof("%s config migrated, previous version is saved to %s", toolName, backupPath)
+	return nil
+}
+
 func checkBridgeConfigPastelID(ctx context.Context, config *configs.Config, confPath string) error {
 	bridgeConfFile, err := ioutil.ReadFile(confPath)
 	if err != nil {
@@ -191,6 +443,9 @@ func checkBridgeConfigPastelID(ctx context.Context, config *configs.Config, conf
 		log.WithContext(ctx).WithError(err).Errorf("Failed to unparse yml for bridge.yml file at - %s", confPath)
 		return err
 	}
+	if version, ok := getConfigVersion(string(bridgeConfFile)); ok {
+		bridgeConfFileUpdated = []byte(setConfigVersion(string(bridgeConfFileUpdated), version))
+	}
 
 	if ioutil.WriteFile(confPath, bridgeConfFileUpdated, 0644) != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update bridge.yml file at - %s", confPath)
@@ -0,0 +1,454 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/gonode/common/version"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	diagnoseCmdTimeout = 30 * time.Second
+	// remoteDiagnoseBundle is where remote pastelup writes the bundle before it is downloaded
+	remoteDiagnoseBundle = "$HOME/.pastelup/pastelup-diagnose.tar.gz"
+)
+
+var (
+	flagDiagnoseFile     string
+	flagDiagnoseLogLines int
+	flagDiagnoseDest     string
+)
+
+// redactedConfKeys are secrets removed from pastel.conf and configs of the components
+var redactedConfKeys = []string{"rpcpassword", "masternodeprivkey", "pass_phrase", "passphrase", "password"}
+
+// redactedArgNames are secret arguments removed from everything added to the bundle: process list,
+// output of the commands, journal and logs
+var redactedArgNames = append([]string{"rpcauth", "user-pw", "ssh-user-pw"}, redactedConfKeys...)
+
+// diagnoseBundle collects files of the support bundle in the staging directory,
+// failure to collect an item doesn't stop the others, it is reported in errors.txt of the bundle
+type diagnoseBundle struct {
+	dir    string
+	errors []string
+}
+
+func (b *diagnoseBundle) add(ctx context.Context, name string, data []byte) {
+	data = utils.RedactArgs(data, redactedArgNames)
+	filePath := filepath.Join(b.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
+		b.fail(ctx, name, err)
+		return
+	}
+	if err := ioutil.WriteFile(filePath, data, 0600); err != nil {
+		b.fail(ctx, name, err)
+	}
+}
+
+func (b *diagnoseBundle) addJSON(ctx context.Context, name string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.fail(ctx, name, err)
+		return
+	}
+	b.add(ctx, name, append(data, '\n'))
+}
+
+func (b *diagnoseBundle) fail(ctx context.Context, item string, err error) {
+	log.WithContext(ctx).WithError(err).Warnf("Failed to collect %s", item)
+	b.errors = append(b.errors, fmt.Sprintf("%s: %v", item, err))
+}
+
+func setupDiagnoseSubCommand(config *configs.Config, remote bool,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("log-lines", &flagDiagnoseLogLines).SetAliases("n").
+			SetUsage(green("Optional, number of the last lines of every log file to collect")).SetValue(1000),
+	}
+
+	if !remote {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+			cli.NewFlag("file", &flagDiagnoseFile).SetAliases("f").
+				SetUsage(green("Optional, path of the bundle (default: pastelup-diagnose-<host>-<time>.tar.gz in the current directory)")),
+		)
+	} else {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory on the remote computer (default: $HOME/pastel)")),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of working directory on the remote computer (default: $HOME/.pastel)")),
+			cli.NewFlag("dest", &flagDiagnoseDest).SetAliases("o").
+				SetUsage(green("Optional, local directory where bundles of the remote hosts are stored")).SetValue("."),
+			cli.NewFlag("ssh-ip", &config.RemoteIP).
+				SetUsage(red("Required (if `inventory` is not used), SSH address of the remote host")),
+			cli.NewFlag("ssh-port", &config.RemotePort).
+				SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
+			cli.NewFlag("ssh-user", &config.RemoteUser).
+				SetUsage(yellow("Optional, Username of user at remote host")),
+			cli.NewFlag("ssh-key", &config.RemoteSSHKey).
+				SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
+		)
+		commandFlags = append(commandFlags, getInventoryFlags(config)...)
+	}
+
+	commandName := "diagnose"
+	commandMessage := "Collects versions, configs, status and logs of pastel components to one bundle for a bug report"
+	if remote {
+		commandName = "remote"
+		commandMessage = "Collects diagnose bundles of the remote hosts"
+	}
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
+	addLogFlags(subCommand, config)
+
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, commandName, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if !remote {
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		if !remote {
+			// bundle is still useful without pastel.conf, e.g. when the installation failed
+			if err = ParsePastelConf(ctx, config); err != nil {
+				log.WithContext(ctx).WithError(err).Warn("Failed to parse pastel.conf, node status won't be collected")
+			}
+		}
+
+		log.WithContext(ctx).Info("Started")
+		if err = f(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return subCommand
+}
+
+func setupDiagnoseCommand(config *configs.Config) *cli.Command {
+	diagnoseCommand := setupDiagnoseSubCommand(config, false, runDiagnose)
+	diagnoseCommand.AddSubcommands(setupDiagnoseSubCommand(config, true, runRemoteDiagnose))
+	return diagnoseCommand
+}
+
+func runDiagnose(ctx context.Context, config *configs.Config) error {
+	hostName, _ := os.Hostname()
+	name := fmt.Sprintf("pastelup-diagnose-%s-%s", hostName, time.Now().Format("20060102-150405"))
+	bundlePath := flagDiagnoseFile
+	if len(bundlePath) == 0 {
+		bundlePath = name + ".tar.gz"
+	}
+
+	stagingDir, err := ioutil.TempDir("", "pastelup-diagnose")
+	if err != nil {
+		return errors.Errorf("failed to create temp directory: %v", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	b := &diagnoseBundle{dir: filepath.Join(stagingDir, name)}
+	collectDiagnose(ctx, config, b)
+	if len(b.errors) != 0 {
+		b.add(ctx, "errors.txt", []byte(strings.Join(b.errors, "\n")+"\n"))
+	}
+
+	if err = utils.CreateTarGz(bundlePath, stagingDir, []string{name}); err != nil {
+		return errors.Errorf("failed to create %s: %v", bundlePath, err)
+	}
+	// bundle has logs and host details, it is only for the user to share
+	if err = os.Chmod(bundlePath, 0600); err != nil {
+		return err
+	}
+	if len(b.errors) != 0 {
+		log.WithContext(ctx).Warnf("%d items failed to be collected, see errors.txt in the bundle", len(b.errors))
+	}
+	log.WithContext(ctx).Infof("Diagnose bundle is saved to %s, secrets are removed from configs, arguments and logs, "+
+		"but please review it before sharing", bundlePath)
+	return nil
+}
+
+// collectDiagnose adds everything needed for a bug report to the bundle
+func collectDiagnose(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	hostName, _ := os.Hostname()
+	b.add(ctx, "pastelup.txt", []byte(fmt.Sprintf(
+		"pastelup version: %s\nplatform: %s/%s\ncollected at: %s\nhost: %s\ninstance: %s\nnetwork: %s\npastel dir: %s\nworking dir: %s\n",
+		version.Version(), runtime.GOOS, runtime.GOARCH, time.Now().Format(time.RFC3339), hostName,
+		config.Instance, config.Network, config.PastelExecDir, config.WorkingDir)))
+
+	log.WithContext(ctx).Info("Collecting host info...")
+	b.addJSON(ctx, "host.json", systemInfo{
+		HostName:   hostName,
+		OS:         string(utils.GetOS()),
+		MemInfo:    getMemoryInfo(),
+		FsInfo:     getFSInfo(),
+		ProcInfo:   redactProcessArgs(getPastelProcesses()),
+		WorkingDir: config.WorkingDir,
+	})
+	b.addJSON(ctx, "versions.json", getComponentVersions(ctx, config))
+
+	log.WithContext(ctx).Info("Collecting configs...")
+	collectDiagnoseConfigs(ctx, config, b)
+
+	log.WithContext(ctx).Info("Collecting node status...")
+	collectDiagnoseNodeStatus(ctx, config, b)
+
+	log.WithContext(ctx).Info("Collecting services status...")
+	collectDiagnoseServices(ctx, config, b)
+
+	log.WithContext(ctx).Info("Collecting logs...")
+	collectDiagnoseLogs(ctx, config, b)
+
+	log.WithContext(ctx).Info("Collecting disk usage and ports...")
+	collectDiagnoseDiskAndPorts(ctx, config, b)
+}
+
+// redactProcessArgs removes secrets from the command lines of the processes, e.g. masternode private key of pasteld
+func redactProcessArgs(processes []processInfo) []processInfo {
+	for i := range processes {
+		processes[i].Args = utils.RedactArgList(processes[i].Args, redactedArgNames)
+	}
+	return processes
+}
+
+func collectDiagnoseConfigs(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	confFiles := []string{
+		filepath.Join(config.WorkingDir, constants.PastelConfName),
+		config.Configurer.GetSuperNodeConfFile(config.WorkingDir),
+		config.Configurer.GetHermesConfFile(config.WorkingDir),
+		config.Configurer.GetBridgeConfFile(config.WorkingDir),
+		config.Configurer.GetWalletNodeConfFile(config.WorkingDir),
+		config.Configurer.GetRQServiceConfFile(config.WorkingDir),
+	}
+	for _, confFile := range confFiles {
+		data, err := ioutil.ReadFile(confFile)
+		if os.IsNotExist(err) {
+			// component is not installed
+			continue
+		} else if err != nil {
+			b.fail(ctx, confFile, err)
+			continue
+		}
+		b.add(ctx, "configs/"+filepath.Base(confFile), utils.RedactConfig(data, redactedConfKeys))
+	}
+}
+
+func collectDiagnoseNodeStatus(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	client := pastelcore.NewClient(config)
+	requests := []struct {
+		name string
+		cmd  string
+		args []string
+	}{
+		{"getinfo.json", pastelcore.GetInfoCmd, nil},
+		{"mnsync-status.json", pastelcore.MasterNodeSyncCmd, []string{"status"}},
+		{"masternode-status.json", pastelcore.MasterNodeCmd, []string{"status"}},
+	}
+	for _, r := range requests {
+		var result interface{}
+		var err error
+		if r.args == nil {
+			err = client.RunCommand(r.cmd, &result)
+		} else {
+			err = client.RunCommandWithArgs(r.cmd, r.args, &result)
+		}
+		if err != nil {
+			b.fail(ctx, "node/"+r.name, err)
+			continue
+		}
+		b.addJSON(ctx, "node/"+r.name, result)
+	}
+}
+
+func collectDiagnoseServices(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	sm, err := NewServiceManager(config)
+	if err != nil {
+		b.fail(ctx, "services", err)
+		return
+	}
+
+	collected := make(map[constants.ToolType]bool)
+	for _, app := range installServiceFlag {
+		tool := toolToToolType[app]
+		if collected[tool] {
+			continue
+		}
+		collected[tool] = true
+
+		var out []byte
+		for _, args := range getServiceStatusCommands(ctx, sm, tool) {
+			cmdOut, _ := getCommandOutput(ctx, args[0], args[1:]...)
+			out = append(out, fmt.Sprintf("$ %s\n", strings.Join(args, " "))...)
+			out = append(out, cmdOut...)
+			out = append(out, '\n')
+		}
+		if len(out) != 0 {
+			b.add(ctx, "services/"+sm.ServiceName(tool)+".txt", out)
+		}
+	}
+}
+
+// getServiceStatusCommands returns commands showing status and the last log lines of the service,
+// nothing if the service isn't registered
+func getServiceStatusCommands(ctx context.Context, sm ServiceManager, tool constants.ToolType) [][]string {
+	name := sm.ServiceName(tool)
+	lines := fmt.Sprint(flagDiagnoseLogLines)
+	switch m := sm.(type) {
+	case LinuxSystemdManager:
+		if !m.hasUnit(ctx, tool) {
+			return nil
+		}
+		return [][]string{
+			append(append([]string{"systemctl"}, m.userArgs()...), "status", "--no-pager", "-l", name),
+			append(append([]string{"journalctl"}, m.userArgs()...), "-u", name, "-n", lines, "--no-pager"),
+		}
+	case SupervisordManager:
+		if !utils.CheckFileExist(m.programPath(tool)) {
+			return nil
+		}
+		return [][]string{{"supervisorctl", "status", name}}
+	case OpenRCManager:
+		if !utils.CheckFileExist(m.scriptPath(tool)) {
+			return nil
+		}
+		return [][]string{{"rc-service", name, "status"}}
+	}
+	return nil
+}
+
+func collectDiagnoseLogs(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	for _, tool := range logComponents {
+		logFile := getLogFile(config, tool)
+		data, err := utils.TailFile(logFile, flagDiagnoseLogLines)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			b.fail(ctx, logFile, err)
+			continue
+		}
+		b.add(ctx, "logs/"+filepath.Base(logFile), data)
+	}
+}
+
+func collectDiagnoseDiskAndPorts(ctx context.Context, config *configs.Config, b *diagnoseBundle) {
+	var disk []byte
+	for _, args := range [][]string{{"df", "-h"}, {"du", "-sh", config.WorkingDir, config.PastelExecDir}} {
+		out, _ := getCommandOutput(ctx, args[0], args[1:]...)
+		disk = append(disk, fmt.Sprintf("$ %s\n%s\n", strings.Join(args, " "), out)...)
+	}
+	b.add(ctx, "disk.txt", disk)
+
+	// first available tool lists the listening ports
+	for _, args := range [][]string{{"ss", "-tlnp"}, {"netstat", "-tlnp"}, {"lsof", "-nP", "-iTCP", "-sTCP:LISTEN"}} {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		out, _ := getCommandOutput(ctx, args[0], args[1:]...)
+		b.add(ctx, "listening-ports.txt", []byte(fmt.Sprintf("$ %s\n%s", strings.Join(args, " "), out)))
+		break
+	}
+
+	type portStatus struct {
+		Name      string `json:"name"`
+		Component string `json:"component"`
+		Port      int    `json:"port"`
+		Listener  string `json:"listener,omitempty"`
+	}
+	var ports []portStatus
+	componentPorts := getComponentPorts(config)
+	for _, name := range constants.PortNames {
+		status := portStatus{Name: name, Component: string(constants.PortOwners[name]), Port: componentPorts[name]}
+		if listener := utils.GetPortListener(status.Port); listener != nil {
+			status.Listener = listener.String()
+		}
+		ports = append(ports, status)
+	}
+	b.addJSON(ctx, "ports.json", ports)
+}
+
+// getCommandOutput runs the command without echo, output is returned even if the command failed
+func getCommandOutput(ctx context.Context, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, diagnoseCmdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		out = append(out, fmt.Sprintf("(%v)\n", err)...)
+	}
+	return out, err
+}
+
+func runRemoteDiagnose(ctx context.Context, config *configs.Config) error {
+	getDiagnoseCommand := func() string {
+		diagnoseOptions := fmt.Sprintf(" --file %s --log-lines %d", remoteDiagnoseBundle, flagDiagnoseLogLines)
+		if len(config.PastelExecDir) > 0 {
+			diagnoseOptions = fmt.Sprintf("%s --dir %s", diagnoseOptions, config.PastelExecDir)
+		}
+		if len(config.WorkingDir) > 0 {
+			diagnoseOptions = fmt.Sprintf("%s --work-dir %s", diagnoseOptions, config.WorkingDir)
+		}
+		if config.Quiet {
+			diagnoseOptions = fmt.Sprintf("%s -q", diagnoseOptions)
+		}
+		if len(config.LogLevel) > 0 {
+			diagnoseOptions = fmt.Sprintf("%s --log-level %s", diagnoseOptions, config.LogLevel)
+		}
+		return fmt.Sprintf("%s diagnose%s", constants.RemotePastelupPath, diagnoseOptions)
+	}
+
+	if err := os.MkdirAll(flagDiagnoseDest, 0755); err != nil {
+		return errors.Errorf("failed to create %s: %v", flagDiagnoseDest, err)
+	}
+	return forEachRemoteHost(ctx, config, prepareRemoteSession, func(host string, client *utils.Client) error {
+		if result := runRemoteCommand(ctx, client, host, getDiagnoseCommand(), false); result.failed() {
+			return errors.Errorf("diagnose failed on %s: %s", host, result.Error)
+		}
+		defer client.Cmd(fmt.Sprintf(`rm -f "%s"`, remoteDiagnoseBundle)).Run()
+
+		bundlePath := filepath.Join(flagDiagnoseDest,
+			fmt.Sprintf("pastelup-diagnose-%s-%s.tar.gz", getHostFileName(host), time.Now().Format("20060102-150405")))
+		if err := client.ScpFrom(remoteDiagnoseBundle, bundlePath); err != nil {
+			return errors.Errorf("failed to download diagnose bundle from %s: %v", host, err)
+		}
+		if err := os.Chmod(bundlePath, 0600); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("Diagnose bundle of %s is saved to %s", host, bundlePath)
+		return nil
+	})
+}
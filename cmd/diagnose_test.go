@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/tj/assert"
+)
+
+func TestDiagnoseBundleHasNoSecrets(t *testing.T) {
+	const (
+		privKey     = "5KbPrivKeyOfTheMasternode"
+		rpcPassword = "RpcPasswordOfTheNode"
+		userPw      = "SudoPasswordOfTheUser"
+	)
+	ctx := context.Background()
+	stagingDir := t.TempDir()
+	b := &diagnoseBundle{dir: filepath.Join(stagingDir, "bundle")}
+
+	b.addJSON(ctx, "host.json", systemInfo{
+		ProcInfo: redactProcessArgs([]processInfo{{
+			Process: "pasteld",
+			Args:    []string{"/home/user/pastel/pasteld", "--masternode", "--masternodeprivkey=" + privKey, "-rpcpassword=" + rpcPassword},
+		}, {
+			Process: "pastelup",
+			Args:    []string{"./pastelup", "update", "node", "--user-pw", userPw},
+		}}),
+	})
+	b.add(ctx, "services/pasteld.service.txt", []byte(
+		"$ systemctl status --no-pager -l pasteld.service\n"+
+			"   CGroup: /system.slice/pasteld.service\n"+
+			"           └─1234 /home/user/pastel/pasteld --externalip=1.2.3.4 --masternodeprivkey="+privKey+" --txindex=1\n"+
+			"$ journalctl -u pasteld.service -n 1000 --no-pager\n"+
+			"Mar 15 12:00:00 host pasteld[1234]: Command-line arg: rpcpassword="+rpcPassword+"\n"))
+	b.add(ctx, "configs/pastel.conf", utils.RedactConfig([]byte("rpcuser=user\nrpcpassword="+rpcPassword+"\n"), redactedConfKeys))
+
+	bundlePath := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	assert.Nil(t, utils.CreateTarGz(bundlePath, stagingDir, []string{"bundle"}))
+
+	f, err := os.Open(bundlePath)
+	assert.Nil(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	tr := tar.NewReader(gz)
+
+	var files int
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		files++
+		data, err := ioutil.ReadAll(tr)
+		assert.Nil(t, err)
+		for _, secret := range []string{privKey, rpcPassword, userPw} {
+			assert.False(t, strings.Contains(string(data), secret), "%s contains secret %s", hdr.Name, secret)
+		}
+		assert.True(t, strings.Contains(string(data), "<redacted>"), "%s has nothing redacted", hdr.Name)
+	}
+	assert.Equal(t, 3, files)
+}
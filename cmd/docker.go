@@ -0,0 +1,412 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/configurer"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type dockerCommand uint8
+
+const (
+	dockerNode dockerCommand = iota
+	dockerWalletNode
+	dockerSuperNode
+)
+
+var (
+	dockerCmdName = map[dockerCommand]string{
+		dockerNode:       "node",
+		dockerWalletNode: "walletnode",
+		dockerSuperNode:  "supernode",
+	}
+	dockerCmdMessage = map[dockerCommand]string{
+		dockerNode:       "Generate docker-compose project of Pastel node",
+		dockerWalletNode: "Generate docker-compose project of Walletnode",
+		dockerSuperNode:  "Generate docker-compose project of Supernode",
+	}
+	dockerCmdComponent = map[dockerCommand]constants.ToolType{
+		dockerNode:       constants.PastelD,
+		dockerWalletNode: constants.WalletNode,
+		dockerSuperNode:  constants.SuperNode,
+	}
+)
+
+const (
+	// dockerConfigDir is the directory of the generated project with the configs of the components
+	dockerConfigDir  = "config"
+	dockerEnvFile    = ".env"
+	dockerReadmeFile = "readme.txt"
+)
+
+const dockerEnv = `# External IP address of the host, pasteld announces it to the network
+EXTERNAL_IP=
+# Private key of the masternode from masternode.conf, used by supernode only
+MASTERNODE_PRIVKEY=
+`
+
+const dockerReadme = `Generated by "pastelup docker generate %s".
+
+1. Set EXTERNAL_IP (and MASTERNODE_PRIVKEY for supernode) in .env file
+2. Build the image, it installs Pastel %s with pastelup:
+   $ docker compose build
+3. Start the components:
+   $ docker compose up -d
+
+Configs of the components are in ./config directory, they are rendered from the same templates
+as "pastelup install" uses, and mounted into the containers. Supernode and hermes require
+pastel_id and pass_phrase of the registered masternode to be set in their configs.
+Data is kept in the docker volumes: %s.
+`
+
+var flagDockerDest string
+
+func setupDockerGenerateSubCommand(config *configs.Config, dockerCommand dockerCommand) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("dest", &flagDockerDest).
+			SetUsage(green("Optional, directory to generate the project into, default is pastel-<node type>-docker in the current directory")),
+		cli.NewFlag("network", &config.Network).SetAliases("n").
+			SetUsage(green("Optional, network type, can be - \"mainnet\" or \"testnet\"")).SetValue("mainnet"),
+		cli.NewFlag("release", &config.Version).SetAliases("r").
+			SetUsage(green("Optional, Pastel version to install into the image")),
+		cli.NewFlag("peers", &config.Peers).SetAliases("p").
+			SetUsage(green("Optional, List of peers to add into pastel.conf file, must be in the format - \"ip\" or \"ip:port\"")),
+		cli.NewFlag("force", &config.Force).SetAliases("f").
+			SetUsage(green("Optional, Force to overwrite files of the existing project")),
+	}
+
+	commandName := dockerCmdName[dockerCommand]
+	commandMessage := dockerCmdMessage[dockerCommand]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, commandMessage, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		if !utils.IsValidNetworkOpt(config.Network) {
+			return errors.Errorf("invalid --network %q", config.Network)
+		}
+
+		log.WithContext(ctx).Info("Started")
+		if err = runDockerGenerate(ctx, config, commandName, dockerCmdComponent[dockerCommand]); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return subCommand
+}
+
+func setupDockerCommand(config *configs.Config) *cli.Command {
+	generateCommand := cli.NewCommand("generate")
+	generateCommand.SetUsage(cyan("Generate docker-compose project"))
+	generateCommand.AddSubcommands(
+		setupDockerGenerateSubCommand(config, dockerNode),
+		setupDockerGenerateSubCommand(config, dockerWalletNode),
+		setupDockerGenerateSubCommand(config, dockerSuperNode),
+	)
+
+	dockerCommand := cli.NewCommand("docker")
+	dockerCommand.SetUsage(blue("Generate docker deployments of Pastel node, Walletnode and Supernode"))
+	dockerCommand.AddSubcommands(generateCommand)
+
+	return dockerCommand
+}
+
+func runDockerGenerate(ctx context.Context, config *configs.Config, name string, tool constants.ToolType) error {
+	destDir := flagDockerDest
+	if len(destDir) == 0 {
+		destDir = fmt.Sprintf("pastel-%s-docker", name)
+	}
+	if utils.CheckFileExist(filepath.Join(destDir, "docker-compose.yml")) && !config.Force {
+		return errors.Errorf("docker project already exists in %s, use --force to overwrite it", destDir)
+	}
+	if err := os.MkdirAll(filepath.Join(destDir, dockerConfigDir), 0755); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to create %s", destDir)
+		return err
+	}
+
+	container := newContainerConfig(config)
+	services := appToServiceMap[tool]
+	isMasternode := tool == constants.SuperNode
+
+	// configs of the components are mounted over the ones installed into the image
+	var mounts []string
+	for _, service := range services {
+		content, err := renderDockerComponentConfig(ctx, container, service)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to render config of %s", service)
+			return err
+		}
+		path := getDockerConfigPath(container, service)
+		fileName := filepath.Base(path)
+		if service == constants.DDService {
+			fileName = "dd-" + fileName
+		}
+		if err = ioutil.WriteFile(filepath.Join(destDir, dockerConfigDir, fileName), []byte(content), 0644); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to write config of %s", service)
+			return err
+		}
+		mounts = append(mounts, fmt.Sprintf("./%s/%s:%s", dockerConfigDir, fileName, path))
+	}
+
+	// data volumes
+	volumes := []string{"pastel-work"}
+	volumeMounts := []string{"pastel-work:" + container.WorkingDir}
+	if utils.ContainsToolType(services, constants.RQService) {
+		volumes = append(volumes, "pastel-rqfiles")
+		volumeMounts = append(volumeMounts, "pastel-rqfiles:"+filepath.Join(container.WorkingDir, constants.RQServiceDir))
+	}
+	if isMasternode {
+		volumes = append(volumes, "pastel-p2pdata", "pastel-mdldata")
+		volumeMounts = append(volumeMounts,
+			"pastel-p2pdata:"+filepath.Join(container.WorkingDir, constants.P2PDataDir),
+			"pastel-mdldata:"+filepath.Join(container.WorkingDir, constants.MDLDataDir))
+	}
+	if utils.ContainsToolType(services, constants.DDService) {
+		volumes = append(volumes, "pastel-dd")
+		volumeMounts = append(volumeMounts, "pastel-dd:"+getDDServiceDir(container))
+	}
+
+	// services of the components, they share network of pasteld, so components talk to each other via localhost as on the host
+	compose := configs.DockerComposeScript{
+		Image:   fmt.Sprintf("pastel-%s:%s", name, getDockerImageTag(config)),
+		Mounts:  append(volumeMounts, mounts...),
+		Volumes: volumes,
+	}
+	for _, service := range services {
+		command, err := json.Marshal(getContainerCommand(container, service, isMasternode))
+		if err != nil {
+			return err
+		}
+		composeService := configs.DockerComposeService{
+			Name:    string(service),
+			Command: string(command),
+		}
+		if service == constants.PastelD {
+			composeService.Ports = getDockerPublishedPorts(container, tool)
+		} else {
+			composeService.NetworkMode = "service:" + string(constants.PastelD)
+		}
+		for _, dep := range getStartDependencies(service) {
+			if utils.ContainsToolType(services, dep) {
+				composeService.DependsOn = append(composeService.DependsOn, string(dep))
+			}
+		}
+		compose.Services = append(compose.Services, composeService)
+	}
+	composeFile, err := utils.GetServiceConfig("docker-compose", configs.DockerCompose, &compose)
+	if err != nil {
+		return err
+	}
+
+	dockerfile, err := utils.GetServiceConfig("Dockerfile", configs.Dockerfile, &configs.DockerfileScript{
+		Component: name,
+		Network:   config.Network,
+		Release:   config.Version,
+		Peers:     config.Peers,
+		WorkDir:   container.PastelExecDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	projectFiles := map[string]string{
+		"docker-compose.yml": composeFile,
+		"Dockerfile":         dockerfile,
+		dockerReadmeFile:     fmt.Sprintf(dockerReadme, name, name, strings.Join(volumes, ", ")),
+	}
+	if !utils.CheckFileExist(filepath.Join(destDir, dockerEnvFile)) {
+		// .env is filled by user, so it is never overwritten
+		projectFiles[dockerEnvFile] = dockerEnv
+	}
+	for fileName, content := range projectFiles {
+		if err = ioutil.WriteFile(filepath.Join(destDir, fileName), []byte(content), 0644); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to write %s", fileName)
+			return err
+		}
+	}
+
+	// image is built with this pastelup
+	execPath, err := os.Executable()
+	if err != nil {
+		return errors.Errorf("failed to find pastelup executable: %v", err)
+	}
+	if err = utils.CopyFile(ctx, execPath, destDir, "pastelup"); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to copy pastelup")
+		return err
+	}
+
+	log.WithContext(ctx).Infof("Docker project of %s is generated in %s", name, destDir)
+	return nil
+}
+
+// newContainerConfig returns config with the locations inside the docker container, configs of the containers
+// are rendered from the defaults, custom ports and overrides of the host don't apply to them
+func newContainerConfig(config *configs.Config) *configs.Config {
+	container := *config
+	container.DefaultConfigs = true
+	container.Configurer = configurer.NewContainerConfigurer(constants.ContainerHomeDir)
+	container.PastelExecDir = container.Configurer.DefaultPastelExecutableDir()
+	container.WorkingDir = container.Configurer.DefaultWorkingDir()
+	container.ArchiveDir = container.Configurer.DefaultArchiveDir()
+	container.Instance = ""
+	container.RPCPort = getDefaultPorts(config.Network)[constants.PortNameNodeRPC]
+	container.RPCUser = utils.GenerateRandomString(8)
+	container.RPCPwd = utils.GenerateRandomString(15)
+	return &container
+}
+
+// getDockerConfigPath returns location of the component config inside the container
+func getDockerConfigPath(container *configs.Config, tool constants.ToolType) string {
+	switch tool {
+	case constants.PastelD:
+		return filepath.Join(container.WorkingDir, constants.PastelConfName)
+	case constants.RQService:
+		return container.Configurer.GetRQServiceConfFile(container.WorkingDir)
+	case constants.DDService:
+		return getDDConfigFilePath(container)
+	case constants.SuperNode:
+		return container.Configurer.GetSuperNodeConfFile(container.WorkingDir)
+	case constants.Hermes:
+		return container.Configurer.GetHermesConfFile(container.WorkingDir)
+	case constants.WalletNode:
+		return container.Configurer.GetWalletNodeConfFile(container.WorkingDir)
+	case constants.Bridge:
+		return container.Configurer.GetBridgeConfFile(container.WorkingDir)
+	}
+	return ""
+}
+
+// renderDockerComponentConfig returns content of the component config inside the container
+func renderDockerComponentConfig(ctx context.Context, container *configs.Config, tool constants.ToolType) (string, error) {
+	switch tool {
+	case constants.PastelD:
+		return string(getPastelConfig(container)), nil
+	case constants.DDService:
+		var pathList []interface{}
+		for _, configItem := range constants.DupeDetectionConfigs {
+			pathList = append(pathList, filepath.Join(getDDServiceDir(container), configItem))
+		}
+		return fmt.Sprintf(configs.DupeDetectionConfig, pathList...), nil
+	case constants.WalletNode:
+		// bridge is always a part of the walletnode project
+		content, err := GetWNConfigs(container, true)
+		if err != nil {
+			return "", err
+		}
+		if version, ok := configs.ConfigVersions[string(tool)]; ok {
+			content = setConfigVersion(content, version)
+		}
+		return applyConfigOverrides(ctx, container, string(tool), content)
+	}
+	return renderComponentConfig(ctx, container, tool)
+}
+
+// getContainerCommand returns command which runs the component in foreground inside the container
+func getContainerCommand(container *configs.Config, tool constants.ToolType, isMasternode bool) []string {
+	execDir := container.PastelExecDir
+	pastelConfigPath := filepath.Join(container.WorkingDir, constants.PastelConfName)
+
+	switch tool {
+	case constants.PastelD:
+		command := []string{
+			filepath.Join(execDir, constants.PasteldName[constants.Linux]),
+			"--datadir=" + container.WorkingDir,
+			"--externalip=${EXTERNAL_IP:?set EXTERNAL_IP in .env}",
+		}
+		if isMasternode {
+			command = append(command, "--txindex=1", "--masternode",
+				"--masternodeprivkey=${MASTERNODE_PRIVKEY:?set MASTERNODE_PRIVKEY in .env}")
+		}
+		return command
+	case constants.RQService:
+		return []string{
+			filepath.Join(execDir, constants.PastelRQServiceExecName[constants.Linux]),
+			"--config-file=" + container.Configurer.GetRQServiceConfFile(container.WorkingDir),
+		}
+	case constants.DDService:
+		return []string{
+			filepath.Join(execDir, constants.DupeDetectionSubFolder, "venv", "bin", "python3"),
+			filepath.Join(execDir, utils.GetDupeDetectionExecName()),
+			getDDConfigFilePath(container),
+		}
+	case constants.SuperNode:
+		return []string{
+			filepath.Join(execDir, constants.SuperNodeExecName[constants.Linux]),
+			"--config-file=" + container.Configurer.GetSuperNodeConfFile(container.WorkingDir),
+			"--pastel-config-file=" + pastelConfigPath,
+		}
+	case constants.Hermes:
+		return []string{
+			filepath.Join(execDir, constants.HermesExecName[constants.Linux]),
+			"--config-file=" + container.Configurer.GetHermesConfFile(container.WorkingDir),
+			"--pastel-config-file=" + pastelConfigPath,
+		}
+	case constants.WalletNode:
+		return []string{
+			filepath.Join(execDir, constants.WalletNodeExecName[constants.Linux]),
+			"--config-file=" + container.Configurer.GetWalletNodeConfFile(container.WorkingDir),
+			"--pastel-config-file=" + pastelConfigPath,
+		}
+	case constants.Bridge:
+		return []string{
+			filepath.Join(execDir, constants.BridgeExecName[constants.Linux]),
+			"--config-file=" + container.Configurer.GetBridgeConfFile(container.WorkingDir),
+			"--pastel-config-file=" + pastelConfigPath,
+		}
+	}
+	return nil
+}
+
+// getDockerPublishedPorts returns ports of the network port list, which are published by the project
+func getDockerPublishedPorts(container *configs.Config, tool constants.ToolType) []int {
+	component := constants.PastelD
+	if tool == constants.SuperNode {
+		component = constants.SuperNode
+	}
+	ports := getDefaultPorts(container.Network)
+	var published []int
+	for _, name := range firewallComponentPorts[component] {
+		if name == constants.PortNameNodeRPC {
+			// rpc is used by the components inside the project only
+			continue
+		}
+		published = append(published, ports[name])
+	}
+	return published
+}
+
+func getDockerImageTag(config *configs.Config) string {
+	if len(config.Version) == 0 {
+		return "latest"
+	}
+	return config.Version
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type firewallCommand uint8
+
+const (
+	firewallStatus firewallCommand = iota
+	firewallOpen
+	firewallClose
+)
+
+var (
+	firewallCmdName = map[firewallCommand]string{
+		firewallStatus: "status",
+		firewallOpen:   "open",
+		firewallClose:  "close",
+	}
+	firewallCmdMessage = map[firewallCommand]string{
+		firewallStatus: "Show firewall rules of Pastel components",
+		firewallOpen:   "Open ports of Pastel components in the firewall",
+		firewallClose:  "Close ports of Pastel components in the firewall",
+	}
+)
+
+var flagFirewallComponent string
+
+// firewallComponents are components, which ports are opened in the firewall, names are values of --component option
+var firewallComponents = map[string]constants.ToolType{
+	"node":      constants.PastelD,
+	"supernode": constants.SuperNode,
+}
+
+// firewallComponentPorts are names of the ports opened in the firewall for the component,
+// pasteld RPC port is internal and is never opened
+var firewallComponentPorts = map[constants.ToolType][]string{
+	constants.PastelD: {
+		constants.PortNameNode,
+	},
+	constants.SuperNode: {
+		constants.PortNameNode,
+		constants.PortNameSuperNode,
+		constants.PortNameP2P,
+		constants.PortNameMetaDB,
+		constants.PortNameRaft,
+	},
+}
+
+func setupFirewallSubCommand(config *configs.Config,
+	fwCommand firewallCommand,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+
+	commonFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("firewall", &config.Firewall).
+			SetUsage(green("Optional, firewall to use, one of: " + strings.Join(FirewallNames, ", ") + ", if omitted, will be detected")),
+		cli.NewFlag("component", &flagFirewallComponent).
+			SetUsage(green("Optional, component which ports to manage, one of: node, supernode, if omitted, installed components are used")),
+		cli.NewFlag("user-pw", &config.UserPw).
+			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+	}
+
+	commandName := firewallCmdName[fwCommand]
+	commandMessage := firewallCmdMessage[fwCommand]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commonFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return fmt.Errorf("failed to configure logging option - %v", err)
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sys.RegisterInterruptHandler(cancel, func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+
+			if err = ParsePastelConf(ctx, config); err != nil {
+				return err
+			}
+
+			log.WithContext(ctx).Info("Started")
+			if err = f(ctx, config); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Finished successfully!")
+			return nil
+		})
+	}
+	return subCommand
+}
+
+func setupFirewallCommand(config *configs.Config) *cli.Command {
+
+	firewallCommand := cli.NewCommand("firewall")
+	firewallCommand.SetUsage(blue("Show, open and close firewall ports of Pastel components"))
+	firewallCommand.AddSubcommands(
+		setupFirewallSubCommand(config, firewallStatus, runFirewallStatus),
+		setupFirewallSubCommand(config, firewallOpen, runFirewallOpen),
+		setupFirewallSubCommand(config, firewallClose, runFirewallClose),
+	)
+
+	return firewallCommand
+}
+
+func runFirewallStatus(ctx context.Context, config *configs.Config) error {
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to find firewall")
+		return err
+	}
+	components, err := getFirewallComponents(config)
+	if err != nil {
+		return err
+	}
+	rules, err := fm.Rules(ctx, config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to get firewall rules")
+		return err
+	}
+	ruleTags := make(map[int]string)
+	for _, rule := range rules {
+		ruleTags[rule.Port] = rule.Tag
+	}
+
+	fmt.Printf("Firewall: %s\n", fm.Name())
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Component", "Port", "Status"})
+	ports := getComponentPorts(config)
+	expected := make(map[int]bool)
+	for _, tool := range components {
+		for _, name := range firewallComponentPorts[tool] {
+			if expected[ports[name]] {
+				continue
+			}
+			expected[ports[name]] = true
+			status := "closed"
+			if _, ok := ruleTags[ports[name]]; ok {
+				status = "open"
+			}
+			table.Append([]string{name, string(tool), strconv.Itoa(ports[name]), status})
+		}
+	}
+	// rules of the ports which are not assigned to the components anymore
+	for _, rule := range rules {
+		if !expected[rule.Port] {
+			table.Append([]string{strings.TrimPrefix(rule.Tag, getFirewallTagPrefix(config)), "", strconv.Itoa(rule.Port), "stale"})
+		}
+	}
+	table.Render()
+	return nil
+}
+
+func runFirewallOpen(ctx context.Context, config *configs.Config) error {
+	components, err := getFirewallComponents(config)
+	if err != nil {
+		return err
+	}
+	for _, tool := range components {
+		if err = openFirewallPorts(ctx, config, tool); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to open ports of %s", tool)
+			return err
+		}
+	}
+	return nil
+}
+
+func runFirewallClose(ctx context.Context, config *configs.Config) error {
+	if len(flagFirewallComponent) == 0 {
+		if err := closeFirewallRules(ctx, config); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to close ports")
+			return err
+		}
+		return nil
+	}
+
+	components, err := getFirewallComponents(config)
+	if err != nil {
+		return err
+	}
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to find firewall")
+		return err
+	}
+	ports := getComponentPorts(config)
+	for _, tool := range components {
+		for _, name := range firewallComponentPorts[tool] {
+			log.WithContext(ctx).Infof("Closing port %d (%s) with %s", ports[name], name, fm.Name())
+			if err = fm.Close(ctx, config, ports[name], getFirewallTag(config, name)); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("Failed to close port %d", ports[name])
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getFirewallComponents returns component set by --component option or installed components
+func getFirewallComponents(config *configs.Config) ([]constants.ToolType, error) {
+	if len(flagFirewallComponent) != 0 {
+		tool, ok := firewallComponents[flagFirewallComponent]
+		if !ok {
+			return nil, errors.Errorf("invalid --component %q, must be one of: node, supernode", flagFirewallComponent)
+		}
+		return []constants.ToolType{tool}, nil
+	}
+	if utils.CheckFileExist(config.Configurer.GetSuperNodeConfFile(config.WorkingDir)) {
+		return []constants.ToolType{constants.SuperNode}, nil
+	}
+	return []constants.ToolType{constants.PastelD}, nil
+}
@@ -0,0 +1,430 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	firewallUfw       = "ufw"
+	firewallFirewalld = "firewalld"
+	firewallNftables  = "nftables"
+	firewallIptables  = "iptables"
+
+	// firewallTagPrefix starts comments of the rules added by pastelup
+	firewallTagPrefix = "pastelup"
+
+	nftTable = "filter"
+	nftChain = "input"
+)
+
+// FirewallNames are supported firewall backends in the order of auto-detection
+var FirewallNames = []string{firewallFirewalld, firewallUfw, firewallNftables, firewallIptables}
+
+// FirewallRule is a rule added by pastelup, which allows incoming TCP connections to the port
+type FirewallRule struct {
+	Port int
+	Tag  string
+}
+
+// FirewallManager opens and closes ports in the firewall of the host, rules are tagged so they can be found and removed later
+type FirewallManager interface {
+	Name() string
+	Open(context.Context, *configs.Config, int, string) error
+	Close(context.Context, *configs.Config, int, string) error
+	Rules(context.Context, *configs.Config) ([]FirewallRule, error)
+}
+
+// NewFirewallManager returns firewall manager set by --firewall option or detected on the host
+func NewFirewallManager(config *configs.Config) (FirewallManager, error) {
+	if utils.GetOS() != constants.Linux {
+		return nil, errors.Errorf("firewall management is not supported on %v", utils.GetOS())
+	}
+
+	name := config.Firewall
+	if len(name) == 0 {
+		name = detectFirewall()
+		if len(name) == 0 {
+			return nil, errors.Errorf("none of supported firewalls found: %s", strings.Join(FirewallNames, ", "))
+		}
+	}
+
+	switch name {
+	case firewallUfw:
+		return UfwManager{}, nil
+	case firewallFirewalld:
+		return FirewalldManager{}, nil
+	case firewallNftables:
+		return NftablesManager{}, nil
+	case firewallIptables:
+		return IptablesManager{}, nil
+	}
+	return nil, errors.Errorf("unknown firewall %q, supported: %s", name, strings.Join(FirewallNames, ", "))
+}
+
+// detectFirewall returns name of the firewall used on the host
+func detectFirewall() string {
+	if findSystemTool("firewall-cmd") {
+		if out, err := RunCMD("firewall-cmd", "--state"); err == nil && strings.TrimSpace(out) == "running" {
+			return firewallFirewalld
+		}
+	}
+	switch {
+	case findSystemTool("ufw"):
+		return firewallUfw
+	case findSystemTool("nft"):
+		return firewallNftables
+	case findSystemTool("iptables"):
+		return firewallIptables
+	}
+	return ""
+}
+
+// getFirewallTagPrefix returns prefix of the tags of the rules added for the instance
+func getFirewallTagPrefix(config *configs.Config) string {
+	if len(config.Instance) != 0 {
+		return firewallTagPrefix + "_" + config.Instance + "."
+	}
+	return firewallTagPrefix + "."
+}
+
+// getFirewallTag returns tag of the rule opening the port with the name, e.g. pastelup.supernode
+func getFirewallTag(config *configs.Config, portName string) string {
+	return getFirewallTagPrefix(config) + portName
+}
+
+func filterInstanceRules(config *configs.Config, rules []FirewallRule) []FirewallRule {
+	var res []FirewallRule
+	seen := make(map[FirewallRule]bool)
+	for _, rule := range rules {
+		if strings.HasPrefix(rule.Tag, getFirewallTagPrefix(config)) && !seen[rule] {
+			seen[rule] = true
+			res = append(res, rule)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Port < res[j].Port })
+	return res
+}
+
+///// ufw
+
+// UfwManager manages ports with ufw, tags are kept in the rule comments
+type UfwManager struct{}
+
+// Name returns name of the firewall
+func (fm UfwManager) Name() string {
+	return firewallUfw
+}
+
+// Open adds rule allowing the port
+func (fm UfwManager) Open(_ context.Context, config *configs.Config, port int, tag string) error {
+	if out, err := RunSudoCMD(config, "ufw", "allow", fmt.Sprintf("%d/tcp", port), "comment", tag); err != nil {
+		return errors.Errorf("ufw allow %d: %v: %s", port, err, out)
+	}
+	return nil
+}
+
+// Close deletes rule allowing the port if it has the tag, rules added by the user are kept
+func (fm UfwManager) Close(ctx context.Context, config *configs.Config, port int, tag string) error {
+	rules, err := fm.Rules(ctx, config)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Port != port || rule.Tag != tag {
+			continue
+		}
+		if out, err := RunSudoCMD(config, "ufw", "delete", "allow", fmt.Sprintf("%d/tcp", port)); err != nil {
+			return errors.Errorf("ufw delete allow %d: %v: %s", port, err, out)
+		}
+		return nil
+	}
+	return nil
+}
+
+var ufwRuleRegexp = regexp.MustCompile(`^ufw allow (\d+)(?:/tcp)? comment '?([^' ]+)'?`)
+
+// Rules returns rules added by pastelup, "show added" lists rules even if ufw is inactive
+func (fm UfwManager) Rules(_ context.Context, config *configs.Config) ([]FirewallRule, error) {
+	out, err := RunSudoCMD(config, "ufw", "show", "added")
+	if err != nil {
+		return nil, errors.Errorf("ufw show added: %v: %s", err, out)
+	}
+	var rules []FirewallRule
+	for _, line := range strings.Split(out, "\n") {
+		if m := ufwRuleRegexp.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			port, _ := strconv.Atoi(m[1])
+			rules = append(rules, FirewallRule{Port: port, Tag: m[2]})
+		}
+	}
+	return filterInstanceRules(config, rules), nil
+}
+
+///// firewalld
+
+// FirewalldManager manages ports with firewalld, ports are added to the pastelup service,
+// which is enabled in the default zone, as firewalld rules have no comments
+type FirewalldManager struct{}
+
+// Name returns name of the firewall
+func (fm FirewalldManager) Name() string {
+	return firewallFirewalld
+}
+
+func (fm FirewalldManager) serviceName(config *configs.Config) string {
+	if len(config.Instance) != 0 {
+		return firewallTagPrefix + "-" + config.Instance
+	}
+	return firewallTagPrefix
+}
+
+// Open adds the port to the pastelup service
+func (fm FirewalldManager) Open(_ context.Context, config *configs.Config, port int, _ string) error {
+	service := fm.serviceName(config)
+	if _, err := RunSudoCMD(config, "firewall-cmd", "--permanent", "--info-service="+service); err != nil {
+		if out, err := RunSudoCMD(config, "firewall-cmd", "--permanent", "--new-service="+service); err != nil {
+			return errors.Errorf("firewall-cmd --new-service=%s: %v: %s", service, err, out)
+		}
+	}
+	for _, args := range [][]string{
+		{"--permanent", "--service=" + service, fmt.Sprintf("--add-port=%d/tcp", port)},
+		{"--permanent", "--add-service=" + service},
+		{"--reload"},
+	} {
+		if out, err := RunSudoCMD(config, append([]string{"firewall-cmd"}, args...)...); err != nil {
+			return errors.Errorf("firewall-cmd %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	return nil
+}
+
+// Close removes the port from the pastelup service
+func (fm FirewalldManager) Close(_ context.Context, config *configs.Config, port int, _ string) error {
+	service := fm.serviceName(config)
+	for _, args := range [][]string{
+		{"--permanent", "--service=" + service, fmt.Sprintf("--remove-port=%d/tcp", port)},
+		{"--reload"},
+	} {
+		if out, err := RunSudoCMD(config, append([]string{"firewall-cmd"}, args...)...); err != nil {
+			return errors.Errorf("firewall-cmd %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+	return nil
+}
+
+// Rules returns ports of the pastelup service, tags are restored from the port names of the components
+func (fm FirewalldManager) Rules(_ context.Context, config *configs.Config) ([]FirewallRule, error) {
+	service := fm.serviceName(config)
+	if _, err := RunSudoCMD(config, "firewall-cmd", "--permanent", "--info-service="+service); err != nil {
+		return nil, nil
+	}
+	out, err := RunSudoCMD(config, "firewall-cmd", "--permanent", "--service="+service, "--get-ports")
+	if err != nil {
+		return nil, errors.Errorf("firewall-cmd --get-ports: %v: %s", err, out)
+	}
+
+	portNames := make(map[int]string)
+	for name, port := range getComponentPorts(config) {
+		portNames[port] = name
+	}
+	var rules []FirewallRule
+	for _, item := range strings.Fields(out) {
+		port, err := strconv.Atoi(strings.TrimSuffix(item, "/tcp"))
+		if err != nil {
+			continue
+		}
+		name, ok := portNames[port]
+		if !ok {
+			name = "unknown"
+		}
+		rules = append(rules, FirewallRule{Port: port, Tag: getFirewallTag(config, name)})
+	}
+	return filterInstanceRules(config, rules), nil
+}
+
+///// nftables
+
+// NftablesManager manages ports with nftables, rules are added to the input chain of the inet filter table with comments.
+// Rules are not persisted, use the distribution tools (e.g. nftables.service) to keep them after reboot
+type NftablesManager struct{}
+
+// Name returns name of the firewall
+func (fm NftablesManager) Name() string {
+	return firewallNftables
+}
+
+// Open adds rule accepting the port
+func (fm NftablesManager) Open(_ context.Context, config *configs.Config, port int, tag string) error {
+	if out, err := RunSudoCMD(config, "nft", "add", "rule", "inet", nftTable, nftChain,
+		"tcp", "dport", strconv.Itoa(port), "accept", "comment", tag); err != nil {
+		return errors.Errorf("nft add rule: %v: %s", err, out)
+	}
+	return nil
+}
+
+var nftRuleRegexp = regexp.MustCompile(`tcp dport (\d+) accept comment "([^"]+)" # handle (\d+)`)
+
+// Close deletes rules with the port and tag by their handles
+func (fm NftablesManager) Close(_ context.Context, config *configs.Config, port int, tag string) error {
+	out, err := RunSudoCMD(config, "nft", "-a", "list", "chain", "inet", nftTable, nftChain)
+	if err != nil {
+		return errors.Errorf("nft list chain: %v: %s", err, out)
+	}
+	for _, m := range nftRuleRegexp.FindAllStringSubmatch(out, -1) {
+		if m[1] != strconv.Itoa(port) || m[2] != tag {
+			continue
+		}
+		if out, err := RunSudoCMD(config, "nft", "delete", "rule", "inet", nftTable, nftChain, "handle", m[3]); err != nil {
+			return errors.Errorf("nft delete rule: %v: %s", err, out)
+		}
+	}
+	return nil
+}
+
+// Rules returns rules added by pastelup
+func (fm NftablesManager) Rules(_ context.Context, config *configs.Config) ([]FirewallRule, error) {
+	out, err := RunSudoCMD(config, "nft", "-a", "list", "chain", "inet", nftTable, nftChain)
+	if err != nil {
+		return nil, errors.Errorf("nft list chain: %v: %s", err, out)
+	}
+	var rules []FirewallRule
+	for _, m := range nftRuleRegexp.FindAllStringSubmatch(out, -1) {
+		port, _ := strconv.Atoi(m[1])
+		rules = append(rules, FirewallRule{Port: port, Tag: m[2]})
+	}
+	return filterInstanceRules(config, rules), nil
+}
+
+///// iptables
+
+// IptablesManager manages ports with iptables, rules are inserted in the INPUT chain with comments.
+// Rules are not persisted, use the distribution tools (e.g. iptables-persistent) to keep them after reboot
+type IptablesManager struct{}
+
+// Name returns name of the firewall
+func (fm IptablesManager) Name() string {
+	return firewallIptables
+}
+
+func (fm IptablesManager) ruleSpec(port int, tag string) []string {
+	return []string{"INPUT", "-p", "tcp", "--dport", strconv.Itoa(port), "-m", "comment", "--comment", tag, "-j", "ACCEPT"}
+}
+
+// Open inserts rule accepting the port, if it doesn't exist yet
+func (fm IptablesManager) Open(_ context.Context, config *configs.Config, port int, tag string) error {
+	if _, err := RunSudoCMD(config, append([]string{"iptables", "-C"}, fm.ruleSpec(port, tag)...)...); err == nil {
+		return nil
+	}
+	if out, err := RunSudoCMD(config, append([]string{"iptables", "-I"}, fm.ruleSpec(port, tag)...)...); err != nil {
+		return errors.Errorf("iptables -I: %v: %s", err, out)
+	}
+	return nil
+}
+
+// Close deletes rule accepting the port
+func (fm IptablesManager) Close(_ context.Context, config *configs.Config, port int, tag string) error {
+	if _, err := RunSudoCMD(config, append([]string{"iptables", "-C"}, fm.ruleSpec(port, tag)...)...); err != nil {
+		return nil
+	}
+	if out, err := RunSudoCMD(config, append([]string{"iptables", "-D"}, fm.ruleSpec(port, tag)...)...); err != nil {
+		return errors.Errorf("iptables -D: %v: %s", err, out)
+	}
+	return nil
+}
+
+var iptablesRuleRegexp = regexp.MustCompile(`--dport (\d+) .*--comment "?([^" ]+)"? -j ACCEPT`)
+
+// Rules returns rules added by pastelup
+func (fm IptablesManager) Rules(_ context.Context, config *configs.Config) ([]FirewallRule, error) {
+	out, err := RunSudoCMD(config, "iptables", "-S", "INPUT")
+	if err != nil {
+		return nil, errors.Errorf("iptables -S: %v: %s", err, out)
+	}
+	var rules []FirewallRule
+	for _, line := range strings.Split(out, "\n") {
+		if m := iptablesRuleRegexp.FindStringSubmatch(line); m != nil {
+			port, _ := strconv.Atoi(m[1])
+			rules = append(rules, FirewallRule{Port: port, Tag: m[2]})
+		}
+	}
+	return filterInstanceRules(config, rules), nil
+}
+
+///// Firewall helpers
+
+// openFirewallPorts opens ports of the component in the firewall
+func openFirewallPorts(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		return err
+	}
+	ports := getComponentPorts(config)
+	for _, name := range firewallComponentPorts[tool] {
+		log.WithContext(ctx).Infof("Opening port %d (%s) with %s", ports[name], name, fm.Name())
+		if err = fm.Open(ctx, config, ports[name], getFirewallTag(config, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeFirewallRules removes all rules added by pastelup for the instance
+func closeFirewallRules(ctx context.Context, config *configs.Config) error {
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		return err
+	}
+	rules, err := fm.Rules(ctx, config)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		log.WithContext(ctx).Infof("Closing port %d (%s) with %s", rule.Port, rule.Tag, fm.Name())
+		if err = fm.Close(ctx, config, rule.Port, rule.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeFirewallPorts removes rules added by pastelup for the ports of the component, which are not used by the node
+func closeFirewallPorts(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	tags := make(map[string]bool)
+	for _, name := range firewallComponentPorts[tool] {
+		if !utils.Contains(firewallComponentPorts[constants.PastelD], name) {
+			tags[getFirewallTag(config, name)] = true
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		return err
+	}
+	rules, err := fm.Rules(ctx, config)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if !tags[rule.Tag] {
+			continue
+		}
+		log.WithContext(ctx).Infof("Closing port %d (%s) with %s", rule.Port, rule.Tag, fm.Name())
+		if err = fm.Close(ctx, config, rule.Port, rule.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -69,9 +69,14 @@ type filesystemInfo struct {
 }
 
 type systemInfo struct {
-	MemInfo  []memoryInfo
-	FsInfo   []filesystemInfo
-	ProcInfo []processInfo
+	HostName   string
+	OS         string
+	MemInfo    []memoryInfo
+	FsInfo     []filesystemInfo
+	ProcInfo   []processInfo
+	WorkingDir string
+	PastelInfo *structure.GetInfoResult  `json:",omitempty"`
+	MNStatus   *structure.MNStatusResult `json:",omitempty"`
 }
 
 type infoCommand uint8
@@ -125,9 +130,8 @@ func setupInfoSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, Username of user at remote host")),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
-		cli.NewFlag("inventory", &config.InventoryFile).
-			SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
 	}
+	remoteFlags = append(remoteFlags, getInventoryFlags(config)...)
 
 	var commandName, commandMessage string
 	if !remote {
@@ -148,6 +152,9 @@ func setupInfoSubCommand(config *configs.Config,
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
 	addLogFlags(subCommand, config)
 
 	if f != nil {
@@ -156,6 +163,13 @@ func setupInfoSubCommand(config *configs.Config,
 			if err != nil {
 				return fmt.Errorf("failed to configure logging option - %v", err)
 			}
+			// logs must not be mixed with the json document
+			if flagOutput == "json" && !config.Quiet {
+				log.SetOutput(os.Stderr)
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
 
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
@@ -194,35 +208,22 @@ func formatSize(size uint64) string {
 func runInfoSubCommand(ctx context.Context, config *configs.Config) error {
 	//var err error
 	var sysInfo systemInfo
+	// json output must have nothing but the json document
+	console := flagOutput == "console"
 	if flagHostInfo {
-		fmt.Println(green("\n=== System info ==="))
-		host, _ := os.Hostname()
-		fmt.Printf("HostName: %s\n", host)
-		fmt.Printf("OS: %s\n", utils.GetOS())
-
-		pastelProcNames := make(map[string]bool)
-		pastelProcNamesShort := make(map[string]bool)
-		for _, tool := range pastelTools {
-			name := constants.ServiceName[tool][utils.GetOS()]
-			pastelProcNames[name] = true
-
-			short := int(math.Min(15, float64(len(name))))
-			shortName := name[:short]
-			pastelProcNamesShort[shortName] = true
+		sysInfo.HostName, _ = os.Hostname()
+		sysInfo.OS = string(utils.GetOS())
+		if console {
+			fmt.Println(green("\n=== System info ==="))
+			fmt.Printf("HostName: %s\n", sysInfo.HostName)
+			fmt.Printf("OS: %s\n", sysInfo.OS)
 		}
-		// for old SN installations
-		pastelProcNames["supernode-ubunt"] = true
-		pastelProcNames["rq-service-ubun"] = true
-
-		//dd and img-server
-		pastelProcNames["python3"] = true //TODO - get command line parameters and check for `dupe_detection_server.py`
-		pastelProcNames["start_dd_img_se"] = true
 
 		sysInfo.MemInfo = getMemoryInfo()
 		sysInfo.FsInfo = getFSInfo()
-		sysInfo.ProcInfo = getPastelProcessesInfo(&pastelProcNames, &pastelProcNamesShort)
+		sysInfo.ProcInfo = getPastelProcesses()
 
-		if flagOutput == "console" {
+		if console {
 			printMemoryInfo(sysInfo.MemInfo)
 			printFSInfo(sysInfo.FsInfo)
 			printProcessInfo(sysInfo.ProcInfo)
@@ -230,14 +231,20 @@ func runInfoSubCommand(ctx context.Context, config *configs.Config) error {
 	}
 
 	if flagPastelInfo {
-		fmt.Println(blue("\n=== Pastel info ==="))
+		if console {
+			fmt.Println(blue("\n=== Pastel info ==="))
+		}
 		for _, process := range sysInfo.ProcInfo {
 			if strings.HasPrefix(process.Process, "pasteld") {
 				config.WorkingDir = config.Configurer.DefaultWorkingDir()
 				if len(process.Args) > 0 {
-					fmt.Printf(red("pasteld") + " was started with the following parameters:\n")
+					if console {
+						fmt.Printf(red("pasteld") + " was started with the following parameters:\n")
+					}
 					for _, arg := range process.Args[1:] {
-						fmt.Printf(cyan("\t%s\n"), arg)
+						if console {
+							fmt.Printf(cyan("\t%s\n"), arg)
+						}
 						if strings.Contains(arg, "--datadir") {
 							datadir := strings.Split(arg, "=")
 							if len(datadir) == 1 {
@@ -248,29 +255,40 @@ func runInfoSubCommand(ctx context.Context, config *configs.Config) error {
 							}
 						}
 					}
-				} else {
+				} else if console {
 					fmt.Print(blue("pasteld was started without parameters\n"))
 				}
 				config.PastelExecDir = process.Path
 
-				fmt.Printf("Blockchain info on the host:\n")
 				var info structure.RPCGetInfo
 				err := pastelcore.NewClient(config).RunCommand(pastelcore.GetInfoCmd, &info)
 				if err != nil {
 					log.WithContext(ctx).Errorf("unable to get pastel info: %v", err)
+				} else {
+					sysInfo.PastelInfo = &info.Result
+				}
+				if console {
+					fmt.Printf("Blockchain info on the host:\n")
+					fmt.Println(info.String() + "\n")
 				}
-				fmt.Println(info.String() + "\n")
 
-				fmt.Printf("Masternode status of the host:\n")
 				var mnStatus structure.RPCPastelMNStatus
 				err = pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"status"}, &mnStatus)
 				if err != nil {
 					log.WithContext(ctx).Errorf("unable to get masternode status: %v", err)
+				} else {
+					sysInfo.MNStatus = &mnStatus.Result
+				}
+				if console {
+					fmt.Printf("Masternode status of the host:\n")
+					fmt.Printf("%+v\n", mnStatus)
 				}
-				fmt.Printf("%+v\n", mnStatus)
 			}
 		}
-		fmt.Printf("Working Directory: %s\n", config.WorkingDir)
+		sysInfo.WorkingDir = config.WorkingDir
+		if console {
+			fmt.Printf("Working Directory: %s\n", config.WorkingDir)
+		}
 	}
 
 	if flagOutput == "json" {
@@ -294,19 +312,49 @@ func runRemoteInfoSubCommand(ctx context.Context, config *configs.Config) error
 	if len(flagOutput) > 0 {
 		infoOptions = fmt.Sprintf("%s --output %s", infoOptions, flagOutput)
 	}
-	if config.Quiet {
+	// remote pastelup of older versions logs to stdout, which would break json output
+	if config.Quiet || flagOutput == "json" {
 		infoOptions = fmt.Sprintf("%s -q", infoOptions)
 	}
 	if len(config.LogLevel) > 0 {
 		infoOptions = fmt.Sprintf("%s --log-level %s", infoOptions, config.LogLevel)
 	}
-	infoCmd := fmt.Sprintf("%s info %s", constants.RemotePastelupPath, infoOptions)
-	if err := executeRemoteCommandsWithInventory(ctx, config, []string{infoCmd}, false); err != nil {
+	getInfoCommand := func() (string, error) {
+		return fmt.Sprintf("%s info %s", constants.RemotePastelupPath, infoOptions), nil
+	}
+
+	if flagOutput == "json" {
+		return printRemoteResultsJSON(ctx, config, getInfoCommand)
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getInfoCommand, false); err != nil {
 		log.WithContext(ctx).WithError(err).Error("Failed to get info from remote hosts")
 	}
 	return nil
 }
 
+// getPastelProcesses returns info of the running pastel processes
+func getPastelProcesses() []processInfo {
+	pastelProcNames := make(map[string]bool)
+	pastelProcNamesShort := make(map[string]bool)
+	for _, tool := range pastelTools {
+		name := constants.ServiceName[tool][utils.GetOS()]
+		pastelProcNames[name] = true
+
+		short := int(math.Min(15, float64(len(name))))
+		shortName := name[:short]
+		pastelProcNamesShort[shortName] = true
+	}
+	// for old SN installations
+	pastelProcNames["supernode-ubunt"] = true
+	pastelProcNames["rq-service-ubun"] = true
+
+	//dd and img-server
+	pastelProcNames["python3"] = true //TODO - get command line parameters and check for `dupe_detection_server.py`
+	pastelProcNames["start_dd_img_se"] = true
+
+	return getPastelProcessesInfo(&pastelProcNames, &pastelProcNamesShort)
+}
+
 func getPastelProcessesInfo(procNames *map[string]bool, procNamesShort *map[string]bool) []processInfo {
 	pids := sigar.ProcList{}
 	pids.Get()
@@ -99,9 +99,16 @@ func setupInitSubCommand(config *configs.Config,
 		}
 	}
 
+	nameFlag := cli.NewFlag("name", &flagMasterNodeName).
+		SetUsage(red("Required, name of the Masternode to create or update in the masternode.conf")).SetRequired()
+	if remote && initCommand != coldHotInit {
+		// name of every host can be set by the inventory variables
+		nameFlag = cli.NewFlag("name", &flagMasterNodeName).
+			SetUsage(red("Required (if `inventory` with the name variables is not used), name of the Masternode to create or update in the masternode.conf"))
+	}
+
 	superNodeInitFlags := []*cli.Flag{
-		cli.NewFlag("name", &flagMasterNodeName).
-			SetUsage(red("Required, name of the Masternode to create or update in the masternode.conf")).SetRequired(),
+		nameFlag,
 
 		cli.NewFlag("new", &flagMasterNodeConfNew).
 			SetUsage(red("Required (if --add is not used), if specified, will create new masternode.conf with new Masternode record in it.")),
@@ -134,11 +141,13 @@ func setupInitSubCommand(config *configs.Config,
 
 		cli.NewFlag("activate", &flagMasterNodeIsActivate).
 			SetUsage(green("Optional, if specified, will try to enable node as Masternode (start-alias).")),
+		cli.NewFlag("sync-timeout", &config.SyncTimeout).
+			SetUsage(green("Optional, how long to wait for the node to sync, e.g. 6h, fails if it is not synced by then (default: wait until synced)")),
 	}
 
 	remoteStartFlags := []*cli.Flag{
 		cli.NewFlag("ssh-ip", &config.RemoteIP).
-			SetUsage(red("Required, SSH address of the remote node")),
+			SetUsage(red("Required (if `inventory` is not used), SSH address of the remote node")),
 		cli.NewFlag("ssh-port", &config.RemotePort).
 			SetUsage(green("Optional, SSH port of the remote node")).SetValue(22),
 		cli.NewFlag("ssh-user", &config.RemoteUser).
@@ -146,6 +155,7 @@ func setupInitSubCommand(config *configs.Config,
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key")),
 	}
+	remoteStartFlags = append(remoteStartFlags, getInventoryFlags(config)...)
 	coldhotStartFlags := []*cli.Flag{
 		cli.NewFlag("ssh-ip", &config.RemoteIP).
 			SetUsage(red("Required, SSH address of the remote HOT node")),
@@ -280,6 +290,20 @@ func runInitColdHotSuperNodeSubCommand(ctx context.Context, config *configs.Conf
 func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Config) error {
 	log.WithContext(ctx).Infof("Initializing remote supernode")
 
+	getInitCommand := func() (string, error) {
+		return getRemoteInitCommand(config), nil
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getInitCommand, false); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to init remote Supernode services")
+		return err
+	}
+	log.WithContext(ctx).Infof("Remote supernode initialized")
+
+	return nil
+}
+
+// getRemoteInitCommand returns command line of the remote pastelup to init the supernode
+func getRemoteInitCommand(config *configs.Config) string {
 	startOptions := ""
 
 	if len(flagMasterNodeName) > 0 {
@@ -348,6 +372,10 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 		startOptions = fmt.Sprintf("%s --reindex", startOptions)
 	}
 
+	if config.SyncTimeout > 0 {
+		startOptions = fmt.Sprintf("%s --sync-timeout=%v", startOptions, config.SyncTimeout)
+	}
+
 	if len(config.PastelExecDir) > 0 {
 		startOptions = fmt.Sprintf("%s --dir=%s", startOptions, config.PastelExecDir)
 	}
@@ -356,14 +384,7 @@ func runInitRemoteSuperNodeSubCommand(ctx context.Context, config *configs.Confi
 		startOptions = fmt.Sprintf("%s --work-dir=%s", startOptions, config.WorkingDir)
 	}
 
-	initSuperNodeCmd := fmt.Sprintf("%s init supernode %s", constants.RemotePastelupPath, startOptions)
-	if err := executeRemoteCommands(ctx, config, []string{initSuperNodeCmd}, false); err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to init remote Supernode services")
-		return err
-	}
-	log.WithContext(ctx).Infof("Remote supernode initialized")
-
-	return nil
+	return fmt.Sprintf("%s init supernode %s", constants.RemotePastelupPath, startOptions)
 }
 
 ///// masternode.conf helpers
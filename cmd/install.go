@@ -91,10 +91,17 @@ func setupSubCommand(config *configs.Config,
 			SetUsage(green("Optional, Force to overwrite config files and re-download ZKSnark parameters")),
 		cli.NewFlag("release", &config.Version).SetAliases("r").
 			SetUsage(green("Optional, Pastel version to install")),
+		cli.NewFlag("ignore-compat", &config.IgnoreCompat).
+			SetUsage(green("Optional, skip the check of compatibility of the release with installed components")),
 		cli.NewFlag("regen-rpc", &config.RegenRPC).
 			SetUsage(green("Optional, regenerate the random rpc user, password and chosen port. This will happen automatically if not defined already in your pastel.conf file")),
 	}
 
+	portsFlags := []*cli.Flag{
+		cli.NewFlag("ports", &config.Ports).
+			SetUsage(green("Optional, custom ports of the components in the format - \"name=port,name=port\", names: " + strings.Join(constants.PortNames, ", "))),
+	}
+
 	pastelFlags := []*cli.Flag{
 		cli.NewFlag("network", &config.Network).SetAliases("n").
 			SetUsage(green("Optional, network type, can be - \"mainnet\" or \"testnet\"")).SetValue("mainnet"),
@@ -105,6 +112,8 @@ func setupSubCommand(config *configs.Config,
 	userFlags := []*cli.Flag{
 		cli.NewFlag("user-pw", &config.UserPw).
 			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		cli.NewFlag("firewall", &config.Firewall).
+			SetUsage(green("Optional, firewall to open ports with, one of: " + strings.Join(FirewallNames, ", ") + ", if omitted, will be detected")),
 	}
 
 	var dirsFlags []*cli.Flag
@@ -127,7 +136,7 @@ func setupSubCommand(config *configs.Config,
 
 	remoteFlags := []*cli.Flag{
 		cli.NewFlag("ssh-ip", &config.RemoteIP).
-			SetUsage(red("Required, SSH address of the remote host")).SetRequired(),
+			SetUsage(red("Required (if `inventory` is not used), SSH address of the remote host")),
 		cli.NewFlag("ssh-port", &config.RemotePort).
 			SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
 		cli.NewFlag("ssh-user", &config.RemoteUser).
@@ -137,6 +146,7 @@ func setupSubCommand(config *configs.Config,
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key")),
 	}
+	remoteFlags = append(remoteFlags, getInventoryFlags(config)...)
 
 	ddServiceFlags := []*cli.Flag{
 		cli.NewFlag("no-cache", &config.NoCache).
@@ -158,10 +168,13 @@ func setupSubCommand(config *configs.Config,
 		installCommand == superNodeInstall {
 		commandFlags = append(commandFlags, pastelFlags[:]...)
 	}
+	commandFlags = append(commandFlags, portsFlags...)
 	if remote {
 		commandFlags = append(commandFlags, remoteFlags[:]...)
-	} else if installCommand == superNodeInstall {
-		commandFlags = append(commandFlags, userFlags...)
+	} else {
+		if installCommand == superNodeInstall {
+			commandFlags = append(commandFlags, userFlags...)
+		}
 	}
 
 	if installCommand == ddServiceInstall || installCommand == superNodeInstall {
@@ -171,6 +184,9 @@ func setupSubCommand(config *configs.Config,
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
 	if f != nil {
 		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
 			ctx, err := configureLogging(ctx, commandMessage, config)
@@ -178,6 +194,9 @@ func setupSubCommand(config *configs.Config,
 				//Logger doesn't exist
 				return fmt.Errorf("failed to configure logging option - %v", err)
 			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
 
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
@@ -187,9 +206,11 @@ func setupSubCommand(config *configs.Config,
 				os.Exit(0)
 			})
 
-			if config.Version == "" {
+			// release of the remote host may be set by the inventory, it is checked for every host
+			if config.Version == "" && !remote {
+				err = constants.NoVersionSetErr{}
 				log.WithContext(ctx).
-					WithError(constants.NoVersionSetErr{}).
+					WithError(err).
 					Error("Failed to process install command")
 				return err
 			}
@@ -305,6 +326,24 @@ func runRemoteInstallHermesService(ctx context.Context, config *configs.Config)
 func runRemoteInstall(ctx context.Context, config *configs.Config, tool string) (err error) {
 	log.WithContext(ctx).Infof("Installing remote %s", tool)
 
+	getInstallCommand := func() (string, error) {
+		return getRemoteInstallCommand(config, tool)
+	}
+	if err = executeRemoteCommandWithInventory(ctx, config, getInstallCommand, true); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to install remote %s", tool)
+		return err
+	}
+
+	log.WithContext(ctx).Infof("Finished remote installation of %s", tool)
+	return nil
+}
+
+// getRemoteInstallCommand returns command line of the remote pastelup to install the tool,
+// fails if the release is set neither by the command line nor by the inventory
+func getRemoteInstallCommand(config *configs.Config, tool string) (string, error) {
+	if len(config.Version) == 0 {
+		return "", constants.NoVersionSetErr{}
+	}
 	remoteOptions := tool
 	if len(config.PastelExecDir) > 0 {
 		remoteOptions = fmt.Sprintf("%s --dir=%s", remoteOptions, config.PastelExecDir)
@@ -318,14 +357,20 @@ func runRemoteInstall(ctx context.Context, config *configs.Config, tool string)
 		remoteOptions = fmt.Sprintf("%s --force", remoteOptions)
 	}
 
-	if len(config.Version) > 0 {
-		remoteOptions = fmt.Sprintf("%s --release=%s", remoteOptions, config.Version)
+	remoteOptions = fmt.Sprintf("%s --release=%s", remoteOptions, config.Version)
+
+	if config.IgnoreCompat {
+		remoteOptions = fmt.Sprintf("%s --ignore-compat", remoteOptions)
 	}
 
 	if len(config.Peers) > 0 {
 		remoteOptions = fmt.Sprintf("%s --peers=%s", remoteOptions, config.Peers)
 	}
 
+	if len(config.Ports) > 0 {
+		remoteOptions = fmt.Sprintf("%s --ports=%s", remoteOptions, config.Ports)
+	}
+
 	if config.Network == constants.NetworkTestnet {
 		remoteOptions = fmt.Sprintf("%s -n=testnet", remoteOptions)
 	} else if config.Network == constants.NetworkRegTest {
@@ -336,15 +381,7 @@ func runRemoteInstall(ctx context.Context, config *configs.Config, tool string)
 		remoteOptions = fmt.Sprintf("%s --user-pw=%s", remoteOptions, config.UserPw)
 	}
 
-	installSuperNodeCmd := fmt.Sprintf("yes Y | %s install %s", constants.RemotePastelupPath, remoteOptions)
-
-	if err = executeRemoteCommands(ctx, config, []string{installSuperNodeCmd}, true); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to install remote %s", tool)
-		return err
-	}
-
-	log.WithContext(ctx).Infof("Finished remote installation of %s", tool)
-	return nil
+	return fmt.Sprintf("yes Y | %s install %s", constants.RemotePastelupPath, remoteOptions), nil
 }
 
 func runServicesInstall(ctx context.Context, config *configs.Config, installCommand constants.ToolType, withDependencies bool) error {
@@ -355,12 +392,20 @@ func runServicesInstall(ctx context.Context, config *configs.Config, installComm
 		log.WithContext(ctx).Infof("initiating in %s mode", config.Network)
 	}
 
+	// update checks compatibility before stopping services
+	if config.OpMode == "install" {
+		if err := checkReleaseCompatibility(ctx, config, installCommand, withDependencies); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Compatibility check failed")
+			return err
+		}
+	}
+
 	if installCommand == constants.PastelD ||
 		(installCommand == constants.WalletNode && withDependencies) ||
 		(installCommand == constants.SuperNode && withDependencies) {
 
 		// need to stop pasteld else we'll get a text file busy error
-		if CheckProcessRunning(constants.PastelD) {
+		if CheckProcessRunning(config, constants.PastelD) {
 			log.WithContext(ctx).Infof("pasteld is already running")
 			if yes, _ := AskUserToContinue(ctx,
 				"Do you want to stop it and continue? Y/N"); !yes {
@@ -368,24 +413,31 @@ func runServicesInstall(ctx context.Context, config *configs.Config, installComm
 				return fmt.Errorf("user terminated installation")
 			}
 
-			sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+			sm, err := NewServiceManager(config)
 			if err == nil {
 				_ = sm.StopService(ctx, config, constants.PastelD)
 			}
-			if CheckProcessRunning(constants.PastelD) {
+			if CheckProcessRunning(config, constants.PastelD) {
 				if err = ParsePastelConf(ctx, config); err != nil {
 					return err
 				}
 				err = stopPatelCLI(ctx, config)
 				if err != nil {
 					log.WithContext(ctx).Warnf("Encountered error trying to stop pasteld %v, will try to kill it", err)
-					_ = KillProcess(ctx, constants.PastelD)
+					_ = KillProcess(ctx, config, constants.PastelD)
 				}
 			}
 			log.WithContext(ctx).Info("pasteld stopped or was not running")
 		}
 	}
 
+	if config.OpMode == "install" {
+		if err := setupInstallPorts(ctx, config, installCommand, withDependencies); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Ports check failed")
+			return err
+		}
+	}
+
 	// create, if needed, installation directory, example ~/pastel
 	if err := checkInstallDir(ctx, config, config.PastelExecDir, config.OpMode); err != nil {
 		//error was logged inside checkInstallDir
@@ -456,14 +508,19 @@ func installPastelUp(ctx context.Context, config *configs.Config) error {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to download %s", constants.Pastelup)
 		return err
 	}
+	downloadURL, _, err := config.Configurer.GetDownloadURL(config.Version, constants.Pastelup)
+	if err != nil {
+		return errors.Errorf("failed to get download url: %v", err)
+	}
 	downloadedExecPath := filepath.Join(config.PastelExecDir, pastelupExecName)
 	outputPath := filepath.Join(config.PastelExecDir, pastelupName)
-	if err := os.Rename(downloadedExecPath, outputPath); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to rename %v to %s: %v", downloadedExecPath, outputPath, err)
+	if err := verifyPastelupExecutable(ctx, downloadedExecPath, downloadURL.String(), config.Version, config.SkipVerify); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to verify downloaded %s", pastelupExecName)
+		os.Remove(downloadedExecPath)
 		return err
 	}
-	if err := makeExecutable(ctx, config.PastelExecDir, pastelupName); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to make %s executable", pastelupName)
+	if err := replaceExecutable(ctx, downloadedExecPath, outputPath); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to replace %s with %s", outputPath, downloadedExecPath)
 		return err
 	}
 	return nil
@@ -500,12 +557,9 @@ func installRQService(ctx context.Context, config *configs.Config) error {
 	toolPath := constants.PastelRQServiceExecName[utils.GetOS()]
 	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
 
-	toolConfig, err := utils.GetServiceConfig(string(constants.RQService), configs.RQServiceDefaultConfig, &configs.RQServiceConfig{
-		HostName: "127.0.0.1",
-		Port:     constants.RQServiceDefaultPort,
-	})
+	toolConfig, err := GetRQServiceConfigs(config)
 	if err != nil {
-		return errors.Errorf("failed to get rqservice config: %v", err)
+		return err
 	}
 
 	if err = downloadComponents(ctx, config, constants.RQService, config.Version, ""); err != nil {
@@ -540,11 +594,37 @@ func installDupeDetection(ctx context.Context, config *configs.Config) (err erro
 		log.WithContext(ctx).WithError(err).Errorf("Failed to download %s", constants.DDService)
 		return err
 	}
+	if err = setupDDVenv(ctx, config); err != nil {
+		return err
+	}
+	pathList, err := createDDDirs(ctx, config)
+	if err != nil {
+		return err
+	}
+	if err = downloadDDSupportFiles(ctx, config); err != nil {
+		return err
+	}
+	if config.OpMode == "install" {
+		if err = setupDDConfigFile(ctx, config, pathList); err != nil {
+			return err
+		}
+	}
+	log.WithContext(ctx).Info("Installing DupeDetection finished successfully")
+	return nil
+}
+
+// getDDVenvDir returns python virtual environment of dd-service
+func getDDVenvDir(config *configs.Config) string {
+	return filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv")
+}
+
+// setupDDVenv creates python virtual environment of dd-service and installs its requirements into it
+func setupDDVenv(ctx context.Context, config *configs.Config) error {
 	pythonCmd := "python3"
 	if utils.GetOS() == constants.Windows {
 		pythonCmd = "python"
 	}
-	venv := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv")
+	venv := getDDVenvDir(config)
 	if err := RunCMDWithInteractive(pythonCmd, "-m", "venv", venv); err != nil {
 		return err
 	}
@@ -562,18 +642,27 @@ func installDupeDetection(ctx context.Context, config *configs.Config) (err erro
 		return err
 	}
 	log.WithContext(ctx).Info("Pip install finished")
-	appBaseDir := filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir)
+	return nil
+}
+
+// createDDDirs creates directories of dd-service, returns their paths in the order of DupeDetectionConfig
+func createDDDirs(ctx context.Context, config *configs.Config) ([]interface{}, error) {
+	appBaseDir := getDDServiceDir(config)
 	var pathList []interface{}
 	for _, configItem := range constants.DupeDetectionConfigs {
 		dupeDetectionDirPath := filepath.Join(appBaseDir, configItem)
-		if err = utils.CreateFolder(ctx, dupeDetectionDirPath, config.Force); err != nil {
+		if err := utils.CreateFolder(ctx, dupeDetectionDirPath, config.Force); err != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to create directory : %s", dupeDetectionDirPath)
-			return err
+			return nil, err
 		}
 		pathList = append(pathList, dupeDetectionDirPath)
 	}
+	return pathList, nil
+}
 
-	targetDir := filepath.Join(appBaseDir, constants.DupeDetectionSupportFilePath)
+// downloadDDSupportFiles downloads support files of dd-service, files with matching checksum are skipped
+func downloadDDSupportFiles(ctx context.Context, config *configs.Config) error {
+	targetDir := filepath.Join(getDDServiceDir(config), constants.DupeDetectionSupportFilePath)
 	tmpDir := filepath.Join(targetDir, "temp.zip")
 	for _, url := range constants.DupeDetectionSupportDownloadURL {
 		// Get ddSupportContent and cal checksum
@@ -616,19 +705,21 @@ func installDupeDetection(ctx context.Context, config *configs.Config) (err erro
 			return err
 		}
 	}
-	if config.OpMode == "install" {
-		ddConfigPath := filepath.Join(targetDir, constants.DupeDetectionConfigFilename)
-		err = utils.CreateFile(ctx, ddConfigPath, config.Force)
-		if err != nil {
-			log.WithContext(ctx).Errorf("Failed to create config.ini for dd-service : %s", ddConfigPath)
-			return err
-		}
-		if err = utils.WriteFile(ddConfigPath, fmt.Sprintf(configs.DupeDetectionConfig, pathList...)); err != nil {
-			return err
-		}
-		_ = os.Setenv("DUPEDETECTIONCONFIGPATH", ddConfigPath)
+	return nil
+}
+
+// setupDDConfigFile writes config.ini of dd-service with paths of its directories
+func setupDDConfigFile(ctx context.Context, config *configs.Config, pathList []interface{}) error {
+	ddConfigPath := getDDConfigFilePath(config)
+	err := utils.CreateFile(ctx, ddConfigPath, config.Force)
+	if err != nil {
+		log.WithContext(ctx).Errorf("Failed to create config.ini for dd-service : %s", ddConfigPath)
+		return err
 	}
-	log.WithContext(ctx).Info("Installing DupeDetection finished successfully")
+	if err = utils.WriteFile(ddConfigPath, fmt.Sprintf(configs.DupeDetectionConfig, pathList...)); err != nil {
+		return err
+	}
+	_ = os.Setenv("DUPEDETECTIONCONFIGPATH", ddConfigPath)
 	return nil
 }
 
@@ -638,31 +729,9 @@ func installWNService(ctx context.Context, config *configs.Config) error {
 
 	wnPath := constants.WalletNodeExecName[utils.GetOS()]
 	bridgePath := constants.BridgeExecName[utils.GetOS()]
-	burnAddress := constants.BurnAddressMainnet
-	if config.Network == constants.NetworkTestnet {
-		burnAddress = constants.BurnAddressTestnet
-	} else if config.Network == constants.NetworkRegTest {
-		burnAddress = constants.BurnAddressTestnet
-	}
-	wnTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
-	rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
-	wnConfig, err := utils.GetServiceConfig(string(constants.WalletNode), configs.WalletDefaultConfig, &configs.WalletNodeConfig{
-		LogLevel:      constants.WalletNodeDefaultLogLevel,
-		LogFilePath:   config.Configurer.GetWalletNodeLogFile(config.WorkingDir),
-		LogCompress:   constants.LogConfigDefaultCompress,
-		LogMaxSizeMB:  constants.LogConfigDefaultMaxSizeMB,
-		LogMaxAgeDays: constants.LogConfigDefaultMaxAgeDays,
-		LogMaxBackups: constants.LogConfigDefaultMaxBackups,
-		WNTempDir:     wnTempDirPath,
-		WNWorkDir:     config.WorkingDir,
-		RQDir:         rqWorkDirPath,
-		BurnAddress:   burnAddress,
-		RaptorqPort:   constants.RQServiceDefaultPort,
-		BridgePort:    constants.BridgeServiceDefaultPort,
-		BridgeOn:      installBridge,
-	})
+	wnConfig, err := GetWNConfigs(config, installBridge)
 	if err != nil {
-		return errors.Errorf("failed to get walletnode config: %v", err)
+		return err
 	}
 
 	if err = downloadComponents(ctx, config, constants.WalletNode, config.Version, ""); err != nil {
@@ -671,24 +740,9 @@ func installWNService(ctx context.Context, config *configs.Config) error {
 	}
 
 	if installBridge {
-		bridgeConfig, err := utils.GetServiceConfig(string(constants.Bridge),
-			configs.BridgeDefaultConfig, &configs.BridgeConfig{
-				LogLevel:           constants.WalletNodeDefaultLogLevel,
-				LogFilePath:        config.Configurer.GetBridgeLogFile(config.WorkingDir),
-				LogCompress:        constants.LogConfigDefaultCompress,
-				LogMaxSizeMB:       constants.LogConfigDefaultMaxSizeMB,
-				LogMaxAgeDays:      constants.LogConfigDefaultMaxAgeDays,
-				LogMaxBackups:      constants.LogConfigDefaultMaxBackups,
-				WNTempDir:          wnTempDirPath,
-				WNWorkDir:          config.WorkingDir,
-				BurnAddress:        burnAddress,
-				ConnRefreshTimeout: 300,
-				Connections:        10,
-				ListenAddress:      "127.0.0.1",
-				Port:               constants.BridgeServiceDefaultPort,
-			})
+		bridgeConfig, err := GetBridgeConfigs(config)
 		if err != nil {
-			return errors.Errorf("failed to get bridge config: %v", err)
+			return err
 		}
 
 		if err = downloadComponents(ctx, config, constants.Bridge, config.Version, ""); err != nil {
@@ -821,9 +875,9 @@ func installSNService(ctx context.Context, config *configs.Config, tryOpenPorts
 		return err
 	}
 
-	if tryOpenPorts {
+	if tryOpenPorts && config.OpMode == "install" {
 		// Open ports
-		if err = openPorts(ctx, config, GetSNPortList(config)); err != nil {
+		if err = openFirewallPorts(ctx, config, constants.SuperNode); err != nil {
 			log.WithContext(ctx).WithError(err).Error("Failed to open ports")
 			return err
 		}
@@ -1024,6 +1078,8 @@ func downloadComponents(ctx context.Context, config *configs.Config, installComm
 	commandName := filepath.Base(string(installCommand))
 	log.WithContext(ctx).Infof("Downloading %s...", commandName)
 
+	version = getReleaseComponentVersion(ctx, installCommand, version)
+
 	downloadURL, archiveName, err := config.Configurer.GetDownloadURL(version, installCommand)
 	if err != nil {
 		return errors.Errorf("failed to get download url: %v", err)
@@ -1081,13 +1137,24 @@ func makeExecutable(ctx context.Context, dirPath string, fileName string) error
 func setupComponentConfigFile(ctx context.Context, config *configs.Config,
 	toolName string, configFilePath string, toolConfig string) error {
 
-	// Ignore if not in "install" mode
+	// Keep existing config if not in "install" mode, only migrate it to the new template version and apply user overrides to it
 	if config.OpMode != "install" {
-		return nil
+		if err := migrateComponentConfigFile(ctx, config, toolName, configFilePath, toolConfig); err != nil {
+			return err
+		}
+		return applyConfigOverridesToFile(ctx, config, toolName, configFilePath)
+	}
+
+	if version, ok := configs.ConfigVersions[toolName]; ok {
+		toolConfig = setConfigVersion(toolConfig, version)
+	}
+	toolConfig, err := applyConfigOverrides(ctx, config, toolName, toolConfig)
+	if err != nil {
+		return err
 	}
 
 	log.WithContext(ctx).Infof("Initialize working environment for %s", toolName)
-	err := utils.CreateFile(ctx, configFilePath, config.Force)
+	err = utils.CreateFile(ctx, configFilePath, config.Force)
 	if err != nil {
 		log.WithContext(ctx).Errorf("Failed to create %s file", configFilePath)
 		return err
@@ -1107,8 +1174,9 @@ func setupBasePasteWorkingEnvironment(ctx context.Context, config *configs.Confi
 		return nil
 	}
 
-	// create working dir
-	if err := utils.CreateFolder(ctx, config.WorkingDir, config.Force); err != nil {
+	// create working dir, it may already exist with the custom ports of the installation,
+	// existing installation is protected by the pastel.conf check below
+	if err := utils.CreateFolder(ctx, config.WorkingDir, config.Force); err != nil && !os.IsExist(err) {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to create folder %s", config.WorkingDir)
 		return err
 	}
@@ -1154,6 +1222,20 @@ func setupBasePasteWorkingEnvironment(ctx context.Context, config *configs.Confi
 }
 
 func updatePastelConfigFile(ctx context.Context, filePath string, config *configs.Config) error {
+	// Save file changes.
+	err := ioutil.WriteFile(filePath, getPastelConfig(config), 0644)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Error saving file")
+		return errors.Errorf("failed to save file changes: %v", err)
+	}
+
+	log.WithContext(ctx).Info("File updated successfully")
+
+	return nil
+}
+
+// getPastelConfig returns content of pastel.conf
+func getPastelConfig(config *configs.Config) []byte {
 	cfgBuffer := bytes.Buffer{}
 
 	// Populate pastel.conf line-by-line to file.
@@ -1163,6 +1245,10 @@ func updatePastelConfigFile(ctx context.Context, filePath string, config *config
 	cfgBuffer.WriteString("rpcpassword=" + config.RPCPwd + "\n")            // creates rpcpassword line
 	cfgBuffer.WriteString("rpcport=" + strconv.Itoa(config.RPCPort) + "\n") // creates rpcport line
 
+	if nodePort := getComponentPort(config, constants.PortNameNode); nodePort != getDefaultPorts(config.Network)[constants.PortNameNode] {
+		cfgBuffer.WriteString("port=" + strconv.Itoa(nodePort) + "\n") // creates custom port line
+	}
+
 	if config.Network == constants.NetworkTestnet {
 		cfgBuffer.WriteString("testnet=1\n") // creates testnet line
 	}
@@ -1178,16 +1264,7 @@ func updatePastelConfigFile(ctx context.Context, filePath string, config *config
 		}
 	}
 
-	// Save file changes.
-	err := ioutil.WriteFile(filePath, cfgBuffer.Bytes(), 0644)
-	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Error saving file")
-		return errors.Errorf("failed to save file changes: %v", err)
-	}
-
-	log.WithContext(ctx).Info("File updated successfully")
-
-	return nil
+	return cfgBuffer.Bytes()
 }
 
 func downloadZksnarkParams(ctx context.Context, path string, force bool, version string) error {
@@ -1231,36 +1308,3 @@ func downloadZksnarkParams(ctx context.Context, path string, force bool, version
 	return nil
 }
 
-func openPorts(ctx context.Context, config *configs.Config, portList []int) (err error) {
-	if config.OpMode != "install" {
-		return nil
-	}
-
-	// only open ports on SuperNode and this is only on Linux!!!
-	var out string
-	for k := range portList {
-		log.WithContext(ctx).Infof("Opening port: %d", portList[k])
-
-		portStr := fmt.Sprintf("%d", portList[k])
-		switch utils.GetOS() {
-		case constants.Linux:
-			out, err = RunSudoCMD(config, "ufw", "allow", portStr)
-			/*		case constants.Windows:
-						out, err = RunCMD("netsh", "advfirewall", "firewall", "add", "rule", "name=TCP Port "+portStr, "dir=in", "action=allow", "protocol=TCP", "localport="+portStr)
-					case constants.Mac:
-						out, err = RunCMD("sudo", "ipfw", "allow", "tcp", "from", "any", "to", "any", "dst-port", portStr)
-			*/
-		}
-
-		if err != nil {
-			if utils.GetOS() == constants.Windows {
-				log.WithContext(ctx).Error("Please run as administrator to open ports!")
-			}
-			log.WithContext(ctx).Error(err.Error())
-			return err
-		}
-		log.WithContext(ctx).Info(out)
-	}
-
-	return nil
-}
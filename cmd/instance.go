@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const instanceDirSeparator = "-"
+
+var instanceNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_]*$`)
+
+func addInstanceFlag(command *cli.Command, config *configs.Config) {
+	command.AddFlags(
+		cli.NewFlag("instance", &config.Instance).
+			SetUsage(green("Optional, name of the isolated node instance on this host, by default directories, services and ports of the instance are suffixed with the name")),
+	)
+}
+
+// applyInstance namespaces directories of the named instance, directories set explicitly by the user are kept as is
+func applyInstance(config *configs.Config) error {
+	if len(config.Instance) == 0 {
+		return nil
+	}
+	if !instanceNameRegexp.MatchString(config.Instance) {
+		return errors.Errorf("invalid instance name %q, only letters, digits and underscores are allowed", config.Instance)
+	}
+
+	suffix := getInstanceSuffix(config)
+	if config.PastelExecDir == config.Configurer.DefaultPastelExecutableDir() {
+		config.PastelExecDir += suffix
+	}
+	if config.WorkingDir == config.Configurer.DefaultWorkingDir() {
+		config.WorkingDir += suffix
+	}
+	if config.ArchiveDir == config.Configurer.DefaultArchiveDir() {
+		config.ArchiveDir += suffix
+	}
+	return nil
+}
+
+func getInstanceSuffix(config *configs.Config) string {
+	if len(config.Instance) == 0 {
+		return ""
+	}
+	return instanceDirSeparator + config.Instance
+}
+
+// getDDServiceDir returns the dupe detection service directory of the instance
+func getDDServiceDir(config *configs.Config) string {
+	return filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir+getInstanceSuffix(config))
+}
+
+// getDDConfigFilePath returns the dupe detection service config file of the instance
+func getDDConfigFilePath(config *configs.Config) string {
+	return filepath.Join(getDDServiceDir(config), constants.DupeDetectionSupportFilePath, constants.DupeDetectionConfigFilename)
+}
+
+// getOtherInstancesWorkDirs returns working directories of the other instances installed with default locations
+func getOtherInstancesWorkDirs(config *configs.Config) []string {
+	defaultWorkDir := config.Configurer.DefaultWorkingDir()
+	dirs, _ := filepath.Glob(defaultWorkDir + instanceDirSeparator + "*")
+	dirs = append([]string{defaultWorkDir}, dirs...)
+
+	var others []string
+	for _, dir := range dirs {
+		if filepath.Clean(dir) == filepath.Clean(config.WorkingDir) {
+			continue
+		}
+		if utils.CheckFileExist(filepath.Join(dir, constants.PastelConfName)) {
+			others = append(others, dir)
+		}
+	}
+	return others
+}
+
+// getOtherInstancesPorts returns ports used by the other instances on this host
+func getOtherInstancesPorts(ctx context.Context, config *configs.Config) map[int]string {
+	used := make(map[int]string)
+	for _, dir := range getOtherInstancesWorkDirs(config) {
+		other := *config
+		other.WorkingDir = dir
+		other.Network = ""
+		other.RPCPort = 0
+		if err := ParsePastelConf(ctx, &other); err != nil {
+			continue
+		}
+		for name, port := range getComponentPorts(&other) {
+			used[port] = fmt.Sprintf("%s of %s", name, dir)
+		}
+	}
+	return used
+}
+
+// isInstanceProcess checks if the process with the pid was started for the instance of the config,
+// processes are told apart by the working directory in their command line
+func isInstanceProcess(config *configs.Config, pid int) bool {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		// command line is not available, instances are not supported on this OS
+		return len(config.Instance) == 0
+	}
+	cmdline := strings.Replace(string(data), "\x00", " ", -1)
+
+	if len(config.WorkingDir) != 0 && containsPath(cmdline, config.WorkingDir) {
+		return true
+	}
+	if len(config.Instance) != 0 {
+		return false
+	}
+	// processes of the default instance may be started without the explicit working directory
+	return !strings.Contains(cmdline, config.Configurer.DefaultWorkingDir()+instanceDirSeparator)
+}
+
+// containsPath checks if the text contains the path which is not a prefix of another path
+func containsPath(text, path string) bool {
+	path = strings.TrimRight(path, "/")
+	for offset := 0; ; {
+		idx := strings.Index(text[offset:], path)
+		if idx < 0 {
+			return false
+		}
+		end := offset + idx + len(path)
+		if end == len(text) || strings.ContainsRune("/ \"'", rune(text[end])) {
+			return true
+		}
+		offset = end
+	}
+}
+
+// allocateInstancePorts moves ports of the named instance, which are not set by user, away from the ports of the other instances
+func allocateInstancePorts(ctx context.Context, config *configs.Config, custom map[string]int) {
+	used := getOtherInstancesPorts(ctx, config)
+	if len(used) == 0 {
+		return
+	}
+
+	ports := getComponentPortsWith(config, custom)
+	var conflicting []string
+	for _, name := range constants.PortNames {
+		if _, ok := custom[name]; ok {
+			used[ports[name]] = name
+			continue
+		}
+		if _, ok := used[ports[name]]; ok {
+			conflicting = append(conflicting, name)
+		}
+	}
+	for _, name := range constants.PortNames {
+		if _, ok := custom[name]; !ok && !utils.Contains(conflicting, name) {
+			used[ports[name]] = name
+		}
+	}
+
+	for _, name := range conflicting {
+		port := ports[name]
+		for port < 65535 {
+			port++
+			if _, ok := used[port]; !ok && utils.GetPortListener(port) == nil {
+				break
+			}
+		}
+		log.WithContext(ctx).Infof("Port %d of %s is used by %s, instance %s will use port %d",
+			ports[name], name, used[ports[name]], config.Instance, port)
+		custom[name] = port
+		used[port] = name
+	}
+}
@@ -3,12 +3,19 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"github.com/pastelnetwork/pastelup/configs"
 	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 
+	"github.com/pastelnetwork/gonode/common/cli"
 	"github.com/pastelnetwork/gonode/common/log"
 )
 
@@ -26,16 +33,43 @@ type ServerGroup struct {
 
 // CommonInventoryParameters defines common parameters of server group
 type CommonInventoryParameters struct {
-	User         string `yaml:"user,omitempty"`
-	IdentityFile string `yaml:"identity-file,omitempty"`
-	Port         int    `yaml:"port,omitempty"`
+	User         string        `yaml:"user,omitempty"`
+	IdentityFile string        `yaml:"identity-file,omitempty"`
+	Port         int           `yaml:"port,omitempty"`
+	Tags         []string      `yaml:"tags,omitempty"`
+	Vars         InventoryVars `yaml:"vars,omitempty"`
+}
+
+// InventoryVars defines variables of the host, they are passed to the remote pastelup
+// instead of the corresponding command line flags
+type InventoryVars struct {
+	// Network is passed as -n
+	Network string `yaml:"network,omitempty"`
+	// Name is the masternode alias, passed as --name
+	Name    string         `yaml:"name,omitempty"`
+	Dir     string         `yaml:"dir,omitempty"`
+	WorkDir string         `yaml:"work-dir,omitempty"`
+	Release string         `yaml:"release,omitempty"`
+	Ports   map[string]int `yaml:"ports,omitempty"`
 }
 
 // InventoryServer defines remote host
 type InventoryServer struct {
-	CommonInventoryParameters
-	Name string `yaml:"name,omitempty"`
-	Host string `yaml:"host,omitempty"`
+	CommonInventoryParameters `yaml:",inline"`
+	Name                      string `yaml:"name,omitempty"`
+	Host                      string `yaml:"host,omitempty"`
+}
+
+// getInventoryFlags returns flags of the inventory and selection of its hosts
+func getInventoryFlags(config *configs.Config) []*cli.Flag {
+	return []*cli.Flag{
+		cli.NewFlag("inventory", &config.InventoryFile).
+			SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
+		cli.NewFlag("limit", &config.InventoryLimit).
+			SetUsage(yellow("Optional, only inventory hosts matching all conditions - \"group=name,host=name,tag=name\", values can have wildcards, e.g. host=sn*")),
+		cli.NewFlag("tags", &config.InventoryTags).
+			SetUsage(yellow("Optional, only inventory hosts having any of the tags - \"tag,tag\"")),
+	}
 }
 
 // ReadInventory read and load inventory file
@@ -50,14 +84,271 @@ func (i *Inventory) Read(path string) error {
 		return errors.Errorf("failed to load Inventory: %v", err)
 	}
 
+	for _, sg := range i.ServerGroups {
+		if err := sg.Common.Vars.validate(); err != nil {
+			return errors.Errorf("invalid vars of group %s: %v", sg.Name, err)
+		}
+		for _, srv := range sg.Servers {
+			if err := srv.Vars.validate(); err != nil {
+				return errors.Errorf("invalid vars of host %s: %v", srv.getName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func (v InventoryVars) validate() error {
+	if len(v.Network) > 0 && !utils.Contains(constants.NetworkModes, v.Network) {
+		return errors.Errorf("unknown network %q, must be one of: %s", v.Network, strings.Join(constants.NetworkModes, ", "))
+	}
+	for name, port := range v.Ports {
+		if !utils.Contains(constants.PortNames, name) {
+			return errors.Errorf("unknown port %q, names: %s", name, strings.Join(constants.PortNames, ", "))
+		}
+		if port < 1 || port > 65535 {
+			return errors.Errorf("invalid port %s=%d, must be in range 1-65535", name, port)
+		}
+	}
 	return nil
 }
 
-// ExecuteCommands executes commands on all hosts from inventory
-func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config, commands []string) error {
+// merge returns variables overridden by the non empty variables of other
+func (v InventoryVars) merge(other InventoryVars) InventoryVars {
+	if len(other.Network) > 0 {
+		v.Network = other.Network
+	}
+	if len(other.Name) > 0 {
+		v.Name = other.Name
+	}
+	if len(other.Dir) > 0 {
+		v.Dir = other.Dir
+	}
+	if len(other.WorkDir) > 0 {
+		v.WorkDir = other.WorkDir
+	}
+	if len(other.Release) > 0 {
+		v.Release = other.Release
+	}
+	if len(other.Ports) > 0 {
+		ports := make(map[string]int)
+		for name, port := range v.Ports {
+			ports[name] = port
+		}
+		for name, port := range other.Ports {
+			ports[name] = port
+		}
+		v.Ports = ports
+	}
+	return v
+}
+
+func (s InventoryServer) getName() string {
+	if len(s.Name) == 0 {
+		return s.Host
+	}
+	return s.Name
+}
+
+// inventoryCondition is the condition of --limit, value is the pattern of path.Match
+type inventoryCondition struct {
+	key   string
+	value string
+}
+
+// inventorySelector selects hosts of the inventory by --limit and --tags
+type inventorySelector struct {
+	conditions []inventoryCondition
+	tags       []string
+}
+
+var inventoryConditionKeys = []string{"group", "host", "tag"}
+
+func parseInventorySelector(limit string, tags string) (*inventorySelector, error) {
+	s := &inventorySelector{}
+	for _, item := range strings.Split(limit, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || !utils.Contains(inventoryConditionKeys, strings.TrimSpace(kv[0])) {
+			return nil, errors.Errorf("invalid limit %q, must be key=value, keys: %s", item, strings.Join(inventoryConditionKeys, ", "))
+		}
+		value := strings.TrimSpace(kv[1])
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, errors.Errorf("invalid limit %q: %v", item, err)
+		}
+		s.conditions = append(s.conditions, inventoryCondition{key: strings.TrimSpace(kv[0]), value: value})
+	}
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); len(tag) != 0 {
+			if _, err := path.Match(tag, ""); err != nil {
+				return nil, errors.Errorf("invalid tag %q: %v", tag, err)
+			}
+			s.tags = append(s.tags, tag)
+		}
+	}
+	return s, nil
+}
+
+// matchAny returns true if any of the values matches the pattern
+func matchAny(pattern string, values ...string) bool {
+	for _, value := range values {
+		if ok, _ := path.Match(pattern, value); ok && len(value) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the host matches all conditions and has any of the tags,
+// host tags are the tags of its group and its own ones
+func (s *inventorySelector) match(sg ServerGroup, srv InventoryServer) bool {
+	hostTags := append(append([]string{}, sg.Common.Tags...), srv.Tags...)
+	for _, c := range s.conditions {
+		var ok bool
+		switch c.key {
+		case "group":
+			ok = matchAny(c.value, sg.Name)
+		case "host":
+			ok = matchAny(c.value, srv.Name, srv.Host)
+		case "tag":
+			ok = matchAny(c.value, hostTags...)
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(s.tags) == 0 {
+		return true
+	}
+	for _, tag := range s.tags {
+		if matchAny(tag, hostTags...) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostParams are the parameters of the command line that are replaced by the inventory variables
+type hostParams struct {
+	network string
+	name    string
+	dir     string
+	workDir string
+	release string
+	ports   string
+}
+
+func getHostParams(config *configs.Config) hostParams {
+	return hostParams{
+		network: config.Network,
+		name:    flagMasterNodeName,
+		dir:     config.PastelExecDir,
+		workDir: config.WorkingDir,
+		release: config.Version,
+		ports:   config.Ports,
+	}
+}
+
+func (p hostParams) apply(config *configs.Config) {
+	config.Network = p.network
+	flagMasterNodeName = p.name
+	config.PastelExecDir = p.dir
+	config.WorkingDir = p.workDir
+	config.Version = p.release
+	config.Ports = p.ports
+}
+
+// withVars returns parameters overridden by the variables of the host,
+// ports of the command line are kept unless the host has the same port
+func (p hostParams) withVars(vars InventoryVars) (hostParams, error) {
+	if len(vars.Network) > 0 {
+		p.network = vars.Network
+	}
+	if len(vars.Name) > 0 {
+		p.name = vars.Name
+	}
+	if len(vars.Dir) > 0 {
+		p.dir = vars.Dir
+	}
+	if len(vars.WorkDir) > 0 {
+		p.workDir = vars.WorkDir
+	}
+	if len(vars.Release) > 0 {
+		p.release = vars.Release
+	}
+	if len(vars.Ports) > 0 {
+		ports := make(map[string]int)
+		if len(p.ports) > 0 {
+			var err error
+			if ports, err = parsePortsOption(p.ports); err != nil {
+				return p, err
+			}
+		}
+		for name, port := range vars.Ports {
+			ports[name] = port
+		}
+		var items []string
+		for _, name := range constants.PortNames {
+			if port, ok := ports[name]; ok {
+				items = append(items, fmt.Sprintf("%s=%d", name, port))
+			}
+		}
+		p.ports = strings.Join(items, ",")
+	}
+	return p, nil
+}
+
+// ExecuteCommand executes the command on all hosts from inventory, the command is got for every host
+// after its variables are applied to config
+func (i *Inventory) ExecuteCommand(ctx context.Context, config *configs.Config, getCommand func() (string, error), tryStop bool) error {
+	return i.ForEachHost(ctx, config, func(name string) error {
+		command, err := getCommand()
+		if err != nil {
+			return err
+		}
+		results, err := runRemoteCommands(ctx, config, name, []string{command}, tryStop, false)
+		if err != nil {
+			return err
+		}
+		return getRemoteResultsError(results)
+	})
+}
+
+// ForEachHost calls f for every host from inventory selected by --limit and --tags with config set up
+// to connect to that host and with its variables, failure of the host doesn't stop the others,
+// returns error if any host failed
+func (i *Inventory) ForEachHost(ctx context.Context, config *configs.Config, f func(name string) error) error {
+	selector, err := parseInventorySelector(config.InventoryLimit, config.InventoryTags)
+	if err != nil {
+		return err
+	}
+
+	params := getHostParams(config)
+	defer params.apply(config)
+
+	// ssh parameters of the command line are the defaults of every group
+	baseUser, basePort, baseKey := config.RemoteUser, config.RemotePort, config.RemoteSSHKey
+	defer func() {
+		config.RemoteUser, config.RemotePort, config.RemoteSSHKey = baseUser, basePort, baseKey
+	}()
+
+	var failed, total int
 	for _, sg := range i.ServerGroups {
-		fmt.Printf(green("\n********** Accessing host group %s **********\n"), sg.Name)
+		var servers []InventoryServer
+		for _, srv := range sg.Servers {
+			if selector.match(sg, srv) {
+				servers = append(servers, srv)
+			}
+		}
+		if len(servers) == 0 {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, green("\n********** Accessing host group %s **********\n"), sg.Name)
 
+		// parameters of the group don't apply to the next groups
+		config.RemoteUser, config.RemotePort, config.RemoteSSHKey = baseUser, basePort, baseKey
 		if len(sg.Common.User) > 0 {
 			config.RemoteUser = sg.Common.User
 		}
@@ -67,8 +358,10 @@ func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config,
 		if len(sg.Common.IdentityFile) > 0 {
 			config.RemoteSSHKey = sg.Common.IdentityFile
 		}
-		for _, srv := range sg.Servers {
-			fmt.Printf(green("\n********** Executing command on %s **********\n"), srv.Name)
+		for _, srv := range servers {
+			name := srv.getName()
+			fmt.Fprintf(os.Stderr, green("\n********** Executing command on %s **********\n"), name)
+			user, port, key := config.RemoteUser, config.RemotePort, config.RemoteSSHKey
 			if len(srv.User) > 0 {
 				config.RemoteUser = srv.User
 			}
@@ -82,12 +375,28 @@ func (i *Inventory) ExecuteCommands(ctx context.Context, config *configs.Config,
 			if config.RemotePort == 0 {
 				config.RemotePort = 22
 			}
-			if err := executeRemoteCommands(ctx, config, commands, false); err != nil {
+			total++
+
+			srvParams, err := params.withVars(sg.Common.Vars.merge(srv.Vars))
+			if err == nil {
+				srvParams.apply(config)
+				err = f(name)
+			}
+			if err != nil {
+				failed++
 				log.WithContext(ctx).WithError(err).Errorf("Failed to execute command on remote host %s"+
 					" [IP:%s; Port:%d; User:%s; KeyFile:%s; ]",
-					srv.Name, config.RemoteIP, config.RemotePort, config.RemoteUser, config.RemoteSSHKey)
+					name, config.RemoteIP, config.RemotePort, config.RemoteUser, config.RemoteSSHKey)
 			}
+			// parameters of the host don't apply to the next hosts of the group
+			config.RemoteUser, config.RemotePort, config.RemoteSSHKey = user, port, key
 		}
 	}
+	if total == 0 {
+		return errors.Errorf("no hosts in the inventory match --limit %q and --tags %q", config.InventoryLimit, config.InventoryTags)
+	}
+	if failed > 0 {
+		return errors.Errorf("command failed on %d of %d hosts", failed, total)
+	}
 	return nil
 }
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/tj/assert"
+)
+
+func TestParseInventorySelector(t *testing.T) {
+	s, err := parseInventorySelector(" group=sn-* , host=sn1,tag=eu", "prod, canary,")
+	assert.Nil(t, err)
+	assert.Equal(t, []inventoryCondition{{"group", "sn-*"}, {"host", "sn1"}, {"tag", "eu"}}, s.conditions)
+	assert.Equal(t, []string{"prod", "canary"}, s.tags)
+
+	s, err = parseInventorySelector("", "")
+	assert.Nil(t, err)
+	assert.Empty(t, s.conditions)
+	assert.Empty(t, s.tags)
+
+	for _, limit := range []string{"sn1", "name=sn1", "host=[", "=sn1"} {
+		_, err = parseInventorySelector(limit, "")
+		assert.NotNil(t, err, limit)
+	}
+	_, err = parseInventorySelector("", "prod,[")
+	assert.NotNil(t, err)
+}
+
+func TestInventorySelectorMatch(t *testing.T) {
+	sg := ServerGroup{Name: "sn-eu", Common: CommonInventoryParameters{Tags: []string{"eu"}}}
+	sn1 := InventoryServer{Name: "sn1", Host: "10.0.0.1", CommonInventoryParameters: CommonInventoryParameters{Tags: []string{"prod"}}}
+	sn2 := InventoryServer{Host: "10.0.0.2"}
+
+	tests := []struct {
+		limit string
+		tags  string
+		sn1   bool
+		sn2   bool
+	}{
+		{"", "", true, true},
+		{"group=sn-*", "", true, true},
+		{"group=wn-*", "", false, false},
+		{"host=sn1", "", true, false},
+		{"host=10.0.0.*", "", true, true},
+		{"host=10.0.0.2", "", false, true},
+		{"tag=eu", "", true, true},
+		{"tag=prod", "", true, false},
+		{"group=sn-eu,host=sn*", "", true, false},
+		{"", "prod", true, false},
+		{"", "us,eu", true, true},
+		{"", "us", false, false},
+		{"host=10.0.0.2", "prod", false, false},
+	}
+	for _, tt := range tests {
+		s, err := parseInventorySelector(tt.limit, tt.tags)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.sn1, s.match(sg, sn1), "limit %q tags %q sn1", tt.limit, tt.tags)
+		assert.Equal(t, tt.sn2, s.match(sg, sn2), "limit %q tags %q sn2", tt.limit, tt.tags)
+	}
+}
+
+func TestHostParamsWithVars(t *testing.T) {
+	params := hostParams{
+		network: constants.NetworkMainnet,
+		name:    "mn",
+		dir:     "/opt/pastel",
+		workDir: "/opt/.pastel",
+		release: "v1.0.0",
+		ports:   "node=9933,rpc=9932",
+	}
+
+	p, err := params.withVars(InventoryVars{})
+	assert.Nil(t, err)
+	assert.Equal(t, params, p)
+
+	p, err = params.withVars(InventoryVars{
+		Network: constants.NetworkTestnet,
+		Name:    "sn1",
+		WorkDir: "/data/.pastel",
+		Release: "beta",
+		Ports:   map[string]int{constants.PortNameNodeRPC: 19932, constants.PortNameSuperNode: 14444},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, hostParams{
+		network: constants.NetworkTestnet,
+		name:    "sn1",
+		dir:     "/opt/pastel",
+		workDir: "/data/.pastel",
+		release: "beta",
+		ports:   "node=9933,rpc=19932,supernode=14444",
+	}, p)
+
+	params.ports = "invalid"
+	_, err = params.withVars(InventoryVars{Ports: map[string]int{constants.PortNameNode: 9933}})
+	assert.NotNil(t, err)
+}
+
+func TestInventoryForEachHost(t *testing.T) {
+	inv := Inventory{ServerGroups: []ServerGroup{{
+		Name: "first",
+		Common: CommonInventoryParameters{
+			User:         "pastel",
+			Port:         2222,
+			IdentityFile: "/keys/first",
+			Vars:         InventoryVars{Release: "beta"},
+		},
+		Servers: []InventoryServer{
+			{Host: "10.0.0.1"},
+			{Host: "10.0.0.2", CommonInventoryParameters: CommonInventoryParameters{
+				User: "root",
+				Vars: InventoryVars{Network: constants.NetworkTestnet, Release: "v1.2.3"},
+			}},
+		},
+	}, {
+		Name: "second",
+		Servers: []InventoryServer{
+			{Host: "10.0.1.1"},
+		},
+	}}}
+
+	config := configs.New(nil)
+	config.RemoteUser = "admin"
+	config.RemoteSSHKey = "/keys/default"
+	config.Network = constants.NetworkMainnet
+
+	var hosts []string
+	err := inv.ForEachHost(context.Background(), config, func(name string) error {
+		command, err := getRemoteUpdateCommand(config, "node")
+		hosts = append(hosts, fmt.Sprintf("%s %s@%s:%d %s %s", name, config.RemoteUser, config.RemoteIP,
+			config.RemotePort, config.RemoteSSHKey, strings.TrimPrefix(command, "yes Y | "+constants.RemotePastelupPath+" update ")))
+		return err
+	})
+	// host of the second group has no release, it fails
+	assert.NotNil(t, err)
+	assert.Equal(t, "command failed on 1 of 3 hosts", err.Error())
+	assert.Equal(t, []string{
+		"10.0.0.1 pastel@10.0.0.1:2222 /keys/first node --release=beta",
+		"10.0.0.2 root@10.0.0.2:2222 /keys/first node --release=v1.2.3 -n=testnet",
+		"10.0.1.1 admin@10.0.1.1:22 /keys/default ",
+	}, hosts)
+
+	// command line parameters are restored
+	assert.Equal(t, "admin", config.RemoteUser)
+	assert.Equal(t, "/keys/default", config.RemoteSSHKey)
+	assert.Equal(t, constants.NetworkMainnet, config.Network)
+	assert.Equal(t, "", config.Version)
+}
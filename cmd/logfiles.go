@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pkg/errors"
+)
+
+// logComponents are the components writing log files to the working directory
+var logComponents = []constants.ToolType{
+	constants.PastelD,
+	constants.SuperNode,
+	constants.Hermes,
+	constants.Bridge,
+	constants.WalletNode,
+}
+
+// getLogComponents parses the component name, "all" is all known components
+func getLogComponents(component string, known []constants.ToolType) ([]constants.ToolType, error) {
+	if component == "all" {
+		return known, nil
+	}
+	tool := constants.ToolType(component)
+	for _, c := range known {
+		if c == tool {
+			return []constants.ToolType{tool}, nil
+		}
+	}
+	var names []string
+	for _, c := range known {
+		names = append(names, string(c))
+	}
+	return nil, errors.Errorf("unknown component %q, must be \"all\" or one of: %s", component, strings.Join(names, ", "))
+}
+
+// getLogPatterns returns glob patterns of the log files of the component relative to the working directory,
+// including the rotated ones
+func getLogPatterns(config *configs.Config, tool constants.ToolType) []string {
+	var logFile string
+	switch tool {
+	case constants.PastelD:
+		return []string{"debug.log", "testnet3/debug.log", "regtest/debug.log"}
+	case constants.SuperNode:
+		logFile = config.Configurer.GetSuperNodeLogFile("")
+	case constants.Hermes:
+		logFile = config.Configurer.GetHermesLogFile("")
+	case constants.Bridge:
+		logFile = config.Configurer.GetBridgeLogFile("")
+	case constants.WalletNode:
+		logFile = config.Configurer.GetWalletNodeLogFile("")
+	default:
+		return nil
+	}
+	// rotated logs are named like supernode-2022-01-02T15-04-05.000.log.gz
+	name := strings.TrimSuffix(filepath.Base(logFile), filepath.Ext(logFile))
+	return []string{name + "*" + filepath.Ext(logFile) + "*"}
+}
+
+// getLogFile returns path of the current log file of the component, empty if the component doesn't write it
+func getLogFile(config *configs.Config, tool constants.ToolType) string {
+	switch tool {
+	case constants.PastelD:
+		return getMasternodeConfPath(config, config.WorkingDir, "debug.log")
+	case constants.SuperNode:
+		return config.Configurer.GetSuperNodeLogFile(config.WorkingDir)
+	case constants.Hermes:
+		return config.Configurer.GetHermesLogFile(config.WorkingDir)
+	case constants.Bridge:
+		return config.Configurer.GetBridgeLogFile(config.WorkingDir)
+	case constants.WalletNode:
+		return config.Configurer.GetWalletNodeLogFile(config.WorkingDir)
+	}
+	return ""
+}
+
+// getRotatedLogFiles returns rotated log files of the component from the oldest to the newest
+func getRotatedLogFiles(config *configs.Config, tool constants.ToolType) []string {
+	if tool == constants.PastelD {
+		// pasteld doesn't rotate debug.log to separate files, the other matches are logs of the other networks
+		return nil
+	}
+	current := getLogFile(config, tool)
+	type rotated struct {
+		path    string
+		modTime time.Time
+	}
+	var files []rotated
+	for _, pattern := range getLogPatterns(config, tool) {
+		matches, _ := filepath.Glob(filepath.Join(config.WorkingDir, pattern))
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || match == current || !info.Mode().IsRegular() {
+				continue
+			}
+			files = append(files, rotated{path: match, modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths
+}
@@ -0,0 +1,459 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+// logsPollInterval is how often followed log files are checked for new lines
+const logsPollInterval = time.Second
+
+var (
+	flagLogsFollow bool
+	flagLogsSince  time.Duration
+	flagLogsGrep   string
+	flagLogsLevel  string
+	flagLogsLines  int
+)
+
+// logsComponents are components which logs are shown, the ones without log files only log to journald
+var logsComponents = append(append([]constants.ToolType(nil), logComponents...),
+	constants.RQService, constants.DDService, constants.DDImgService)
+
+// logSource is the log file or journal of the systemd unit of the component
+type logSource struct {
+	component constants.ToolType
+	path      string
+	// rotated are rotated log files from the oldest
+	rotated []string
+	// journal is journalctl arguments selecting the unit
+	journal []string
+}
+
+func (s logSource) String() string {
+	if len(s.path) != 0 {
+		return s.path
+	}
+	return "journalctl " + strings.Join(s.journal, " ")
+}
+
+// logsFilter selects records to show
+type logsFilter struct {
+	since time.Time
+	level int
+	grep  *regexp.Regexp
+}
+
+// selective checks if the filter drops records regardless of their time,
+// so the last records can't be found without reading whole logs
+func (f logsFilter) selective() bool {
+	return f.level > 0 || f.grep != nil
+}
+
+func (f logsFilter) match(record utils.LogRecord) bool {
+	if !f.since.IsZero() && record.Time.Before(f.since) {
+		return false
+	}
+	if record.Level < f.level {
+		return false
+	}
+	return f.grep == nil || f.grep.MatchString(record.Text)
+}
+
+// componentRecord is the log record of the component
+type componentRecord struct {
+	component constants.ToolType
+	utils.LogRecord
+}
+
+func setupLogsCommand(config *configs.Config) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("follow", &flagLogsFollow).SetAliases("f").
+			SetUsage(green("Optional, keep showing new log records")),
+		cli.NewFlag("since", &flagLogsSince).
+			SetUsage(green("Optional, show records within this period, e.g. 2h, rotated log files are read too")),
+		cli.NewFlag("grep", &flagLogsGrep).
+			SetUsage(green("Optional, show only records matching the regular expression")),
+		cli.NewFlag("level", &flagLogsLevel).
+			SetUsage(green("Optional, minimal level of the records - " + strings.Join(utils.LogLevels, ", "))).SetValue("trace"),
+		cli.NewFlag("lines", &flagLogsLines).SetAliases("n").
+			SetUsage(green("Optional, number of the last records to show, 0 - all")).SetValue(100),
+	}
+
+	logsCommand := cli.NewCommand("logs")
+	logsCommand.SetUsage(blue("Shows logs of pastel components merged by time"))
+	logsCommand.AddFlags(commandFlags...)
+	addInstanceFlag(logsCommand, config)
+	addLogFlags(logsCommand, config)
+
+	var names []string
+	for _, tool := range logsComponents {
+		names = append(names, string(tool))
+	}
+	argsUsage := fmt.Sprintf("[all|%s]...", strings.Join(names, "|"))
+
+	setActionFuncWithArgs(logsCommand, argsUsage, func(ctx context.Context, args []string) error {
+		ctx, err := configureLogging(ctx, "logs", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		// own logs must not be mixed with the shown ones
+		if !config.Quiet {
+			log.SetOutput(os.Stderr)
+		}
+		if err = applyInstance(config); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			os.Exit(0)
+		})
+
+		if len(args) == 0 {
+			args = []string{"all"}
+		}
+		var components []constants.ToolType
+		for _, arg := range args {
+			tools, err := getLogComponents(arg, logsComponents)
+			if err != nil {
+				return err
+			}
+			for _, tool := range tools {
+				if !utils.ContainsToolType(components, tool) {
+					components = append(components, tool)
+				}
+			}
+		}
+
+		// network, so location of debug.log, is in pastel.conf
+		if err = ParsePastelConf(ctx, config); err != nil {
+			log.WithContext(ctx).WithError(err).Warn("Failed to parse pastel.conf, mainnet debug.log is shown")
+		}
+		return runLogs(ctx, config, components)
+	})
+	return logsCommand
+}
+
+func runLogs(ctx context.Context, config *configs.Config, components []constants.ToolType) error {
+	var err error
+	filter := logsFilter{}
+	if flagLogsSince > 0 {
+		filter.since = time.Now().Add(-flagLogsSince)
+	}
+	if filter.level, err = utils.ParseLogLevel(flagLogsLevel); err != nil {
+		return err
+	}
+	if len(flagLogsGrep) != 0 {
+		if filter.grep, err = regexp.Compile(flagLogsGrep); err != nil {
+			return errors.Errorf("invalid --grep: %v", err)
+		}
+	}
+
+	sources := getLogSources(ctx, config, components)
+	if len(sources) == 0 {
+		return errors.Errorf("no logs of %v found in %s or journald", components, config.WorkingDir)
+	}
+
+	var records []componentRecord
+	offsets := make(map[string]int64)
+	for _, source := range sources {
+		log.WithContext(ctx).Debugf("Reading %s logs from %s", source.component, source)
+		sourceRecords, offset, err := readLogSource(ctx, source, filter)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Failed to read %s logs from %s", source.component, source)
+			continue
+		}
+		offsets[source.path] = offset
+		for _, record := range sourceRecords {
+			if filter.match(record) {
+				records = append(records, componentRecord{component: source.component, LogRecord: record})
+			}
+		}
+	}
+
+	// records of the same component keep their order
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	if flagLogsLines > 0 && len(records) > flagLogsLines {
+		records = records[len(records)-flagLogsLines:]
+	}
+	for _, record := range records {
+		printLogRecord(record.component, record.Text)
+	}
+
+	if !flagLogsFollow {
+		return nil
+	}
+	return followLogs(ctx, sources, offsets, filter)
+}
+
+// getLogSources returns log files and journals of the components
+func getLogSources(ctx context.Context, config *configs.Config, components []constants.ToolType) []logSource {
+	// journal is only available for systemd services
+	var systemd *LinuxSystemdManager
+	if sm, err := NewServiceManager(config); err == nil {
+		if m, ok := sm.(LinuxSystemdManager); ok {
+			systemd = &m
+		}
+	}
+
+	var sources []logSource
+	for _, tool := range components {
+		found := false
+		if logFile := getLogFile(config, tool); len(logFile) != 0 && utils.CheckFileExist(logFile) {
+			sources = append(sources, logSource{component: tool, path: logFile, rotated: getRotatedLogFiles(config, tool)})
+			found = true
+		}
+		// output of the service which isn't in the log file, e.g. crash of the component
+		if systemd != nil && systemd.hasUnit(ctx, tool) {
+			journal := append(systemd.userArgs(), "-u", systemd.ServiceName(tool))
+			sources = append(sources, logSource{component: tool, journal: journal})
+			found = true
+		}
+		if !found {
+			log.WithContext(ctx).Debugf("No logs of %s found", tool)
+		}
+	}
+	return sources
+}
+
+// readLogSource reads records of the source, returns size of the log file which is followed from there
+func readLogSource(ctx context.Context, source logSource, filter logsFilter) ([]utils.LogRecord, int64, error) {
+	if len(source.path) == 0 {
+		records, err := readJournal(ctx, source.journal, filter)
+		return records, 0, err
+	}
+
+	info, err := os.Stat(source.path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// only last lines of the current file are needed
+	if filter.since.IsZero() && !filter.selective() && flagLogsLines > 0 {
+		data, err := utils.TailFile(source.path, flagLogsLines)
+		if err != nil {
+			return nil, 0, err
+		}
+		records, err := utils.ReadLogRecords(bytes.NewReader(data))
+		return records, info.Size(), err
+	}
+
+	var records []utils.LogRecord
+	files := append(append([]string(nil), source.rotated...), source.path)
+	for _, file := range files {
+		if file != source.path && !filter.since.IsZero() {
+			if fileInfo, err := os.Stat(file); err != nil || fileInfo.ModTime().Before(filter.since) {
+				continue
+			}
+		}
+		fileRecords, err := readLogFile(file)
+		if err != nil {
+			return nil, 0, err
+		}
+		records = append(records, fileRecords...)
+	}
+	return records, info.Size(), nil
+}
+
+// readLogFile reads records of the log file, rotated files may be compressed
+func readLogFile(filePath string) ([]utils.LogRecord, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(filePath, ".gz") {
+		gzr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, errors.Errorf("failed to read %s: %v", filePath, err)
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+	return utils.ReadLogRecords(r)
+}
+
+func getJournalArgs(journal []string, filter logsFilter) []string {
+	args := append(append([]string(nil), journal...), "--no-pager", "-o", "short-iso")
+	if !filter.since.IsZero() {
+		args = append(args, "--since", fmt.Sprintf("@%d", filter.since.Unix()))
+	}
+	return args
+}
+
+func readJournal(ctx context.Context, journal []string, filter logsFilter) ([]utils.LogRecord, error) {
+	args := getJournalArgs(journal, filter)
+	if !filter.selective() && flagLogsLines > 0 {
+		args = append(args, "-n", fmt.Sprint(flagLogsLines))
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Errorf("journalctl failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	// journal lines are the records, the records without timestamp are "-- No entries --" etc.
+	records, err := utils.ReadLogRecords(bytes.NewReader(out))
+	var withTime []utils.LogRecord
+	for _, record := range records {
+		if !record.Time.IsZero() {
+			withTime = append(withTime, record)
+		}
+	}
+	return withTime, err
+}
+
+func printLogRecord(component constants.ToolType, text string) {
+	prefix := fmt.Sprintf("[%s] ", component)
+	fmt.Println(prefix + strings.ReplaceAll(text, "\n", "\n"+prefix))
+}
+
+// followLogs shows new records of the sources until ctx is done, log files are read from the offsets,
+// rotated or truncated files are read from the beginning
+func followLogs(ctx context.Context, sources []logSource, offsets map[string]int64, filter logsFilter) error {
+	records := make(chan componentRecord)
+	for _, source := range sources {
+		source := source
+		if len(source.path) != 0 {
+			go followLogFile(ctx, source, offsets[source.path], records)
+		} else {
+			go followJournal(ctx, source, records)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case record := <-records:
+			if filter.match(record.LogRecord) {
+				printLogRecord(record.component, record.Text)
+			}
+		}
+	}
+}
+
+func followLogFile(ctx context.Context, source logSource, offset int64, records chan<- componentRecord) {
+	parser := utils.NewLogParser()
+	var partial string
+	lastInfo, _ := os.Stat(source.path)
+
+	send := func(record *utils.LogRecord) {
+		if record != nil {
+			select {
+			case records <- componentRecord{component: source.component, LogRecord: *record}:
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(logsPollInterval):
+		}
+
+		info, err := os.Stat(source.path)
+		if err != nil {
+			// file is being rotated
+			continue
+		}
+		if (lastInfo != nil && !os.SameFile(lastInfo, info)) || info.Size() < offset {
+			log.WithContext(ctx).Debugf("%s is rotated", source.path)
+			offset, partial = 0, ""
+		}
+		lastInfo = info
+		if info.Size() == offset {
+			// record may have more lines, it is complete if nothing is added for a while
+			send(parser.Flush())
+			continue
+		}
+
+		data, err := readFileFrom(source.path, offset)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Failed to read %s", source.path)
+			continue
+		}
+		offset += int64(len(data))
+
+		lines := strings.Split(partial+string(data), "\n")
+		// the last line is not finished yet
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			send(parser.Add(line))
+		}
+	}
+}
+
+func readFileFrom(filePath string, offset int64) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+func followJournal(ctx context.Context, source logSource, records chan<- componentRecord) {
+	// records before now are already shown
+	args := append(getJournalArgs(source.journal, logsFilter{}), "-f", "-n", "0")
+	cmd := exec.CommandContext(ctx, "journalctl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Failed to follow %s", source)
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Failed to follow %s", source)
+		return
+	}
+	defer cmd.Wait()
+
+	// every journal line is the record
+	parser := utils.NewLogParser()
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		parser.Add(scanner.Text())
+		record := parser.Flush()
+		if record.Time.IsZero() {
+			continue
+		}
+		select {
+		case records <- componentRecord{component: source.component, LogRecord: *record}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
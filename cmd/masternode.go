@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type masternodeCommand uint8
+
+const (
+	mnCollateral masternodeCommand = iota
+	mnCollateralLock
+	mnRewards
+)
+
+// rpcInvalidAddressOrKey is returned by pasteld when transaction is not (yet) known to the wallet
+const rpcInvalidAddressOrKey = -5
+
+var (
+	masternodeCmdName = map[masternodeCommand]string{
+		mnCollateral:     "collateral",
+		mnCollateralLock: "lock",
+		mnRewards:        "rewards",
+	}
+	masternodeCmdMessage = map[masternodeCommand]string{
+		mnCollateral:     "Create, wait for, validate and lock masternode collateral",
+		mnCollateralLock: "Lock collateral outputs of all masternodes from masternode.conf",
+		mnRewards:        "Show rewards received by masternodes from masternode.conf",
+	}
+)
+
+var (
+	flagCollateralNewAddress    bool
+	flagCollateralConfirmations int
+	flagCollateralTimeout       time.Duration
+	flagCollateralNoLock        bool
+)
+
+func setupMasternodeSubCommand(config *configs.Config,
+	mnCommand masternodeCommand,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+
+	commonFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+	}
+
+	collateralFlags := []*cli.Flag{
+		cli.NewFlag("new-address", &flagCollateralNewAddress).
+			SetUsage(green("Optional, generate new address to send collateral to")),
+		cli.NewFlag("txid", &flagMasterNodeTxID).
+			SetUsage(yellow("Required (if --new-address is not used), collateral payment txid")),
+		cli.NewFlag("ind", &flagMasterNodeInd).
+			SetUsage(green("Optional, collateral payment output index, if omitted, will be found by collateral amount")),
+		cli.NewFlag("confirmations", &flagCollateralConfirmations).
+			SetUsage(green("Optional, number of confirmations collateral transaction must have")).SetValue(constants.CollateralDefaultConfirmations),
+		cli.NewFlag("timeout", &flagCollateralTimeout).
+			SetUsage(green("Optional, how long to wait for collateral transaction, e.g. 30m")).SetValue(constants.CollateralDefaultTimeout),
+		cli.NewFlag("no-lock", &flagCollateralNoLock).
+			SetUsage(yellow("Optional, do not lock collateral output in the wallet")),
+	}
+
+	rewardsFlags := []*cli.Flag{
+		cli.NewFlag("since", &flagRewardsSince).
+			SetUsage(green("Optional, report period, e.g. 30d, 2w or 12h")).SetValue("30d"),
+		cli.NewFlag("output", &flagRewardsOutput).
+			SetUsage(green("Optional, report format. Available choices are: 'console', 'csv' and 'json'")).SetValue("console"),
+		cli.NewFlag("file", &flagRewardsFile).
+			SetUsage(green("Optional, path to the file to save report to, if omitted, report is printed to stdout")),
+	}
+
+	commandFlags := commonFlags
+	switch mnCommand {
+	case mnCollateral:
+		commandFlags = append(commandFlags, collateralFlags[:]...)
+	case mnRewards:
+		commandFlags = append(commandFlags, rewardsFlags[:]...)
+	}
+
+	commandName := masternodeCmdName[mnCommand]
+	commandMessage := masternodeCmdMessage[mnCommand]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return fmt.Errorf("failed to configure logging option - %v", err)
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sys.RegisterInterruptHandler(cancel, func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+
+			if err = ParsePastelConf(ctx, config); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Started")
+			if err = f(ctx, config); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Finished successfully!")
+			return nil
+		})
+	}
+	return subCommand
+}
+
+func setupMasternodeCommand(config *configs.Config) *cli.Command {
+
+	collateralSubCommand := setupMasternodeSubCommand(config, mnCollateral, runMasternodeCollateral)
+	collateralSubCommand.AddSubcommands(setupMasternodeSubCommand(config, mnCollateralLock, runMasternodeCollateralLock))
+
+	masternodeCommand := cli.NewCommand("masternode")
+	masternodeCommand.SetUsage(blue("Perform masternode related operations"))
+	masternodeCommand.AddSubcommands(collateralSubCommand)
+	masternodeCommand.AddSubcommands(setupMasternodeSubCommand(config, mnRewards, runMasternodeRewards))
+
+	return masternodeCommand
+}
+
+func runMasternodeCollateral(ctx context.Context, config *configs.Config) error {
+	amount, ok := constants.MasternodeCollateralAmount[config.Network]
+	if !ok {
+		return errors.Errorf("unknown network - %s", config.Network)
+	}
+
+	if flagCollateralNewAddress {
+		address, err := getNewAddress(ctx, config)
+		if err != nil {
+			return err
+		}
+		log.WithContext(ctx).Warnf(red(fmt.Sprintf("Your new address for collateral payment is %s", address)))
+		log.WithContext(ctx).Warnf(red(fmt.Sprintf("Use another wallet to send exactly %s %s to that address.",
+			formatCollateralAmount(amount), constants.CoinName[config.Network])))
+		if len(flagMasterNodeTxID) == 0 {
+			log.WithContext(ctx).Info("Run this command again with --txid of the payment to validate and lock collateral")
+			return nil
+		}
+	}
+
+	if len(flagMasterNodeTxID) == 0 {
+		return fmt.Errorf("required parameter: --txid <collateral payment txid> or --new-address")
+	}
+
+	vout, err := waitForCollateral(ctx, config, flagMasterNodeTxID, flagMasterNodeInd,
+		flagCollateralConfirmations, flagCollateralTimeout)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Collateral transaction is not valid")
+		return err
+	}
+
+	if !flagCollateralNoLock {
+		if err := lockCollateral(ctx, config, flagMasterNodeTxID, vout); err != nil {
+			return err
+		}
+	}
+
+	log.WithContext(ctx).Infof(red(fmt.Sprintf("masternode outputs = %s, %d", flagMasterNodeTxID, vout)))
+	log.WithContext(ctx).Infof("Use --txid=%s --ind=%d with 'pastelup init supernode'", flagMasterNodeTxID, vout)
+	return nil
+}
+
+func runMasternodeCollateralLock(ctx context.Context, config *configs.Config) error {
+	return relockMasternodeCollaterals(ctx, config)
+}
+
+///// Collateral helpers
+func getNewAddress(ctx context.Context, config *configs.Config) (string, error) {
+	var resp map[string]interface{}
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetNewAddressCmd, &resp); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to get new address")
+		return "", err
+	}
+	address, ok := resp["result"].(string)
+	if !ok {
+		err := errors.Errorf("unexpected getnewaddress response: %v", resp["error"])
+		log.WithContext(ctx).WithError(err).Error("Failed to get new address")
+		return "", err
+	}
+	return address, nil
+}
+
+// waitForCollateral waits until the collateral transaction has the required confirmation depth,
+// and returns the output index of the collateral amount
+func waitForCollateral(ctx context.Context, config *configs.Config, txid string, ind string,
+	confirmations int, timeout time.Duration) (int, error) {
+
+	amount, ok := constants.MasternodeCollateralAmount[config.Network]
+	if !ok {
+		return -1, errors.Errorf("unknown network - %s", config.Network)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		vout, confs, err := getCollateralOutput(config, txid, ind, amount)
+		if err != nil {
+			return -1, err
+		}
+		if vout >= 0 && confs >= int64(confirmations) {
+			if err := checkCollateralUnspent(config, txid, vout); err != nil {
+				return -1, err
+			}
+			return vout, nil
+		}
+
+		if vout < 0 {
+			log.WithContext(ctx).Infof("Waiting for collateral transaction %s...", txid)
+		} else {
+			log.WithContext(ctx).Infof("Collateral transaction %s has %d of %d confirmations", txid, confs, confirmations)
+		}
+
+		select {
+		case <-ctx.Done():
+			return -1, errors.Errorf("timeout waiting for collateral transaction %s after %v", txid, timeout)
+		case <-time.After(constants.CollateralPollInterval):
+		}
+	}
+}
+
+// getCollateralOutput returns output index and confirmations of the collateral output, or -1 if transaction is not in the wallet yet
+func getCollateralOutput(config *configs.Config, txid string, ind string, amount float64) (int, int64, error) {
+	var resp structure.RPCGetTransaction
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.GetTransactionCmd, []string{txid}, &resp); err != nil {
+		return -1, 0, err
+	}
+	if resp.Error != nil {
+		if resp.Error.Code == rpcInvalidAddressOrKey {
+			return -1, 0, nil
+		}
+		return -1, 0, resp.Error
+	}
+
+	for _, detail := range resp.Result.Details {
+		if detail.Category != "receive" {
+			continue
+		}
+		if len(ind) != 0 && strconv.Itoa(detail.Vout) != ind {
+			continue
+		}
+		if detail.Amount != amount {
+			if len(ind) != 0 {
+				return -1, 0, errors.Errorf("output %s:%d has %s %s, expected exactly %s %s", txid, detail.Vout,
+					formatCollateralAmount(detail.Amount), constants.CoinName[config.Network],
+					formatCollateralAmount(amount), constants.CoinName[config.Network])
+			}
+			continue
+		}
+		return detail.Vout, resp.Result.Confirmations, nil
+	}
+	return -1, 0, errors.Errorf("transaction %s has no output of exactly %s %s to this wallet", txid,
+		formatCollateralAmount(amount), constants.CoinName[config.Network])
+}
+
+func checkCollateralUnspent(config *configs.Config, txid string, vout int) error {
+	var resp structure.RPCGetTxOut
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.GetTxOutCmd, []interface{}{txid, vout}, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if resp.Result == nil {
+		return errors.Errorf("collateral output %s:%d is already spent", txid, vout)
+	}
+	return nil
+}
+
+// lockCollateral locks collateral output in the wallet, so it can't be spent accidentally
+func lockCollateral(ctx context.Context, config *configs.Config, txid string, vout int) error {
+	var resp structure.RPCLockUnspent
+	err := pastelcore.NewClient(config).RunCommandWithArgs(
+		pastelcore.LockUnspentCmd,
+		[]interface{}{false, []structure.Outpoint{{TxID: txid, Vout: vout}}},
+		&resp,
+	)
+	if err == nil && resp.Error != nil {
+		err = resp.Error
+	}
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to lock collateral output %s:%d", txid, vout)
+		return err
+	}
+	log.WithContext(ctx).Infof("Collateral output %s:%d is locked", txid, vout)
+	return nil
+}
+
+// relockMasternodeCollaterals locks collateral outputs of all masternodes in masternode.conf.
+// pasteld keeps locks only in memory, so this has to be done after every restart
+func relockMasternodeCollaterals(ctx context.Context, config *configs.Config) error {
+	conf, err := loadMasternodeConfFile(ctx, config)
+	if err != nil {
+		return err
+	}
+	for name, mn := range conf {
+		vout, err := strconv.Atoi(mn.OutIndex)
+		if len(mn.Txid) == 0 || err != nil {
+			log.WithContext(ctx).Warnf("Masternode %s has no valid collateral in masternode.conf - skipping", name)
+			continue
+		}
+		if err := lockCollateral(ctx, config, mn.Txid, vout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relockCollateralsAfterStart locks collaterals of masternodes after pasteld was started by pastelup,
+// pasteld doesn't persist locked outputs, failure is only reported
+func relockCollateralsAfterStart(ctx context.Context, config *configs.Config) {
+	if !utils.CheckFileExist(getMasternodeConfPath(config, config.WorkingDir, "masternode.conf")) {
+		return
+	}
+	if err := relockMasternodeCollaterals(ctx, config); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Failed to lock masternode collateral, make sure not to spend it")
+	}
+}
+
+func formatCollateralAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,374 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+// listTransactionsPageSize is number of transactions requested from the wallet at once
+const listTransactionsPageSize = 500
+
+var (
+	flagRewardsSince  string
+	flagRewardsOutput string
+	flagRewardsFile   string
+)
+
+type rewardsReport struct {
+	Since          time.Time      `json:"since"`
+	Until          time.Time      `json:"until"`
+	EnabledNodes   int            `json:"enabled_masternodes"`
+	Nodes          []nodeRewards  `json:"nodes"`
+	DailyPayments  []dailyRewards `json:"daily"`
+	TotalAmount    float64        `json:"total_amount"`
+	ExpectedAmount float64        `json:"expected_amount"`
+	CoinName       string         `json:"coin"`
+}
+
+type nodeRewards struct {
+	Alias            string  `json:"alias"`
+	Address          string  `json:"address"`
+	Payments         int     `json:"payments"`
+	Amount           float64 `json:"amount"`
+	ExpectedPayments float64 `json:"expected_payments"`
+	ExpectedAmount   float64 `json:"expected_amount"`
+	UpcomingPayments int     `json:"upcoming_payments"`
+}
+
+type dailyRewards struct {
+	Day      string  `json:"day"`
+	Alias    string  `json:"alias"`
+	Address  string  `json:"address"`
+	Payments int     `json:"payments"`
+	Amount   float64 `json:"amount"`
+}
+
+func runMasternodeRewards(ctx context.Context, config *configs.Config) error {
+	since, err := utils.ParseDuration(flagRewardsSince)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Invalid --since value")
+		return err
+	}
+
+	report, err := getRewardsReport(ctx, config, since)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to collect masternode rewards")
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if len(flagRewardsFile) != 0 {
+		f, err := os.Create(flagRewardsFile)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to create file - %s", flagRewardsFile)
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch flagRewardsOutput {
+	case "json":
+		err = writeRewardsJSON(out, report)
+	case "csv":
+		err = writeRewardsCSV(out, report)
+	case "console":
+		writeRewardsTables(out, report)
+	default:
+		err = errors.Errorf("unknown output format - %s", flagRewardsOutput)
+	}
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to write rewards report")
+		return err
+	}
+	if len(flagRewardsFile) != 0 {
+		log.WithContext(ctx).Infof("Rewards report saved to %s", flagRewardsFile)
+	}
+	return nil
+}
+
+func getRewardsReport(ctx context.Context, config *configs.Config, period time.Duration) (*rewardsReport, error) {
+	report := &rewardsReport{
+		Until:    time.Now().UTC(),
+		CoinName: constants.CoinName[config.Network],
+	}
+	report.Since = report.Until.Add(-period)
+
+	conf, err := loadMasternodeConfFile(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// masternode payments are sent to the address of the collateral
+	collaterals := make(map[string]bool)
+	aliases := make(map[string]string)
+	nodes := make(map[string]*nodeRewards)
+	for alias, mn := range conf {
+		address, err := getCollateralAddress(config, mn.Txid, mn.OutIndex)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Cannot get payout address of masternode %s - skipping", alias)
+			continue
+		}
+		collaterals[mn.Txid] = true
+		aliases[address] = alias
+		nodes[alias] = &nodeRewards{Alias: alias, Address: address}
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no masternodes with valid collateral found in masternode.conf")
+	}
+
+	txs, err := listWalletTransactions(config, report.Since)
+	if err != nil {
+		return nil, err
+	}
+
+	daily := make(map[string]*dailyRewards)
+	for _, tx := range txs {
+		alias, ok := aliases[tx.Address]
+		if !ok || collaterals[tx.TxID] || !isRewardCategory(tx.Category) {
+			continue
+		}
+		txTime := time.Unix(int64(tx.Time), 0).UTC()
+		if txTime.Before(report.Since) {
+			continue
+		}
+
+		node := nodes[alias]
+		node.Payments++
+		node.Amount += tx.Amount
+		report.TotalAmount += tx.Amount
+
+		day := txTime.Format("2006-01-02")
+		key := day + "/" + alias
+		if _, ok := daily[key]; !ok {
+			daily[key] = &dailyRewards{Day: day, Alias: alias, Address: tx.Address}
+		}
+		daily[key].Payments++
+		daily[key].Amount += tx.Amount
+	}
+
+	report.EnabledNodes, err = getEnabledMasternodesCount(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Cannot get masternode list - expected payouts will not be estimated")
+	}
+	winners, err := getMasternodeWinners(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Cannot get masternode winners - upcoming payouts will not be shown")
+	}
+
+	// every enabled masternode is expected to be paid once per number of enabled masternodes blocks,
+	// expected amount is the masternode reward of the network, so it is known even if no node was paid
+	payment, err := getMasternodePayment(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Cannot get masternode reward of the network - expected amount is estimated from the actual payments")
+		var totalPayments int
+		for _, node := range nodes {
+			totalPayments += node.Payments
+		}
+		if totalPayments > 0 {
+			payment = report.TotalAmount / float64(totalPayments)
+		}
+	}
+	for _, node := range nodes {
+		if report.EnabledNodes > 0 {
+			node.ExpectedPayments = period.Hours() / 24 * constants.BlocksPerDay / float64(report.EnabledNodes)
+			node.ExpectedAmount = node.ExpectedPayments * payment
+			report.ExpectedAmount += node.ExpectedAmount
+		}
+		for _, payee := range winners {
+			if strings.Contains(payee, node.Address) {
+				node.UpcomingPayments++
+			}
+		}
+		report.Nodes = append(report.Nodes, *node)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		return report.Nodes[i].Alias < report.Nodes[j].Alias
+	})
+
+	for _, d := range daily {
+		report.DailyPayments = append(report.DailyPayments, *d)
+	}
+	sort.Slice(report.DailyPayments, func(i, j int) bool {
+		if report.DailyPayments[i].Day == report.DailyPayments[j].Day {
+			return report.DailyPayments[i].Alias < report.DailyPayments[j].Alias
+		}
+		return report.DailyPayments[i].Day < report.DailyPayments[j].Day
+	})
+
+	return report, nil
+}
+
+func isRewardCategory(category string) bool {
+	// masternode payments are part of coinbase transaction, other transfers to the address are "receive"
+	return category == "generate" || category == "immature"
+}
+
+func getCollateralAddress(config *configs.Config, txid string, outIndex string) (string, error) {
+	var resp structure.RPCGetTransaction
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.GetTransactionCmd, []string{txid}, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != nil {
+		return "", resp.Error
+	}
+	for _, detail := range resp.Result.Details {
+		if detail.Category == "receive" && fmt.Sprint(detail.Vout) == outIndex {
+			return detail.Address, nil
+		}
+	}
+	return "", errors.Errorf("output %s:%s is not found in the wallet", txid, outIndex)
+}
+
+// listWalletTransactions returns wallet transactions starting from the most recent one until the since time
+func listWalletTransactions(config *configs.Config, since time.Time) ([]structure.TxInfo, error) {
+	var txs []structure.TxInfo
+	for from := 0; ; from += listTransactionsPageSize {
+		var resp structure.RPCListTransactions
+		err := pastelcore.NewClient(config).RunCommandWithArgs(
+			pastelcore.ListTransactionsCmd,
+			[]interface{}{"*", listTransactionsPageSize, from},
+			&resp,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		txs = append(txs, resp.Result...)
+
+		// page is ordered from the oldest to the newest transaction
+		if len(resp.Result) < listTransactionsPageSize ||
+			time.Unix(int64(resp.Result[0].Time), 0).Before(since) {
+			break
+		}
+	}
+	return txs, nil
+}
+
+func getEnabledMasternodesCount(config *configs.Config) (int, error) {
+	var resp map[string]interface{}
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"list", "status"}, &resp); err != nil {
+		return 0, err
+	}
+	list, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		return 0, errors.Errorf("unexpected masternode list response: %v", resp["error"])
+	}
+	count := 0
+	for _, status := range list {
+		if strings.Contains(fmt.Sprint(status), "ENABLED") {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// getMasternodePayment returns masternode part of the block subsidy at the current height
+func getMasternodePayment(config *configs.Config) (float64, error) {
+	var resp map[string]interface{}
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetBlockSubsidyCmd, &resp); err != nil {
+		return 0, err
+	}
+	subsidy, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		return 0, errors.Errorf("unexpected block subsidy response: %v", resp["error"])
+	}
+	payment, ok := subsidy["masternode"].(float64)
+	if !ok || payment <= 0 {
+		return 0, errors.Errorf("block subsidy has no masternode reward: %v", subsidy)
+	}
+	return payment, nil
+}
+
+// getMasternodeWinners returns payees of the upcoming blocks
+func getMasternodeWinners(config *configs.Config) ([]string, error) {
+	var resp map[string]interface{}
+	if err := pastelcore.NewClient(config).RunCommandWithArgs(pastelcore.MasterNodeCmd, []string{"winners"}, &resp); err != nil {
+		return nil, err
+	}
+	list, ok := resp["result"].(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected masternode winners response: %v", resp["error"])
+	}
+	var winners []string
+	for _, payee := range list {
+		winners = append(winners, fmt.Sprint(payee))
+	}
+	return winners, nil
+}
+
+func writeRewardsJSON(w io.Writer, report *rewardsReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeRewardsCSV(w io.Writer, report *rewardsReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"day", "alias", "address", "payments", "amount"}); err != nil {
+		return err
+	}
+	for _, d := range report.DailyPayments {
+		if err := writer.Write([]string{d.Day, d.Alias, d.Address,
+			fmt.Sprint(d.Payments), formatCollateralAmount(d.Amount)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeRewardsTables(w io.Writer, report *rewardsReport) {
+	fmt.Fprintf(w, "Masternode rewards from %s to %s (%d enabled masternodes)\n",
+		report.Since.Format(time.RFC3339), report.Until.Format(time.RFC3339), report.EnabledNodes)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Alias", "Address", "Payments", "Amount", "Expected Payments", "Expected Amount", "Upcoming"})
+	for _, node := range report.Nodes {
+		table.Append([]string{
+			node.Alias,
+			node.Address,
+			fmt.Sprint(node.Payments),
+			fmt.Sprintf("%.5f %s", node.Amount, report.CoinName),
+			fmt.Sprintf("%.1f", node.ExpectedPayments),
+			fmt.Sprintf("%.5f %s", node.ExpectedAmount, report.CoinName),
+			fmt.Sprint(node.UpcomingPayments),
+		})
+	}
+	table.SetFooter([]string{"", "", "Total", fmt.Sprintf("%.5f %s", report.TotalAmount, report.CoinName),
+		"", fmt.Sprintf("%.5f %s", report.ExpectedAmount, report.CoinName), ""})
+	table.Render()
+
+	table = tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Day", "Alias", "Payments", "Amount"})
+	for _, d := range report.DailyPayments {
+		table.Append([]string{
+			d.Day,
+			d.Alias,
+			fmt.Sprint(d.Payments),
+			fmt.Sprintf("%.5f %s", d.Amount, report.CoinName),
+		})
+	}
+	table.Render()
+}
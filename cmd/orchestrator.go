@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	componentProbeInterval = 2 * time.Second
+	// componentStartGrace is time given to the started process to show up in the process list
+	componentStartGrace = 10 * time.Second
+	// defaultComponentReadyTimeout is time to wait for the component to become ready, if not set in componentReadyTimeouts
+	defaultComponentReadyTimeout = 2 * time.Minute
+	componentStopTimeout         = 2 * time.Minute
+)
+
+// componentReadyTimeouts are readiness timeouts of the components which are slow to start
+var componentReadyTimeouts = map[constants.ToolType]time.Duration{
+	constants.PastelD:   5 * time.Minute,
+	constants.DDService: 5 * time.Minute,
+}
+
+// componentReadyPorts are names of the ports, which the component listens on when it is ready
+var componentReadyPorts = map[constants.ToolType]string{
+	constants.RQService:  constants.PortNameRQService,
+	constants.DDService:  constants.PortNameDDServer,
+	constants.SuperNode:  constants.PortNameSuperNode,
+	constants.WalletNode: constants.PortNameWalletNodeAPI,
+	constants.Bridge:     constants.PortNameBridge,
+}
+
+// nodeIndependentComponents don't talk to pasteld, so they are started along with it
+var nodeIndependentComponents = []constants.ToolType{
+	constants.RQService,
+	constants.DDService,
+}
+
+// componentStep is the component and the function which starts it, readiness is awaited by the orchestrator
+type componentStep struct {
+	tool  constants.ToolType
+	start func(ctx context.Context, config *configs.Config) error
+}
+
+// startComponent returns the action starting the single component and awaiting its readiness
+func startComponent(tool constants.ToolType, start func(ctx context.Context, config *configs.Config) error) func(ctx context.Context, config *configs.Config) error {
+	return func(ctx context.Context, config *configs.Config) error {
+		return startComponents(ctx, config, []componentStep{{tool, start}})
+	}
+}
+
+// getStartDependencies returns components, which must be ready before the app is started.
+// The solution component (supernode, walletnode) waits for components listed before it in appToServiceMap,
+// components listed after it wait for the solution component, other components wait for pasteld
+func getStartDependencies(app constants.ToolType) []constants.ToolType {
+	var deps []constants.ToolType
+	for _, solution := range []constants.ToolType{constants.SuperNode, constants.WalletNode} {
+		services := appToServiceMap[solution]
+		appIdx, solutionIdx := -1, -1
+		for i, service := range services {
+			if service == app {
+				appIdx = i
+			}
+			if service == solution {
+				solutionIdx = i
+			}
+		}
+		switch {
+		case appIdx < 0:
+		case appIdx == solutionIdx:
+			deps = append(deps, services[:appIdx]...)
+		case appIdx > solutionIdx:
+			deps = append(deps, solution)
+		case app != constants.PastelD && !isNodeIndependent(app):
+			deps = append(deps, constants.PastelD)
+		}
+	}
+
+	var unique []constants.ToolType
+	seen := make(map[constants.ToolType]bool)
+	for _, dep := range deps {
+		if !seen[dep] {
+			seen[dep] = true
+			unique = append(unique, dep)
+		}
+	}
+	return unique
+}
+
+func isNodeIndependent(app constants.ToolType) bool {
+	for _, tool := range nodeIndependentComponents {
+		if tool == app {
+			return true
+		}
+	}
+	return false
+}
+
+// startComponents starts the components in parallel, each component is started as soon as
+// the components it depends on are ready, and then is awaited to become ready itself
+func startComponents(ctx context.Context, config *configs.Config, steps []componentStep) error {
+	index := make(map[constants.ToolType]int)
+	for i, step := range steps {
+		index[step.tool] = i
+	}
+	done := make([]chan struct{}, len(steps))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	results := make([]error, len(steps))
+
+	var wg sync.WaitGroup
+	for i, step := range steps {
+		wg.Add(1)
+		go func(i int, step componentStep) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, dep := range getStartDependencies(step.tool) {
+				j, ok := index[dep]
+				if !ok {
+					continue
+				}
+				<-done[j]
+				if results[j] != nil {
+					results[i] = errors.Errorf("%s was not started, %s failed to start", step.tool, dep)
+					return
+				}
+			}
+
+			// starters may update the config, so each of them gets its own copy
+			stepConfig := *config
+			log.WithContext(ctx).Infof("Starting %s", step.tool)
+			if err := step.start(ctx, &stepConfig); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("%s failed to start", step.tool)
+				results[i] = err
+				return
+			}
+			if err := waitForComponentReady(ctx, &stepConfig, step.tool); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("%s failed to start", step.tool)
+				results[i] = err
+			}
+		}(i, step)
+	}
+	wg.Wait()
+
+	for _, err := range results {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopComponents stops the components in reverse order of their start dependencies,
+// each component is stopped when all components depending on it are stopped
+func stopComponents(ctx context.Context, config *configs.Config, services []constants.ToolType) error {
+	dependents := make([][]int, len(services))
+	for i, service := range services {
+		for _, dep := range getStartDependencies(service) {
+			for j, other := range services {
+				if other == dep {
+					dependents[j] = append(dependents[j], i)
+				}
+			}
+		}
+	}
+	done := make([]chan struct{}, len(services))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	results := make([]error, len(services))
+
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func(i int, service constants.ToolType) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for _, j := range dependents[i] {
+				<-done[j]
+				if results[j] != nil {
+					results[i] = errors.Errorf("%s was not stopped, %s failed to stop", service, services[j])
+					return
+				}
+			}
+			results[i] = stopServices(ctx, []constants.ToolType{service}, config)
+		}(i, service)
+	}
+	wg.Wait()
+
+	for _, err := range results {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitForComponentReady polls readiness probe of the component until it succeeds or times out
+func waitForComponentReady(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	timeout, ok := componentReadyTimeouts[tool]
+	if !ok {
+		timeout = defaultComponentReadyTimeout
+	}
+
+	log.WithContext(ctx).Infof("Waiting for %s to be ready...", tool)
+	start := time.Now()
+	for {
+		if isComponentReady(config, tool) {
+			log.WithContext(ctx).Infof("%s is ready (%v)", tool, time.Since(start).Round(time.Second))
+			return nil
+		}
+		elapsed := time.Since(start)
+		if elapsed > componentStartGrace && !isComponentProcessRunning(config, tool) {
+			return errors.Errorf("%s is not running", tool)
+		}
+		if elapsed > timeout {
+			return errors.Errorf("%s is not ready after %v", tool, timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(componentProbeInterval):
+		}
+	}
+}
+
+// waitForComponentStopped waits until the process of the component exits
+func waitForComponentStopped(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	start := time.Now()
+	for CheckProcessRunning(config, tool) {
+		if time.Since(start) > componentStopTimeout {
+			return errors.Errorf("%s is still running after %v", tool, componentStopTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(componentProbeInterval):
+		}
+	}
+	return nil
+}
+
+// isComponentReady is readiness probe of the component: pasteld answers RPC calls,
+// services listen on their ports, the rest have running process
+func isComponentReady(config *configs.Config, tool constants.ToolType) bool {
+	if tool == constants.PastelD {
+		var info structure.RPCGetInfo
+		if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetInfoCmd, &info); err != nil {
+			return false
+		}
+		return info.Result.Version != 0
+	}
+	if name, ok := componentReadyPorts[tool]; ok {
+		return isComponentListening(config, tool, utils.GetPortListener(getComponentPort(config, name)))
+	}
+	return CheckProcessRunning(config, tool)
+}
+
+// isComponentListening checks that the port is listened by the component of the instance,
+// not by the leftover or foreign process holding the port
+func isComponentListening(config *configs.Config, tool constants.ToolType, listener *utils.PortListener) bool {
+	if listener == nil {
+		return false
+	}
+	if listener.PID == 0 {
+		// the socket of the process of another user (e.g. the system service), its owner is unknown
+		return isComponentProcessRunning(config, tool)
+	}
+	if !isPortOwner(listener, tool) {
+		return false
+	}
+	// dd-service is started with the config from dd dir, so it can't be matched to the instance
+	return tool == constants.DDService || isInstanceProcess(config, listener.PID)
+}
+
+func isComponentProcessRunning(config *configs.Config, tool constants.ToolType) bool {
+	if tool == constants.DDService {
+		// dd-service is a python script, it is checked by its port only
+		return true
+	}
+	return CheckProcessRunning(config, tool)
+}
@@ -0,0 +1,666 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type portsCommand uint8
+
+const (
+	portsShow portsCommand = iota
+	portsCheck
+	portsSet
+)
+
+var (
+	portsCmdName = map[portsCommand]string{
+		portsShow:  "show",
+		portsCheck: "check",
+		portsSet:   "set",
+	}
+	portsCmdMessage = map[portsCommand]string{
+		portsShow:  "Show ports assigned to Pastel components",
+		portsCheck: "Check that ports assigned to Pastel components are not used by other processes",
+		portsSet:   "Assign new ports to Pastel components and update all configs",
+	}
+)
+
+// componentConfigPorts maps YAML config keys of the components to the port names
+var componentConfigPorts = map[constants.ToolType]map[string]string{
+	constants.SuperNode: {
+		"node.server.port": constants.PortNameSuperNode,
+		"p2p.port":         constants.PortNameP2P,
+		"metadb.http_port": constants.PortNameMetaDB,
+		"metadb.raft_port": constants.PortNameRaft,
+		"raptorq.port":     constants.PortNameRQService,
+		"dd-server.port":   constants.PortNameDDServer,
+	},
+	constants.Hermes: {
+		"sn_port": constants.PortNameSuperNode,
+	},
+	constants.WalletNode: {
+		"node.api.port": constants.PortNameWalletNodeAPI,
+		"raptorq.port":  constants.PortNameRQService,
+		"bridge.port":   constants.PortNameBridge,
+	},
+	constants.Bridge: {
+		"server.port": constants.PortNameBridge,
+	},
+}
+
+func setupPortsSubCommand(config *configs.Config,
+	portsCmd portsCommand,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+
+	commonFlags := []*cli.Flag{
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+	}
+
+	setFlags := []*cli.Flag{
+		cli.NewFlag("ports", &config.Ports).
+			SetUsage(red("Required, new ports in the format - \"name=port,name=port\", names: " + strings.Join(constants.PortNames, ", "))).SetRequired(),
+		cli.NewFlag("user-pw", &config.UserPw).
+			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+	}
+
+	commandName := portsCmdName[portsCmd]
+	commandMessage := portsCmdMessage[portsCmd]
+
+	commandFlags := commonFlags
+	if portsCmd == portsSet {
+		commandFlags = append(commandFlags, setFlags...)
+	}
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return fmt.Errorf("failed to configure logging option - %v", err)
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sys.RegisterInterruptHandler(cancel, func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+
+			if err = ParsePastelConf(ctx, config); err != nil {
+				return err
+			}
+
+			log.WithContext(ctx).Info("Started")
+			if err = f(ctx, config); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Finished successfully!")
+			return nil
+		})
+	}
+	return subCommand
+}
+
+func setupPortsCommand(config *configs.Config) *cli.Command {
+
+	portsCommand := cli.NewCommand("ports")
+	portsCommand.SetUsage(blue("Show, check and change ports of Pastel components"))
+	portsCommand.AddSubcommands(
+		setupPortsSubCommand(config, portsShow, runPortsShow),
+		setupPortsSubCommand(config, portsCheck, runPortsCheck),
+		setupPortsSubCommand(config, portsSet, runPortsSet),
+	)
+
+	return portsCommand
+}
+
+func runPortsShow(_ context.Context, config *configs.Config) error {
+	defaults := getDefaultPorts(config.Network)
+	ports := getComponentPorts(config)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Name", "Component", "Port", "Default"})
+	for _, name := range constants.PortNames {
+		table.Append([]string{name, string(constants.PortOwners[name]), strconv.Itoa(ports[name]), strconv.Itoa(defaults[name])})
+	}
+	table.Render()
+	return nil
+}
+
+func runPortsCheck(ctx context.Context, config *configs.Config) error {
+	if _, err := loadCustomPorts(config); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to load custom ports")
+		return err
+	}
+	return checkPortsAvailable(ctx, getComponentPorts(config), true)
+}
+
+func runPortsSet(ctx context.Context, config *configs.Config) error {
+	changes, err := parsePortsOption(config.Ports)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Invalid --ports value")
+		return err
+	}
+	custom, err := loadCustomPorts(config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to load custom ports")
+		return err
+	}
+
+	oldPorts := getComponentPorts(config)
+	newPorts := make(map[string]int)
+	changed := make(map[string]int)
+	for name, port := range oldPorts {
+		newPorts[name] = port
+	}
+	for name, port := range changes {
+		if oldPorts[name] != port {
+			changed[name] = port
+		}
+		newPorts[name] = port
+		custom[name] = port
+	}
+	if len(changed) == 0 {
+		log.WithContext(ctx).Info("Ports are not changed")
+		return nil
+	}
+	if err = checkPortsCollisions(newPorts); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Invalid ports")
+		return err
+	}
+	// running components keep their current ports, so only new ports have to be free
+	if err = checkPortsAvailable(ctx, changed, false); err != nil {
+		return err
+	}
+
+	if err = saveCustomPorts(config, custom); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to save custom ports")
+		return err
+	}
+	if err = updatePastelConfPorts(ctx, config, newPorts); err != nil {
+		return err
+	}
+	if err = updateComponentConfigsPorts(ctx, config, changed); err != nil {
+		return err
+	}
+	if err = updateMasternodeConfPorts(ctx, config, oldPorts, newPorts); err != nil {
+		return err
+	}
+	if err = updateFirewallPorts(ctx, config, oldPorts, changed); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Failed to update firewall rules, please update them manually")
+	}
+
+	log.WithContext(ctx).Warn("Restart Pastel components to apply new ports")
+	return nil
+}
+
+///// Port helpers
+
+// getDefaultPorts returns default ports of the components for the network
+func getDefaultPorts(network string) map[string]int {
+	portList := getNetworkPortList(network)
+	return map[string]int{
+		constants.PortNameNode:          portList[constants.NodePort],
+		constants.PortNameNodeRPC:       portList[constants.NodeRPCPort],
+		constants.PortNameSuperNode:     portList[constants.SNPort],
+		constants.PortNameP2P:           portList[constants.P2PPort],
+		constants.PortNameMetaDB:        portList[constants.MDLPort],
+		constants.PortNameRaft:          portList[constants.RAFTPort],
+		constants.PortNameRQService:     constants.RQServiceDefaultPort,
+		constants.PortNameDDServer:      constants.DDServerDefaultPort,
+		constants.PortNameBridge:        constants.BridgeServiceDefaultPort,
+		constants.PortNameWalletNodeAPI: constants.WalletNodeAPIDefaultPort,
+	}
+}
+
+func getCustomPortsPath(config *configs.Config) string {
+	return filepath.Join(config.WorkingDir, constants.PortsFileName)
+}
+
+// loadCustomPorts returns ports assigned by user at install or by "ports set" command
+func loadCustomPorts(config *configs.Config) (map[string]int, error) {
+	ports := make(map[string]int)
+	if len(config.WorkingDir) == 0 {
+		return ports, nil
+	}
+
+	data, err := ioutil.ReadFile(getCustomPortsPath(config))
+	if os.IsNotExist(err) {
+		return ports, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, &ports); err != nil {
+		return nil, errors.Errorf("invalid %s: %v", getCustomPortsPath(config), err)
+	}
+	return ports, nil
+}
+
+// customPortsCache keeps custom ports of the working directory, so ports.json is read once per command
+var customPortsCache = struct {
+	sync.Mutex
+	ports map[string]map[string]int
+}{ports: make(map[string]map[string]int)}
+
+// getCustomPorts returns custom ports like loadCustomPorts, the file is read only the first time,
+// the returned map must not be changed
+func getCustomPorts(config *configs.Config) (map[string]int, error) {
+	customPortsCache.Lock()
+	defer customPortsCache.Unlock()
+
+	path := getCustomPortsPath(config)
+	if ports, ok := customPortsCache.ports[path]; ok {
+		return ports, nil
+	}
+	ports, err := loadCustomPorts(config)
+	if err != nil {
+		return nil, err
+	}
+	customPortsCache.ports[path] = ports
+	return ports, nil
+}
+
+func saveCustomPorts(config *configs.Config, ports map[string]int) error {
+	customPortsCache.Lock()
+	delete(customPortsCache.ports, getCustomPortsPath(config))
+	customPortsCache.Unlock()
+
+	defaults := getDefaultPorts(config.Network)
+	for name, port := range ports {
+		if defaults[name] == port {
+			delete(ports, name)
+		}
+	}
+	data, err := json.MarshalIndent(ports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(getCustomPortsPath(config), data, 0644)
+}
+
+// getComponentPorts returns default ports of the network overridden by the custom ones
+func getComponentPorts(config *configs.Config) map[string]int {
+	var custom map[string]int
+	if !config.DefaultConfigs {
+		var err error
+		if custom, err = getCustomPorts(config); err != nil {
+			// invalid file is reported by "ports check"
+			custom = nil
+		}
+	}
+	ports := getComponentPortsWith(config, custom)
+	// rpc port may be generated or changed in pastel.conf
+	if _, ok := custom[constants.PortNameNodeRPC]; !ok && config.RPCPort != 0 {
+		ports[constants.PortNameNodeRPC] = config.RPCPort
+	}
+	return ports
+}
+
+// getComponentPort returns port of the component by the port name
+func getComponentPort(config *configs.Config, name string) int {
+	return getComponentPorts(config)[name]
+}
+
+// parsePortsOption parses ports in the format - "name=port,name=port"
+func parsePortsOption(val string) (map[string]int, error) {
+	ports := make(map[string]int)
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || !utils.Contains(constants.PortNames, strings.TrimSpace(kv[0])) {
+			return nil, errors.Errorf("invalid port %q, must be name=port, names: %s", item, strings.Join(constants.PortNames, ", "))
+		}
+		port, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || port < 1 || port > 65535 {
+			return nil, errors.Errorf("invalid port %q, must be in range 1-65535", item)
+		}
+		ports[strings.TrimSpace(kv[0])] = port
+	}
+	if len(ports) == 0 {
+		return nil, errors.New("no ports provided")
+	}
+	return ports, nil
+}
+
+// checkPortsCollisions checks that different components don't use the same port
+func checkPortsCollisions(ports map[string]int) error {
+	used := make(map[int]string)
+	for _, name := range constants.PortNames {
+		port, ok := ports[name]
+		if !ok {
+			continue
+		}
+		if other, ok := used[port]; ok {
+			return errors.Errorf("port %d is assigned to both %s and %s", port, other, name)
+		}
+		used[port] = name
+	}
+	return nil
+}
+
+// checkPortsAvailable checks that ports are not bound by other processes,
+// if allowOwner is set, port bound by the component which it is assigned to is not a conflict
+func checkPortsAvailable(ctx context.Context, ports map[string]int, allowOwner bool) error {
+	var names []string
+	for name := range ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conflicts []string
+	for _, name := range names {
+		listener := utils.GetPortListener(ports[name])
+		if listener == nil {
+			continue
+		}
+		if allowOwner && isPortOwner(listener, constants.PortOwners[name]) {
+			continue
+		}
+		msg := fmt.Sprintf("port %d (%s) is already in use by %s", ports[name], name, listener)
+		log.WithContext(ctx).Error(msg)
+		conflicts = append(conflicts, msg)
+	}
+	if len(conflicts) > 0 {
+		return errors.Errorf("%d port(s) are in use, stop the processes or assign other ports with --ports", len(conflicts))
+	}
+	log.WithContext(ctx).Info("All ports are available")
+	return nil
+}
+
+// isPortOwner checks if the listening process is the component itself
+func isPortOwner(listener *utils.PortListener, tool constants.ToolType) bool {
+	if listener.PID == 0 {
+		return false
+	}
+	if tool == constants.DDService {
+		return strings.HasPrefix(listener.Name, "python")
+	}
+	// process name in procfs is truncated to 15 characters
+	execName := constants.ServiceName[tool][utils.GetOS()]
+	return len(execName) > 0 && strings.HasPrefix(execName, listener.Name)
+}
+
+// getInstallPortNames returns ports used by the components installed by the install command
+func getInstallPortNames(tool constants.ToolType, withDependencies bool) []string {
+	var names []string
+	if tool == constants.PastelD || withDependencies {
+		names = append(names, constants.PortNameNode, constants.PortNameNodeRPC)
+	}
+	switch {
+	case tool == constants.SuperNode:
+		names = append(names, constants.PortNameSuperNode, constants.PortNameP2P, constants.PortNameMetaDB, constants.PortNameRaft)
+		if withDependencies {
+			names = append(names, constants.PortNameRQService, constants.PortNameDDServer)
+		}
+	case tool == constants.WalletNode:
+		names = append(names, constants.PortNameWalletNodeAPI, constants.PortNameBridge)
+		if withDependencies {
+			names = append(names, constants.PortNameRQService)
+		}
+	case tool == constants.RQService:
+		names = append(names, constants.PortNameRQService)
+	case tool == constants.DDService:
+		names = append(names, constants.PortNameDDServer)
+	}
+	return names
+}
+
+// setupInstallPorts saves custom ports from --ports option and checks that ports of the installed components are free
+func setupInstallPorts(ctx context.Context, config *configs.Config, tool constants.ToolType, withDependencies bool) error {
+	if len(config.Ports) != 0 || len(config.Instance) != 0 {
+		changes := make(map[string]int)
+		if len(config.Ports) != 0 {
+			var err error
+			if changes, err = parsePortsOption(config.Ports); err != nil {
+				log.WithContext(ctx).WithError(err).Error("Invalid --ports value")
+				return err
+			}
+		}
+		custom, err := loadCustomPorts(config)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to load custom ports")
+			return err
+		}
+		for name, port := range changes {
+			custom[name] = port
+		}
+		if len(config.Instance) != 0 {
+			allocateInstancePorts(ctx, config, custom)
+		}
+		if err = checkPortsCollisions(getComponentPortsWith(config, custom)); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Invalid --ports value")
+			return err
+		}
+		if err = utils.CreateFolder(ctx, config.WorkingDir, false); err != nil && !os.IsExist(err) {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to create folder %s", config.WorkingDir)
+			return err
+		}
+		if err = saveCustomPorts(config, custom); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to save custom ports")
+			return err
+		}
+		if port, ok := custom[constants.PortNameNodeRPC]; ok {
+			config.RPCPort = port
+		}
+	}
+
+	ports := getComponentPorts(config)
+	installPorts := make(map[string]int)
+	for _, name := range getInstallPortNames(tool, withDependencies) {
+		installPorts[name] = ports[name]
+	}
+	return checkPortsAvailable(ctx, installPorts, true)
+}
+
+// getComponentPortsWith returns default ports of the network overridden by the provided custom ones
+func getComponentPortsWith(config *configs.Config, custom map[string]int) map[string]int {
+	ports := getDefaultPorts(config.Network)
+	for name, port := range custom {
+		if _, ok := ports[name]; ok {
+			ports[name] = port
+		}
+	}
+	return ports
+}
+
+///// Ports reconfiguration helpers
+
+// updatePastelConfPorts sets rpcport and port options in pastel.conf
+func updatePastelConfPorts(ctx context.Context, config *configs.Config, ports map[string]int) error {
+	pastelConfPath := filepath.Join(config.WorkingDir, constants.PastelConfName)
+	data, err := ioutil.ReadFile(pastelConfPath)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to read %s", pastelConfPath)
+		return err
+	}
+
+	values := map[string]string{"rpcport": strconv.Itoa(ports[constants.PortNameNodeRPC])}
+	if ports[constants.PortNameNode] != getDefaultPorts(config.Network)[constants.PortNameNode] {
+		values["port"] = strconv.Itoa(ports[constants.PortNameNode])
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		if key == "port" {
+			// default port is set explicitly to override previous custom one
+			values[key] = strconv.Itoa(ports[constants.PortNameNode])
+		}
+		if value, ok := values[key]; ok {
+			lines[i] = key + "=" + value
+			delete(values, key)
+		}
+	}
+	for _, key := range []string{"rpcport", "port"} {
+		if value, ok := values[key]; ok {
+			lines = append(lines, key+"="+value)
+		}
+	}
+
+	if err = ioutil.WriteFile(pastelConfPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to update %s", pastelConfPath)
+		return err
+	}
+	config.RPCPort = ports[constants.PortNameNodeRPC]
+	log.WithContext(ctx).Infof("%s updated", pastelConfPath)
+	return nil
+}
+
+// updateComponentConfigsPorts sets changed ports in the existing configs of the components
+func updateComponentConfigsPorts(ctx context.Context, config *configs.Config, changed map[string]int) error {
+	configPaths := map[constants.ToolType]string{
+		constants.SuperNode:  config.Configurer.GetSuperNodeConfFile(config.WorkingDir),
+		constants.Hermes:     config.Configurer.GetHermesConfFile(config.WorkingDir),
+		constants.WalletNode: config.Configurer.GetWalletNodeConfFile(config.WorkingDir),
+		constants.Bridge:     config.Configurer.GetBridgeConfFile(config.WorkingDir),
+	}
+	for _, tool := range []constants.ToolType{constants.SuperNode, constants.Hermes, constants.WalletNode, constants.Bridge} {
+		path := configPaths[tool]
+		values := make(map[string]interface{})
+		for key, name := range componentConfigPorts[tool] {
+			if port, ok := changed[name]; ok {
+				values[key] = port
+			}
+		}
+		if len(values) == 0 || !utils.CheckFileExist(path) {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to read %s", path)
+			return err
+		}
+		updated, err := utils.SetYAMLValues(data, values)
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to update ports in %s", path)
+			return err
+		}
+		toolConfig := string(updated)
+		if version, ok := getConfigVersion(string(data)); ok {
+			toolConfig = setConfigVersion(toolConfig, version)
+		}
+		if err = utils.WriteFile(path, toolConfig); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to write %s", path)
+			return err
+		}
+		log.WithContext(ctx).Infof("%s updated", path)
+	}
+
+	// rq-service config is not YAML
+	if port, ok := changed[constants.PortNameRQService]; ok {
+		path := config.Configurer.GetRQServiceConfFile(config.WorkingDir)
+		if utils.CheckFileExist(path) {
+			toolConfig, err := GetRQServiceConfigs(config)
+			if err != nil {
+				return err
+			}
+			if err = utils.WriteFile(path, toolConfig); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("Failed to write %s", path)
+				return err
+			}
+			log.WithContext(ctx).Infof("%s updated with port %d", path, port)
+		}
+	}
+	return nil
+}
+
+// updateMasternodeConfPorts changes ports of the masternode addresses which use the old ports
+func updateMasternodeConfPorts(ctx context.Context, config *configs.Config, oldPorts map[string]int, newPorts map[string]int) error {
+	if !utils.CheckFileExist(getMasternodeConfPath(config, config.WorkingDir, "masternode.conf")) {
+		return nil
+	}
+	conf, err := loadMasternodeConfFile(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	replacePort := func(address string, name string) string {
+		idx := strings.LastIndex(address, ":")
+		if idx < 0 || address[idx+1:] != strconv.Itoa(oldPorts[name]) {
+			return address
+		}
+		return address[:idx+1] + strconv.Itoa(newPorts[name])
+	}
+
+	updated := false
+	for alias, mn := range conf {
+		newMn := mn
+		newMn.MnAddress = replacePort(mn.MnAddress, constants.PortNameNode)
+		newMn.ExtAddress = replacePort(mn.ExtAddress, constants.PortNameSuperNode)
+		newMn.ExtP2P = replacePort(mn.ExtP2P, constants.PortNameP2P)
+		if newMn != mn {
+			conf[alias] = newMn
+			updated = true
+			log.WithContext(ctx).Warnf("Masternode %s addresses changed, it has to be started again with start-alias", alias)
+		}
+	}
+	if !updated {
+		return nil
+	}
+	return writeMasterNodeConfFile(ctx, config, conf)
+}
+
+// updateFirewallPorts opens new and closes old ports of the supernode in the firewall
+func updateFirewallPorts(ctx context.Context, config *configs.Config, oldPorts map[string]int, changed map[string]int) error {
+	if utils.GetOS() != constants.Linux || !utils.CheckFileExist(config.Configurer.GetSuperNodeConfFile(config.WorkingDir)) {
+		return nil
+	}
+
+	fm, err := NewFirewallManager(config)
+	if err != nil {
+		return err
+	}
+	for _, name := range firewallComponentPorts[constants.SuperNode] {
+		port, ok := changed[name]
+		if !ok {
+			continue
+		}
+		tag := getFirewallTag(config, name)
+		log.WithContext(ctx).Infof("Opening port %d (%s) with %s", port, name, fm.Name())
+		if err = fm.Open(ctx, config, port, tag); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("Closing port %d (%s) with %s", oldPorts[name], name, fm.Name())
+		if err = fm.Close(ctx, config, oldPorts[name], tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const releaseIndexTimeout = 30 * time.Second
+
+// listingLinkRegexp matches links in the html listing of the download folder
+var listingLinkRegexp = regexp.MustCompile(`href="([^"?#]+)"`)
+
+// getReleaseIndexURL returns url of the listing of the published releases
+func getReleaseIndexURL() string {
+	return fmt.Sprintf("%s/%s/", constants.DownloadBaseURL, constants.ReleaseHistoryDir)
+}
+
+// getReleaseVersions returns published release versions sorted from the oldest to the newest
+func getReleaseVersions(ctx context.Context, timeout time.Duration) ([]string, error) {
+	url := getReleaseIndexURL()
+	log.WithContext(ctx).Debugf("Fetching release index from %s", url)
+
+	entries, err := getListingEntries(url, timeout)
+	if err != nil {
+		return nil, errors.Errorf("failed to fetch release index %s: %v", url, err)
+	}
+
+	var versions []string
+	for _, v := range entries {
+		// skip anything that is not a version, like "../"
+		if _, err := utils.CompareVersions(v, v); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		cmp, _ := utils.CompareVersions(versions[i], versions[j])
+		return cmp < 0
+	})
+	return versions, nil
+}
+
+// getListingEntries returns unique names of files and folders in the html listing of the download folder
+func getListingEntries(url string, timeout time.Duration) ([]string, error) {
+	body, err := utils.GetURL(url, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return parseListing(string(body)), nil
+}
+
+func parseListing(listing string) []string {
+	seen := make(map[string]bool)
+	var entries []string
+	for _, match := range listingLinkRegexp.FindAllStringSubmatch(listing, -1) {
+		name := path.Base(strings.TrimSuffix(match[1], "/"))
+		if name == "." || name == ".." || name == "/" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, name)
+	}
+	return entries
+}
+
+// resolveReleaseChannel returns the release version the channel points to:
+// "latest" - the newest release, "beta" - the newest release including pre-releases,
+// anything else is the exact version which must be published
+func resolveReleaseChannel(ctx context.Context, channel string, timeout time.Duration) (string, error) {
+	versions, err := getReleaseVersions(ctx, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	switch channel {
+	case "latest", "beta":
+		for i := len(versions) - 1; i >= 0; i-- {
+			if channel == "beta" || !utils.IsPreRelease(versions[i]) {
+				return versions[i], nil
+			}
+		}
+		return "", errors.Errorf("no releases found for channel %s in %s", channel, getReleaseIndexURL())
+	default:
+		if _, err := utils.CompareVersions(channel, channel); err != nil {
+			return "", errors.Errorf("invalid channel %q, must be \"latest\", \"beta\" or release version", channel)
+		}
+		// "1.2.3" and "v1.2.3" are the same release
+		for _, v := range versions {
+			if cmp, _ := utils.CompareVersions(v, channel); cmp == 0 {
+				return v, nil
+			}
+		}
+		return "", errors.Errorf("release %s is not found in %s", channel, getReleaseIndexURL())
+	}
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+// remoteResult is the result of the command executed on the remote host
+type remoteResult struct {
+	Host     string `json:"host"`
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	// Output is the captured stdout, unless it is parsed to Result
+	Output string          `json:"output,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+func (r remoteResult) failed() bool {
+	return r.ExitCode != 0 || len(r.Error) != 0
+}
+
+// getRemoteResultsError returns error describing the failed commands, nil if all succeeded
+func getRemoteResultsError(results []remoteResult) error {
+	var failures []string
+	for _, r := range results {
+		if r.failed() {
+			failures = append(failures, fmt.Sprintf("%s: %q: %s", r.Host, r.Command, r.Error))
+		}
+	}
+	if len(failures) != 0 {
+		return errors.Errorf("remote command failed - %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// runRemoteCommands connects to the remote host of config and executes commands one by one until one of them fails.
+// host is the name of the host in the output and results.
+// If jsonOutput is set, stdout of the commands isn't streamed, but parsed as json result of the remote pastelup
+func runRemoteCommands(ctx context.Context, config *configs.Config, host string, commands []string, tryStop bool, jsonOutput bool) ([]remoteResult, error) {
+	client, err := prepareRemoteSession(ctx, config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to prepare remote session")
+		return nil, fmt.Errorf("failed to prepare remote session: %v", err)
+	}
+	defer client.Close()
+
+	if tryStop {
+		if err = checkAndStopRemoteServices(ctx, config, client); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []remoteResult
+	for _, command := range commands {
+		result := runRemoteCommand(ctx, client, host, command, jsonOutput)
+		results = append(results, result)
+		if result.failed() {
+			log.WithContext(ctx).Errorf("Failed while executing remote command on %s: %s", host, result.Error)
+			break
+		}
+	}
+	return results, nil
+}
+
+// runRemoteCommand executes the command, its output is streamed with the host prefix and captured
+func runRemoteCommand(ctx context.Context, client *utils.Client, host string, command string, jsonOutput bool) remoteResult {
+	result := remoteResult{Host: host, Command: command}
+	prefix := fmt.Sprintf("[%s] ", host)
+
+	var stdout, stderr bytes.Buffer
+	stderrStream := utils.NewPrefixWriter(os.Stderr, prefix)
+	stdoutWriter := io.Writer(&stdout)
+	stdoutStream := utils.NewPrefixWriter(os.Stdout, prefix)
+	if !jsonOutput {
+		stdoutWriter = io.MultiWriter(&stdout, stdoutStream)
+	}
+
+	log.WithContext(ctx).Infof("Remote Command: %s started on %s", command, host)
+	exitCode, err := client.ShellCmdOutput(ctx, command, stdoutWriter, io.MultiWriter(&stderr, stderrStream))
+	stdoutStream.Flush()
+	stderrStream.Flush()
+	log.WithContext(ctx).Infof("Remote Command: %s finished on %s with exit status %d", command, host, exitCode)
+
+	result.ExitCode = exitCode
+	result.Output = stdout.String()
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+	case exitCode != 0:
+		result.Error = fmt.Sprintf("exited with status %d", exitCode)
+		if msg := lastLine(stderr.String()); len(msg) != 0 {
+			result.Error = fmt.Sprintf("%s: %s", result.Error, msg)
+		}
+	}
+
+	if jsonOutput && err == nil {
+		if data, err := parseJSONResult(stdout.Bytes()); err != nil {
+			if len(result.Error) == 0 {
+				result.Error = err.Error()
+			}
+		} else {
+			result.Result = data
+			result.Output = ""
+		}
+	}
+	return result
+}
+
+// parseJSONResult finds json document in the output of remote pastelup, it is either the whole output
+// or its last line if the output has log lines before it
+func parseJSONResult(output []byte) (json.RawMessage, error) {
+	output = bytes.TrimSpace(output)
+	if json.Valid(output) && len(output) != 0 {
+		return json.RawMessage(output), nil
+	}
+	lines := bytes.Split(output, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := bytes.TrimSpace(lines[i])
+		if (bytes.HasPrefix(line, []byte("{")) || bytes.HasPrefix(line, []byte("["))) && json.Valid(line) {
+			return json.RawMessage(line), nil
+		}
+	}
+	return nil, errors.Errorf("remote output is not json: %s", lastLine(string(output)))
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// collectRemoteResults executes the command of remote pastelup with json output on the remote host or all hosts
+// from inventory and returns results of all of them, hosts that failed are in the results with the error,
+// getCommand is called for every host after its inventory variables are applied to config
+func collectRemoteResults(ctx context.Context, config *configs.Config, getCommand func() (string, error)) ([]remoteResult, error) {
+	var results []remoteResult
+	run := func(name string) error {
+		command, err := getCommand()
+		var hostResults []remoteResult
+		if err == nil {
+			hostResults, err = runRemoteCommands(ctx, config, name, []string{command}, false, true)
+		}
+		if err != nil {
+			hostResults = []remoteResult{{Host: name, Command: command, ExitCode: -1, Error: err.Error()}}
+		}
+		results = append(results, hostResults...)
+		return getRemoteResultsError(hostResults)
+	}
+
+	if len(config.InventoryFile) == 0 {
+		run(config.RemoteIP)
+		return results, nil
+	}
+
+	var inv Inventory
+	if err := inv.Read(config.InventoryFile); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to load inventory file")
+		return nil, err
+	}
+	// failed hosts are reported in the results, error without results is the invalid selection of hosts
+	if err := inv.ForEachHost(ctx, config, run); err != nil && len(results) == 0 {
+		return nil, err
+	}
+	return results, nil
+}
+
+// printRemoteResultsJSON prints results of the command from all hosts as one json document,
+// fails if the command failed on any host
+func printRemoteResultsJSON(ctx context.Context, config *configs.Config, getCommand func() (string, error)) error {
+	results, err := collectRemoteResults(ctx, config, getCommand)
+	if err != nil {
+		return err
+	}
+	if err = writeJSON(os.Stdout, results); err != nil {
+		return err
+	}
+	return getRemoteResultsError(results)
+}
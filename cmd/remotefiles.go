@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type remoteCommand uint8
+
+const (
+	remoteFetchLogs remoteCommand = iota
+	remoteGet
+	remotePut
+)
+
+var (
+	remoteCmdName = map[remoteCommand]string{
+		remoteFetchLogs: "fetch-logs",
+		remoteGet:       "get",
+		remotePut:       "put",
+	}
+	remoteCmdMessage = map[remoteCommand]string{
+		remoteFetchLogs: "Download logs of pastel components from the remote hosts",
+		remoteGet:       "Download file or directory from the remote hosts",
+		remotePut:       "Upload file to the remote hosts",
+	}
+	remoteCmdArgsUsage = map[remoteCommand]string{
+		remoteGet: "<remote path>",
+		remotePut: "<local file> <remote path>",
+	}
+)
+
+var (
+	flagRemoteComponent string
+	flagRemoteSince     time.Duration
+	flagRemoteDest      string
+)
+
+func setupRemoteSubCommand(config *configs.Config,
+	command remoteCommand,
+	f func(context.Context, *configs.Config, []string) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("ssh-ip", &config.RemoteIP).
+			SetUsage(red("Required (if `inventory` is not used), SSH address of the remote host")),
+		cli.NewFlag("ssh-port", &config.RemotePort).
+			SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
+		cli.NewFlag("ssh-user", &config.RemoteUser).
+			SetUsage(yellow("Optional, Username of user at remote host")),
+		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
+			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
+	}
+	commandFlags = append(commandFlags, getInventoryFlags(config)...)
+
+	switch command {
+	case remoteFetchLogs:
+		commandFlags = append(commandFlags,
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of working directory on the remote computer (default: $HOME/.pastel)")),
+			cli.NewFlag("component", &flagRemoteComponent).SetAliases("c").
+				SetUsage(green("Optional, component which logs to download - \"all\", pasteld, supernode, hermes, bridge or walletnode")).SetValue("all"),
+			cli.NewFlag("since", &flagRemoteSince).
+				SetUsage(green("Optional, download only log files modified within this period, e.g. 2h (default: all log files)")),
+		)
+	}
+	if command != remotePut {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dest", &flagRemoteDest).SetAliases("o").
+				SetUsage(green("Optional, local directory where files of every host are stored in the sub directory named after the host")).SetValue("."),
+		)
+	}
+
+	commandName := remoteCmdName[command]
+	commandMessage := remoteCmdMessage[command]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addLogFlags(subCommand, config)
+
+	setActionFuncWithArgs(subCommand, remoteCmdArgsUsage[command], func(ctx context.Context, args []string) error {
+		ctx, err := configureLogging(ctx, commandMessage, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		log.WithContext(ctx).Info("Started")
+		if err = f(ctx, config, args); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return subCommand
+}
+
+func setupRemoteCommand(config *configs.Config) *cli.Command {
+	remoteCommand := cli.NewCommand("remote")
+	remoteCommand.SetUsage(blue("Transfers files and logs from and to the remote hosts"))
+	remoteCommand.AddSubcommands(
+		setupRemoteSubCommand(config, remoteFetchLogs, runRemoteFetchLogs),
+		setupRemoteSubCommand(config, remoteGet, runRemoteGet),
+		setupRemoteSubCommand(config, remotePut, runRemotePut),
+	)
+	return remoteCommand
+}
+
+// forEachRemoteHost connects to the remote host or every host from inventory with dial and calls f with the name of the host,
+// failure of the host doesn't stop the others, returns error if any host failed
+func forEachRemoteHost(ctx context.Context, config *configs.Config,
+	dial func(context.Context, *configs.Config) (*utils.Client, error),
+	f func(host string, client *utils.Client) error,
+) error {
+	run := func(host string) error {
+		client, err := dial(ctx, config)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %v", host, err)
+		}
+		defer client.Close()
+		return f(host, client)
+	}
+
+	if len(config.InventoryFile) == 0 {
+		return run(config.RemoteIP)
+	}
+
+	var inv Inventory
+	if err := inv.Read(config.InventoryFile); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to load inventory file")
+		return err
+	}
+	return inv.ForEachHost(ctx, config, run)
+}
+
+// getHostFileName returns host name that is safe to use as a local file name
+func getHostFileName(host string) string {
+	return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(host)
+}
+
+// getHostDir returns local directory of the host, files are stored separately for every host
+func getHostDir(host string) (string, error) {
+	dir := filepath.Join(flagRemoteDest, getHostFileName(host))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Errorf("failed to create %s: %v", dir, err)
+	}
+	return dir, nil
+}
+
+func isRemoteDir(client *utils.Client, remotePath string) bool {
+	_, err := client.Cmd(fmt.Sprintf(`test -d "%s"`, remotePath)).Output()
+	return err == nil
+}
+
+// fetchRemoteFiles downloads files (or directories) relative to remoteDir to localDir keeping their paths,
+// files are packed to the archive on the remote host, so they are transferred at once and compressed
+func fetchRemoteFiles(ctx context.Context, client *utils.Client, remoteDir string, names []string, localDir string) error {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf(`"%s"`, name)
+	}
+	// GNU tar exits with 1 if the file changed while it was read, that's expected for the logs
+	cmd := fmt.Sprintf(`f=$(mktemp) && { tar czf "$f" -C "%s" %s; [ $? -le 1 ]; } && echo "$f"`, remoteDir, strings.Join(quoted, " "))
+	out, err := client.Cmd(cmd).SmartOutput()
+	if err != nil {
+		return errors.Errorf("failed to pack files at remote: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	remoteArchive := strings.TrimSpace(string(out))
+	defer client.Cmd(fmt.Sprintf(`rm -f "%s"`, remoteArchive)).Run()
+
+	localArchive := filepath.Join(localDir, ".remote-files.tar.gz")
+	defer os.Remove(localArchive)
+	if err := client.ScpFrom(remoteArchive, localArchive); err != nil {
+		return err
+	}
+	log.WithContext(ctx).Debugf("Extracting %s to %s", remoteArchive, localDir)
+	return utils.ExtractTarGz(localArchive, localDir)
+}
+
+func runRemoteFetchLogs(ctx context.Context, config *configs.Config, _ []string) error {
+	components, err := getLogComponents(flagRemoteComponent, logComponents)
+	if err != nil {
+		return err
+	}
+
+	var conditions []string
+	for _, tool := range components {
+		for _, pattern := range getLogPatterns(config, tool) {
+			conditions = append(conditions, fmt.Sprintf(`-path "./%s"`, pattern))
+		}
+	}
+
+	return forEachRemoteHost(ctx, config, dialRemoteHost, func(host string, client *utils.Client) error {
+		// work dir may be set by the inventory variables of the host
+		workDir := config.WorkingDir
+		if len(workDir) == 0 {
+			workDir = "$HOME/.pastel"
+		}
+		findCmd := fmt.Sprintf(`cd "%s" && find . -maxdepth 2 -type f \( %s \)`, workDir, strings.Join(conditions, " -o "))
+		if flagRemoteSince > 0 {
+			findCmd = fmt.Sprintf("%s -mmin -%d", findCmd, int(math.Ceil(flagRemoteSince.Minutes())))
+		}
+
+		out, err := client.Cmd(findCmd).SmartOutput()
+		if err != nil {
+			return errors.Errorf("failed to find logs in %s: %v: %s", workDir, err, strings.TrimSpace(string(out)))
+		}
+		var files []string
+		for _, file := range strings.Split(string(out), "\n") {
+			if file = strings.TrimSpace(file); len(file) != 0 {
+				files = append(files, strings.TrimPrefix(file, "./"))
+			}
+		}
+		if len(files) == 0 {
+			log.WithContext(ctx).Warnf("No %s logs found on %s in %s", flagRemoteComponent, host, workDir)
+			return nil
+		}
+
+		hostDir, err := getHostDir(host)
+		if err != nil {
+			return err
+		}
+		if err = fetchRemoteFiles(ctx, client, workDir, files, hostDir); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Infof("Downloaded %d log files from %s to %s", len(files), host, hostDir)
+		return nil
+	})
+}
+
+func runRemoteGet(ctx context.Context, config *configs.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.Errorf("expected one argument - %s", remoteCmdArgsUsage[remoteGet])
+	}
+	remotePath := strings.TrimSuffix(args[0], "/")
+
+	return forEachRemoteHost(ctx, config, dialRemoteHost, func(host string, client *utils.Client) error {
+		hostDir, err := getHostDir(host)
+		if err != nil {
+			return err
+		}
+		if isRemoteDir(client, remotePath) {
+			err = fetchRemoteFiles(ctx, client, path.Dir(remotePath), []string{path.Base(remotePath)}, hostDir)
+		} else {
+			err = client.ScpFrom(remotePath, filepath.Join(hostDir, path.Base(remotePath)))
+		}
+		if err != nil {
+			return errors.Errorf("failed to download %s from %s: %v", remotePath, host, err)
+		}
+		log.WithContext(ctx).Infof("Downloaded %s from %s to %s", remotePath, host, filepath.Join(hostDir, path.Base(remotePath)))
+		return nil
+	})
+}
+
+func runRemotePut(ctx context.Context, config *configs.Config, args []string) error {
+	if len(args) != 2 {
+		return errors.Errorf("expected two arguments - %s", remoteCmdArgsUsage[remotePut])
+	}
+	localPath, remotePath := args[0], args[1]
+
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return errors.Errorf("failed to read %s: %v", localPath, err)
+	}
+	if info.IsDir() {
+		return errors.Errorf("%s is a directory, only files can be uploaded", localPath)
+	}
+	perm := fmt.Sprintf("%04o", info.Mode().Perm())
+
+	return forEachRemoteHost(ctx, config, dialRemoteHost, func(host string, client *utils.Client) error {
+		dst := remotePath
+		// like cp, file is uploaded into the existing directory
+		if strings.HasSuffix(dst, "/") || isRemoteDir(client, dst) {
+			dst = path.Join(dst, filepath.Base(localPath))
+		}
+		if _, err := client.Cmd(fmt.Sprintf(`mkdir -p "%s"`, path.Dir(dst))).Output(); err != nil {
+			return errors.Errorf("failed to create %s on %s: %v", path.Dir(dst), host, err)
+		}
+		if err := client.Scp(localPath, dst, perm); err != nil {
+			return errors.Errorf("failed to upload %s to %s: %v", localPath, host, err)
+		}
+		log.WithContext(ctx).Infof("Uploaded %s to %s:%s", localPath, host, dst)
+		return nil
+	})
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+// remotePlatform is OS and CPU architecture of the remote host in GOOS/GOARCH terms
+type remotePlatform struct {
+	osType constants.OSType
+	goos   string
+	goarch string
+}
+
+func (p remotePlatform) String() string {
+	return p.goos + "/" + p.goarch
+}
+
+// getRemotePlatform detects OS and CPU architecture of the remote host
+func getRemotePlatform(client *utils.Client) (remotePlatform, error) {
+	var platform remotePlatform
+
+	out, err := client.Cmd("uname -sm").Output()
+	if err != nil {
+		return platform, errors.Errorf("failed to detect OS of remote host: %v", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return platform, errors.Errorf("unexpected output of 'uname -sm' on remote host: %q", string(out))
+	}
+
+	switch fields[0] {
+	case "Linux":
+		platform.osType, platform.goos = constants.Linux, "linux"
+	case "Darwin":
+		platform.osType, platform.goos = constants.Mac, "darwin"
+	default:
+		return platform, errors.Errorf("unsupported OS of remote host: %s", fields[0])
+	}
+
+	switch fields[1] {
+	case "x86_64", "amd64":
+		platform.goarch = "amd64"
+	case "aarch64", "arm64":
+		platform.goarch = "arm64"
+	default:
+		platform.goarch = fields[1]
+	}
+	return platform, nil
+}
+
+// getRemoteChecksum returns sha256 of the remote file, fails if the file doesn't exist
+func getRemoteChecksum(client *utils.Client, remotePath string) (string, error) {
+	out, err := client.Cmd(fmt.Sprintf(`sha256sum "%[1]s" 2>/dev/null || shasum -a 256 "%[1]s"`, remotePath)).Output()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", errors.Errorf("empty checksum of %s", remotePath)
+	}
+	return fields[0], nil
+}
+
+// getPublishedChecksum returns checksum from .sha256 file published next to the download url, empty if it isn't published
+func getPublishedChecksum(ctx context.Context, downloadURL string) (string, error) {
+	body, err := utils.GetURL(downloadURL+".sha256", checksumTimeout)
+	if err == utils.ErrNotFound {
+		log.WithContext(ctx).Warnf("Checksum is not published for %s", downloadURL)
+		return "", nil
+	} else if err != nil {
+		return "", errors.Errorf("failed to download checksum of %s: %v", downloadURL, err)
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", errors.Errorf("checksum of %s is empty", downloadURL)
+	}
+	return fields[0], nil
+}
+
+// copyPastelUpToRemote installs pastelup to remotePastelUp on the remote host. If the remote host has the same OS and
+// architecture, the running pastelup is uploaded, otherwise the remote host downloads the published build of the release.
+// The upload is skipped if the remote pastelup is already the same, the new one is verified before it replaces the old one.
+func copyPastelUpToRemote(ctx context.Context, client *utils.Client, version string, remotePastelUp string) error {
+	platform, err := getRemotePlatform(client)
+	if err != nil {
+		return err
+	}
+
+	var localPastelupPath, downloadURL, checksum string
+	if platform.goos == runtime.GOOS && platform.goarch == runtime.GOARCH {
+		ex, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to get path of executable file %s", err)
+		}
+		if localPastelupPath, err = filepath.EvalSymlinks(ex); err != nil {
+			return fmt.Errorf("failed to resolve path of executable file %s", err)
+		}
+		if checksum, err = utils.GetChecksum(ctx, localPastelupPath); err != nil {
+			return fmt.Errorf("failed to get checksum of %s: %v", localPastelupPath, err)
+		}
+	} else {
+		// only amd64 builds are published
+		if platform.goarch != "amd64" {
+			return errors.Errorf("remote host is %s, but pastelup is published only for amd64, "+
+				"run pastelup built for %s to manage this host", platform, platform)
+		}
+		if len(version) == 0 {
+			version = "latest"
+		}
+		downloadURL = fmt.Sprintf("%s/%s/%s", constants.DownloadBaseURL, constants.GetVersionSubURL(version),
+			constants.PastelUpExecName[platform.osType])
+		if checksum, err = getPublishedChecksum(ctx, downloadURL); err != nil {
+			return err
+		}
+	}
+
+	if len(checksum) != 0 {
+		if remoteChecksum, err := getRemoteChecksum(client, remotePastelUp); err == nil && strings.EqualFold(remoteChecksum, checksum) {
+			log.WithContext(ctx).Infof("pastelup on remote host is up to date, skipping upload")
+			return nil
+		}
+	}
+
+	newPastelUp := remotePastelUp + ".new"
+	if _, err := client.Cmd(fmt.Sprintf(`mkdir -p "%[1]s" && chmod 700 "%[1]s"`, path.Dir(remotePastelUp))).Output(); err != nil {
+		return fmt.Errorf("failed to create %s at remote: %v", path.Dir(remotePastelUp), err)
+	}
+
+	if len(localPastelupPath) != 0 {
+		log.WithContext(ctx).Infof("copying pastelup to remote %s host", platform)
+		if err := client.Scp(localPastelupPath, newPastelUp, "0755"); err != nil {
+			return fmt.Errorf("failed to copy pastelup to remote %s", err)
+		}
+	} else {
+		log.WithContext(ctx).Infof("remote host is %s, downloading pastelup from %s", platform, downloadURL)
+		cmd := fmt.Sprintf(`wget -q "%[1]s" -O "%[2]s" || curl -fsSL "%[1]s" -o "%[2]s"`, downloadURL, newPastelUp)
+		if out, err := client.Cmd(cmd).SmartOutput(); err != nil {
+			return fmt.Errorf("failed to download pastelup at remote: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		if _, err := client.Cmd(fmt.Sprintf(`chmod 755 "%s"`, newPastelUp)).Output(); err != nil {
+			return fmt.Errorf("failed to chmod +x pastelup at remote: %s", err.Error())
+		}
+	}
+
+	// verify the transferred file before it replaces the working one
+	if len(checksum) != 0 {
+		remoteChecksum, err := getRemoteChecksum(client, newPastelUp)
+		if err != nil {
+			return fmt.Errorf("failed to get checksum of pastelup at remote: %v", err)
+		}
+		if !strings.EqualFold(remoteChecksum, checksum) {
+			client.Cmd(fmt.Sprintf(`rm -f "%s"`, newPastelUp)).Run()
+			return fmt.Errorf("checksum mismatch of pastelup at remote: expected %s, got %s", checksum, remoteChecksum)
+		}
+	}
+	if out, err := client.Cmd(fmt.Sprintf(`"%s" --version`, newPastelUp)).SmartOutput(); err != nil {
+		client.Cmd(fmt.Sprintf(`rm -f "%s"`, newPastelUp)).Run()
+		return fmt.Errorf("pastelup failed to run at remote: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	if _, err := client.Cmd(fmt.Sprintf(`mv -f "%s" "%s"`, newPastelUp, remotePastelUp)).Output(); err != nil {
+		return fmt.Errorf("failed to replace pastelup at remote: %v", err)
+	}
+	return nil
+}
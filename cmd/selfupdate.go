@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/gonode/common/version"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	selfUpdateCommandName = "self-update"
+
+	// startup check must not noticeably delay the command the user runs
+	updateCheckTimeout = 3 * time.Second
+	checksumTimeout    = 30 * time.Second
+)
+
+var flagSelfUpdateChannel string
+
+// updateCheck is the cached result of the last check for the new pastelup version
+type updateCheck struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	Channel       string    `json:"channel"`
+	LatestVersion string    `json:"latest_version,omitempty"`
+}
+
+func setupSelfUpdateCommand(config *configs.Config) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("channel", &flagSelfUpdateChannel).SetAliases("c").
+			SetUsage(green("Optional, release channel to update from, can be - \"latest\", \"beta\" or exact release version, e.g. \"v1.2.3\"")).SetValue("latest"),
+		cli.NewFlag("force", &config.Force).SetAliases("f").
+			SetUsage(green("Optional, install the release even if it is not newer than the current version")),
+		cli.NewFlag("skip-verify", &config.SkipVerify).
+			SetUsage(green("Optional, install the release even if its checksum is not published")),
+	}
+
+	selfUpdateCommand := cli.NewCommand(selfUpdateCommandName)
+	selfUpdateCommand.SetUsage(blue("Updates pastelup itself to the newer version from the release channel"))
+	selfUpdateCommand.AddFlags(commandFlags...)
+	addLogFlags(selfUpdateCommand, config)
+
+	selfUpdateCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, selfUpdateCommandName, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		log.WithContext(ctx).Info("Started")
+		if err = runSelfUpdate(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return selfUpdateCommand
+}
+
+func runSelfUpdate(ctx context.Context, config *configs.Config) error {
+	channel := flagSelfUpdateChannel
+	pinned := channel != "latest" && channel != "beta"
+	current := version.Version()
+
+	target, err := resolveReleaseChannel(ctx, channel, releaseIndexTimeout)
+	if err != nil {
+		return err
+	}
+
+	if !config.Force {
+		cmp, err := utils.CompareVersions(target, current)
+		switch {
+		case err != nil:
+			log.WithContext(ctx).Warnf("Current pastelup version %q is not a release version, installing %s", current, target)
+		case cmp == 0:
+			log.WithContext(ctx).Infof("pastelup %s is already installed", current)
+			saveUpdateCheck(config, channel, target)
+			return nil
+		case cmp < 0 && !pinned:
+			log.WithContext(ctx).Infof("pastelup %s is newer than %s of \"%s\" channel, use --force to downgrade", current, target, channel)
+			return nil
+		}
+	}
+
+	exePath, err := os.Executable()
+	if err != nil {
+		return errors.Errorf("failed to get path of pastelup executable: %v", err)
+	}
+	if exePath, err = filepath.EvalSymlinks(exePath); err != nil {
+		return errors.Errorf("failed to resolve path of pastelup executable: %v", err)
+	}
+
+	downloadURL, _, err := config.Configurer.GetDownloadURL(target, constants.Pastelup)
+	if err != nil {
+		return errors.Errorf("failed to get download url: %v", err)
+	}
+
+	// download next to the executable, so it can be renamed over it
+	newPath := exePath + ".new"
+	defer os.Remove(newPath)
+
+	log.WithContext(ctx).Infof("Updating pastelup %s to %s ...", current, target)
+	if err = utils.DownloadFile(ctx, newPath, downloadURL.String()); err != nil {
+		return errors.Errorf("failed to download pastelup %s: %v", target, err)
+	}
+	if err = verifyPastelupExecutable(ctx, newPath, downloadURL.String(), target, config.SkipVerify); err != nil {
+		return err
+	}
+	if err = replaceExecutable(ctx, newPath, exePath); err != nil {
+		return err
+	}
+
+	saveUpdateCheck(config, channel, target)
+	log.WithContext(ctx).Infof("pastelup updated to %s, previous version is kept in %s", target, exePath+".bak")
+	return nil
+}
+
+// verifyPastelupExecutable checks downloaded pastelup against published checksum and that it runs, missing checksum
+// fails the verification unless skipMissingChecksum is set, expectedVersion is checked only if it is an exact release version
+func verifyPastelupExecutable(ctx context.Context, path string, downloadURL string, expectedVersion string, skipMissingChecksum bool) error {
+	checksumURL := downloadURL + ".sha256"
+	body, err := utils.GetURL(checksumURL, checksumTimeout)
+	switch {
+	case err == utils.ErrNotFound && skipMissingChecksum:
+		log.WithContext(ctx).Warnf("Checksum is not published at %s, skipping checksum verification", checksumURL)
+	case err == utils.ErrNotFound:
+		return errors.Errorf("checksum is not published at %s, use --skip-verify to install unverified pastelup", checksumURL)
+	case err != nil:
+		return errors.Errorf("failed to download checksum of %s: %v", downloadURL, err)
+	default:
+		fields := strings.Fields(string(body))
+		if len(fields) == 0 {
+			return errors.Errorf("checksum %s is empty", checksumURL)
+		}
+		checksum, err := utils.GetChecksum(ctx, path)
+		if err != nil {
+			return errors.Errorf("failed to get checksum of %s: %v", path, err)
+		}
+		if !strings.EqualFold(checksum, fields[0]) {
+			return errors.Errorf("checksum mismatch of downloaded %s: expected %s, got %s", downloadURL, fields[0], checksum)
+		}
+		log.WithContext(ctx).Info("Checksum of downloaded pastelup is verified")
+	}
+
+	if err = os.Chmod(path, 0755); err != nil {
+		return errors.Errorf("failed to make %s executable: %v", path, err)
+	}
+
+	out, err := RunCMD(path, "--version")
+	if err != nil {
+		return errors.Errorf("downloaded pastelup failed to run: %v: %s", err, out)
+	}
+	if _, err := utils.CompareVersions(expectedVersion, expectedVersion); err == nil &&
+		!strings.Contains(out, strings.TrimPrefix(expectedVersion, "v")) {
+		return errors.Errorf("downloaded pastelup reports unexpected version: %s", strings.TrimSpace(out))
+	}
+	return nil
+}
+
+// replaceExecutable atomically replaces dstPath with srcPath, keeping the previous file as dstPath.bak
+func replaceExecutable(ctx context.Context, srcPath string, dstPath string) error {
+	if utils.CheckFileExist(dstPath) {
+		backupPath := dstPath + ".bak"
+		if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+			return errors.Errorf("failed to remove old backup %s: %v", backupPath, err)
+		}
+		// hard link keeps the running executable untouched, copy if the filesystem doesn't support them
+		if err := os.Link(dstPath, backupPath); err != nil {
+			log.WithContext(ctx).Debugf("Failed to link %s to %s, copying: %v", dstPath, backupPath, err)
+			if err = utils.CopyFile(ctx, dstPath, filepath.Dir(backupPath), filepath.Base(backupPath)); err != nil {
+				return errors.Errorf("failed to backup %s: %v", dstPath, err)
+			}
+			if err = os.Chmod(backupPath, 0755); err != nil {
+				return errors.Errorf("failed to make %s executable: %v", backupPath, err)
+			}
+		}
+	}
+
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		return errors.Errorf("failed to replace %s: %v", dstPath, err)
+	}
+	return nil
+}
+
+func getUpdateCheckPath(config *configs.Config) string {
+	return filepath.Join(config.Configurer.DefaultHomeDir(), constants.UpdateCheckFileName)
+}
+
+func saveUpdateCheck(config *configs.Config, channel string, latestVersion string) {
+	if channel != "latest" && channel != "beta" {
+		// pinned version tells nothing about the channel, so let the next startup check it
+		os.Remove(getUpdateCheckPath(config))
+		return
+	}
+	data, err := json.Marshal(updateCheck{CheckedAt: time.Now(), Channel: channel, LatestVersion: latestVersion})
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(getUpdateCheckPath(config), data, 0644)
+}
+
+// warnIfUpdateAvailable prints the notice if the newer pastelup is published,
+// the result is cached for constants.UpdateCheckInterval, any failure is silently ignored.
+// Non-interactive runs (scripts, cron, pastelup started on the remote host) are not checked
+func warnIfUpdateAvailable(config *configs.Config, args []string) {
+	if sys.GetBoolEnv(constants.NoUpdateCheckEnvName, false) {
+		return
+	}
+	if !utils.IsTerminal(os.Stdout) || !utils.IsTerminal(os.Stderr) {
+		return
+	}
+	for _, arg := range args {
+		if arg == selfUpdateCommandName {
+			return
+		}
+	}
+
+	current := version.Version()
+	if _, err := utils.CompareVersions(current, current); err != nil {
+		// development build
+		return
+	}
+	channel := "latest"
+	if utils.IsPreRelease(current) {
+		channel = "beta"
+	}
+
+	var check updateCheck
+	if data, err := ioutil.ReadFile(getUpdateCheckPath(config)); err == nil {
+		json.Unmarshal(data, &check)
+	}
+	if check.Channel != channel || time.Since(check.CheckedAt) > constants.UpdateCheckInterval {
+		latest, err := resolveReleaseChannel(context.Background(), channel, updateCheckTimeout)
+		if err != nil {
+			latest = ""
+		}
+		// failed check is cached too, so offline hosts don't wait for the timeout on every run
+		saveUpdateCheck(config, channel, latest)
+		check.LatestVersion = latest
+	}
+
+	if cmp, err := utils.CompareVersions(check.LatestVersion, current); err == nil && cmp > 0 {
+		fmt.Fprintf(os.Stderr, "A newer pastelup version %s is available (current %s), run 'pastelup %s' to update. "+
+			"Set %s=true to turn off this check.\n", check.LatestVersion, current, selfUpdateCommandName, constants.NoUpdateCheckEnvName)
+	}
+}
@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/pastelnetwork/gonode/common/log"
@@ -13,6 +17,16 @@ import (
 	"github.com/pastelnetwork/pastelup/utils"
 )
 
+const (
+	serviceManagerSystemd     = "systemd"
+	serviceManagerSystemdUser = "systemd-user"
+	serviceManagerSupervisord = "supervisord"
+	serviceManagerOpenRC      = "openrc"
+)
+
+// ServiceManagerNames are supported service managers in the order of auto-detection
+var ServiceManagerNames = []string{serviceManagerSystemd, serviceManagerSystemdUser, serviceManagerOpenRC, serviceManagerSupervisord}
+
 // ServiceManager handles registering, starting and stopping system processes on the clients respective OS system manager (i.e. linux -> systemctl)
 type ServiceManager interface {
 	RegisterService(context.Context, *configs.Config, constants.ToolType, bool) error
@@ -20,9 +34,11 @@ type ServiceManager interface {
 	StopService(context.Context, *configs.Config, constants.ToolType) error
 	EnableService(context.Context, *configs.Config, constants.ToolType) error
 	DisableService(context.Context, *configs.Config, constants.ToolType) error
+	RemoveService(context.Context, *configs.Config, constants.ToolType) error
 	IsRunning(context.Context, *configs.Config, constants.ToolType) bool
 	IsRegistered(context.Context, *configs.Config, constants.ToolType) bool
 	ServiceName(constants.ToolType) string
+	Name() string
 }
 
 /*type systemdCmd string
@@ -36,19 +52,106 @@ const (
 )
 */
 
-// NewServiceManager returns a new serviceManager, if the OS does not have one configured, the error will be set and Noop Manager will be returned
-func NewServiceManager(os constants.OSType, homeDir string) (ServiceManager, error) {
-	switch os {
-	case constants.Linux:
-		return LinuxSystemdManager{
-			homeDir: homeDir,
-		}, nil
+// NewServiceManager returns a new serviceManager, if the OS does not have one configured, the error will be set and Noop Manager will be returned.
+// Service manager is taken from --service-manager option, from the one used to register services of the instance, or detected on the host
+func NewServiceManager(config *configs.Config) (ServiceManager, error) {
+	os := utils.GetOS()
+	if os == constants.Linux {
+		name := config.ServiceManager
+		if len(name) == 0 {
+			name = loadServiceManagerName(config)
+		}
+		if len(name) == 0 {
+			name = detectServiceManager(config)
+		}
+		switch name {
+		case serviceManagerSystemd:
+			return LinuxSystemdManager{instance: config.Instance}, nil
+		case serviceManagerSystemdUser:
+			return LinuxSystemdManager{instance: config.Instance, userMode: true}, nil
+		case serviceManagerSupervisord:
+			return SupervisordManager{instance: config.Instance}, nil
+		case serviceManagerOpenRC:
+			return OpenRCManager{instance: config.Instance}, nil
+		case "":
+			return NoopManager{}, fmt.Errorf("none of supported service managers found: %s", strings.Join(ServiceManagerNames, ", "))
+		}
+		return NoopManager{}, fmt.Errorf("unknown service manager %q, supported: %s", name, strings.Join(ServiceManagerNames, ", "))
 	}
 	// if you don't want to check error, we return a noop manager that will do nothing since
 	// the user's system is not supported for system management
 	return NoopManager{}, fmt.Errorf("services are not comptabile with your OS (%v)", os)
 }
 
+// detectServiceManager returns name of the service manager available on the host,
+// user-level systemd is used when system one can't be managed without root privileges
+func detectServiceManager(config *configs.Config) string {
+	switch {
+	case utils.CheckFileExist("/run/systemd/system"):
+		if os.Geteuid() == 0 || canUseSudo(config) {
+			return serviceManagerSystemd
+		}
+		if len(os.Getenv("XDG_RUNTIME_DIR")) != 0 || hasLinger() {
+			return serviceManagerSystemdUser
+		}
+	case utils.CheckFileExist("/run/openrc") && findSystemTool("rc-service"):
+		return serviceManagerOpenRC
+	}
+	if findSystemTool("supervisorctl") {
+		return serviceManagerSupervisord
+	}
+	return ""
+}
+
+// canUseSudo checks that the user is allowed to run commands with sudo, with --user-pw password if it is set,
+// otherwise without password prompt
+func canUseSudo(config *configs.Config) bool {
+	if !findSystemTool("sudo") {
+		return false
+	}
+	cmd := exec.Command("sudo", "-n", "true")
+	if len(config.UserPw) > 0 {
+		cmd = exec.Command("sudo", "-S", "-p", "", "true")
+		cmd.Stdin = strings.NewReader(config.UserPw + "\n")
+	}
+	return cmd.Run() == nil
+}
+
+// hasLinger checks that user manager of systemd is kept running for the user without the session
+func hasLinger() bool {
+	username, err := RunCMD("whoami")
+	if err != nil {
+		return false
+	}
+	return utils.CheckFileExist(filepath.Join("/var/lib/systemd/linger", strings.TrimSpace(username)))
+}
+
+type serviceManagerSettings struct {
+	Manager string `json:"manager"`
+}
+
+// loadServiceManagerName returns service manager used to register services of the instance
+func loadServiceManagerName(config *configs.Config) string {
+	data, err := ioutil.ReadFile(filepath.Join(config.WorkingDir, constants.ServicesFileName))
+	if err != nil {
+		return ""
+	}
+	var settings serviceManagerSettings
+	if err = json.Unmarshal(data, &settings); err != nil {
+		return ""
+	}
+	return settings.Manager
+}
+
+// saveServiceManagerName remembers service manager, so start and stop commands use the same one
+func saveServiceManagerName(config *configs.Config, name string) error {
+	data, err := json.MarshalIndent(serviceManagerSettings{Manager: name}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(config.WorkingDir, constants.ServicesFileName), data, 0644)
+}
+
 // NoopManager can be used to do nothing if the OS doesnt have a system manager configured
 type NoopManager struct{}
 
@@ -82,6 +185,11 @@ func (nm NoopManager) DisableService(context.Context, *configs.Config, constants
 	return nil
 }
 
+// RemoveService removes the registered service
+func (nm NoopManager) RemoveService(context.Context, *configs.Config, constants.ToolType) error {
+	return nil
+}
+
 // IsRegistered checks if the associated app's system command file exists, if it does it returns true, else it returns false
 // if err is not nil, there was an error checking the existence of the file
 func (nm NoopManager) IsRegistered(context.Context, *configs.Config, constants.ToolType) bool {
@@ -93,38 +201,81 @@ func (nm NoopManager) ServiceName(constants.ToolType) string {
 	return ""
 }
 
-// LinuxSystemdManager is a service manager for linux based OS
-type LinuxSystemdManager struct {
-	homeDir string
+// Name returns name of the service manager
+func (nm NoopManager) Name() string {
+	return ""
 }
 
-// RegisterService registers the service and starts it
-func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) error {
-	log.WithContext(ctx).Infof("Installing %v as systemd service", app)
+///// Service helpers
 
-	if isRegistered := sm.IsRegistered(ctx, config, app); isRegistered {
-		return nil // already registered
+// getServiceBaseName returns name of the service without extension, services of the named instance are prefixed with the instance name
+func getServiceBaseName(instance string, app constants.ToolType) string {
+	if len(instance) != 0 {
+		return fmt.Sprintf("%v%v-%v", constants.SystemdServicePrefix, instance, app)
 	}
+	return fmt.Sprintf("%v%v", constants.SystemdServicePrefix, app)
+}
 
-	var systemdFile string
-	var err error
-	var execCmd, execPath, workDir string
-
-	// Service file - will be installed at /etc/systemd/system
-	appServiceFileName := sm.ServiceName(app)
-	appServiceFilePath := filepath.Join(constants.SystemdSystemDir, appServiceFileName)
-	appServiceTempFilePath := filepath.Join("/tmp/", appServiceFileName)
+func getServiceDesc(instance string, app constants.ToolType) string {
+	if len(instance) != 0 {
+		return fmt.Sprintf("%v daemon (instance %v)", app, instance)
+	}
+	return fmt.Sprintf("%v daemon", app)
+}
 
-	username, err := RunCMD("whoami")
-	if err != nil {
-		return fmt.Errorf("unable to get own user name (%v): %v", app, err)
+// getServiceDependencies derives dependencies of the service from appToServiceMap of the solutions - service is started
+// after the services listed before it, and the solution component and the ones following it require pasteld and the solution component
+func getServiceDependencies(app constants.ToolType) (after []constants.ToolType, wants []constants.ToolType, requires []constants.ToolType) {
+	seen := make(map[constants.ToolType]bool)
+	seenRequired := make(map[constants.ToolType]bool)
+	for _, solution := range []constants.ToolType{constants.SuperNode, constants.WalletNode} {
+		services := appToServiceMap[solution]
+		appIdx, solutionIdx := -1, -1
+		for i, service := range services {
+			if service == app {
+				appIdx = i
+			}
+			if service == solution {
+				solutionIdx = i
+			}
+		}
+		if appIdx <= 0 {
+			continue
+		}
+		for _, dep := range services[:appIdx] {
+			if !seen[dep] {
+				seen[dep] = true
+				after = append(after, dep)
+				wants = append(wants, dep)
+			}
+		}
+		if appIdx < solutionIdx {
+			continue
+		}
+		deps := []constants.ToolType{constants.PastelD}
+		if appIdx > solutionIdx {
+			deps = append(deps, solution)
+		}
+		for _, dep := range deps {
+			if !seenRequired[dep] {
+				seenRequired[dep] = true
+				requires = append(requires, dep)
+			}
+		}
 	}
+	return after, wants, requires
+}
+
+// getServiceExecCmd returns command line and working directory of the service,
+// empty command is returned if the app can't be run as a service
+func getServiceExecCmd(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) (execCmd string, workDir string, err error) {
+	var execPath string
 
 	pastelConfigPath := filepath.Join(config.WorkingDir, constants.PastelConfName)
 
 	switch app {
 	case constants.DDImgService:
-		appBaseDir := filepath.Join(sm.homeDir, constants.DupeDetectionServiceDir)
+		appBaseDir := getDDServiceDir(config)
 		appServiceWorkDirPath := filepath.Join(appBaseDir, "img_server")
 		execCmd = "python3 -m  http.server 8000"
 		workDir = appServiceWorkDirPath
@@ -134,19 +285,23 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, constants.PasteldName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 		// Get external IP
 		if extIP, err = utils.GetExternalIPAddress(); err != nil {
 			log.WithContext(ctx).WithError(err).Error("Could not get external IP address")
-			return err
+			return "", "", err
+		}
+		execCmd = execPath + " --datadir=" + config.WorkingDir + " --externalip=" + extIP
+		// reindex is very long, so it is only done when asked explicitly
+		if config.ReIndex {
+			execCmd += " --reindex"
 		}
-		execCmd = execPath + " --datadir=" + config.WorkingDir + " --externalip=" + extIP + " --reindex"
 		if isMn {
 			privKey, _ /*extIP*/, _ /*extPort*/, err := getMasternodeConfData(ctx, config, flagMasterNodeName, extIP)
 			if err != nil {
 				log.WithContext(ctx).WithError(err).Error("Failed to get masternode details from masternode.conf")
-				return err
+				return "", "", err
 			}
 			execCmd += " --txindex=1 --masternode --masternodeprivkey=" + privKey
 		}
@@ -155,7 +310,7 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, constants.PastelRQServiceExecName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Printf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 		rqServiceArgs := fmt.Sprintf("--config-file=%s", config.Configurer.GetRQServiceConfFile(config.WorkingDir))
 		execCmd = execPath + " " + rqServiceArgs
@@ -164,24 +319,21 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, utils.GetDupeDetectionExecName())
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Printf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 		envPythonPath := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "/venv/bin/python3")
 		if exists := utils.CheckFileExist(envPythonPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Printf("Could not find venv python executable file at %s", envPythonPath))
-			return err
+			return "", "", err
 		}
-		ddConfigFilePath := filepath.Join(sm.homeDir,
-			constants.DupeDetectionServiceDir,
-			constants.DupeDetectionSupportFilePath,
-			constants.DupeDetectionConfigFilename)
+		ddConfigFilePath := getDDConfigFilePath(config)
 		execCmd = envPythonPath + " " + execPath + " " + ddConfigFilePath
 		workDir = config.PastelExecDir
 	case constants.SuperNode:
 		execPath = filepath.Join(config.PastelExecDir, constants.SuperNodeExecName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 		supernodeConfigPath := config.Configurer.GetSuperNodeConfFile(config.WorkingDir)
 		execCmd = execPath + " --config-file=" + supernodeConfigPath + " --pastel-config-file=" + pastelConfigPath
@@ -190,7 +342,7 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, constants.HermesExecName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 
 		hermesConfigPath := config.Configurer.GetHermesConfFile(config.WorkingDir)
@@ -200,7 +352,7 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, constants.WalletNodeExecName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 		walletnodeConfigFile := config.Configurer.GetWalletNodeConfFile(config.WorkingDir)
 		execCmd = execPath + " --config-file=" + walletnodeConfigFile + " --pastel-config-file=" + pastelConfigPath
@@ -212,49 +364,278 @@ func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *confi
 		execPath = filepath.Join(config.PastelExecDir, constants.BridgeExecName[utils.GetOS()])
 		if exists := utils.CheckFileExist(execPath); !exists {
 			log.WithContext(ctx).WithError(err).Error(fmt.Sprintf("Could not find %v executable file", app))
-			return err
+			return "", "", err
 		}
 
 		bridgeConfigPath := config.Configurer.GetBridgeConfFile(config.WorkingDir)
 		execCmd = execPath + " --config-file=" + bridgeConfigPath + " --pastel-config-file=" + pastelConfigPath
 		workDir = config.PastelExecDir
-	default:
-		return nil
 	}
+	return execCmd, workDir, nil
+}
 
-	// Create systemd file
-	systemdFile, err = utils.GetServiceConfig(string(app), configs.SystemdService,
-		&configs.SystemdServiceScript{
-			Desc:    fmt.Sprintf("%v daemon", app),
-			ExecCmd: execCmd,
-			WorkDir: workDir,
-			User:    username,
-		})
+// runPrivilegedCMD runs command as is under root, and with sudo otherwise, e.g. in containers there is no sudo
+func runPrivilegedCMD(config *configs.Config, args ...string) (string, error) {
+	if os.Geteuid() == 0 {
+		return RunCMD(args[0], args[1:]...)
+	}
+	return RunSudoCMD(config, args...)
+}
+
+// writePrivilegedFile writes file to the system directory through the temporary file
+func writePrivilegedFile(config *configs.Config, path string, data string, mode os.FileMode) error {
+	// temporary file gets unique name and is created exclusively, so it can't be pre-created or replaced by a symlink
+	tempFile, err := ioutil.TempFile("", filepath.Base(path)+".*")
 	if err != nil {
-		e := fmt.Errorf("unable ot create service file for (%v): %v", app, err)
-		log.WithContext(ctx).WithError(err).Error(e.Error())
-		return e
+		return fmt.Errorf("unable to create temporary file for %s: %v", path, err)
+	}
+	tempPath := tempFile.Name()
+	defer os.Remove(tempPath)
+
+	_, err = tempFile.WriteString(data)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write %s file: %v", tempPath, err)
 	}
 
-	// write systemdFile to SystemdUserDir with mode 0644
-	if err := ioutil.WriteFile(appServiceTempFilePath, []byte(systemdFile), 0644); err != nil {
-		log.WithContext(ctx).WithError(err).Error("unable to write " + appServiceFileName + " file")
+	if out, err := runPrivilegedCMD(config, "cp", tempPath, path); err != nil {
+		return fmt.Errorf("unable to copy %s: %v: %s", path, err, out)
 	}
+	if out, err := runPrivilegedCMD(config, "chmod", fmt.Sprintf("%o", mode), path); err != nil {
+		return fmt.Errorf("unable to chmod %s: %v: %s", path, err, out)
+	}
+	return nil
+}
 
-	_, err = RunSudoCMD(config, "cp", appServiceTempFilePath, appServiceFilePath)
+func getServiceUser(app constants.ToolType) (string, error) {
+	username, err := RunCMD("whoami")
 	if err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to update")
+		return "", fmt.Errorf("unable to get own user name (%v): %v", app, err)
+	}
+	return strings.TrimSpace(username), nil
+}
+
+///// systemd
+
+// LinuxSystemdManager is a service manager for linux based OS, in user mode services are managed
+// with "systemctl --user" without root privileges
+type LinuxSystemdManager struct {
+	instance string
+	userMode bool
+}
+
+// Name returns name of the service manager
+func (sm LinuxSystemdManager) Name() string {
+	if sm.userMode {
+		return serviceManagerSystemdUser
+	}
+	return serviceManagerSystemd
+}
+
+func (sm LinuxSystemdManager) systemctl(config *configs.Config, args ...string) (string, error) {
+	if sm.userMode {
+		return RunCMD("systemctl", append([]string{"--user"}, args...)...)
+	}
+	return RunSudoCMD(config, append([]string{"systemctl"}, args...)...)
+}
+
+// userArgs returns arguments of systemctl and journalctl selecting the user-level systemd
+func (sm LinuxSystemdManager) userArgs() []string {
+	if sm.userMode {
+		return []string{"--user"}
+	}
+	return nil
+}
+
+// hasUnit checks if the unit of the service exists, unlike IsRegistered it doesn't need root privileges
+func (sm LinuxSystemdManager) hasUnit(ctx context.Context, app constants.ToolType) bool {
+	args := append(sm.userArgs(), "cat", sm.ServiceName(app))
+	return exec.CommandContext(ctx, "systemctl", args...).Run() == nil
+}
+
+func (sm LinuxSystemdManager) unitDir(config *configs.Config) string {
+	if sm.userMode {
+		return filepath.Join(config.Configurer.DefaultHomeDir(), constants.SystemdUserDir)
+	}
+	return constants.SystemdSystemDir
+}
+
+// RegisterService registers the service, unit of the registered service is re-rendered if it was changed
+func (sm LinuxSystemdManager) RegisterService(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) error {
+	log.WithContext(ctx).Infof("Installing %v as %v service", app, sm.Name())
+
+	unit, err := sm.renderUnit(ctx, config, app, isMn)
+	if err != nil || len(unit) == 0 {
 		return err
 	}
 
-	// reload systemctl daemon
-	_, err = RunSudoCMD(config, "systemctl", "daemon-reload")
+	appServiceFileName := sm.ServiceName(app)
+	appServiceFilePath := filepath.Join(sm.unitDir(config), appServiceFileName)
+
+	reload := false
+	if current, err := ioutil.ReadFile(appServiceFilePath); err != nil || string(current) != unit {
+		if err == nil {
+			log.WithContext(ctx).Infof("Unit %s is changed, service has to be restarted to apply it", appServiceFileName)
+		}
+		if err = sm.writeFile(config, appServiceFilePath, unit); err != nil {
+			log.WithContext(ctx).WithError(err).Error("Failed to update")
+			return err
+		}
+		reload = true
+	}
+
+	overridesChanged, err := sm.updateOverridesDropIn(ctx, config, app)
 	if err != nil {
-		return fmt.Errorf("unable to reload systemctl daemon (%v): %v", app, err)
+		return err
+	}
+
+	if sm.userMode && reload {
+		// lingering keeps user services running without the user session and starts them on boot
+		if out, err := RunCMD("loginctl", "enable-linger"); err != nil {
+			log.WithContext(ctx).Warnf("unable to enable lingering, services will stop on logout: %v: %s", err, out)
+		}
+	}
+
+	if reload || overridesChanged {
+		// reload systemctl daemon
+		_, err = sm.systemctl(config, "daemon-reload")
+		if err != nil {
+			return fmt.Errorf("unable to reload systemctl daemon (%v): %v", app, err)
+		}
 	}
 	return nil
 }
 
+// RefreshService re-renders unit of the registered service with the current template, dependencies and overrides
+func (sm LinuxSystemdManager) RefreshService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	current, err := ioutil.ReadFile(filepath.Join(sm.unitDir(config), sm.ServiceName(app)))
+	if err != nil {
+		return nil // not registered
+	}
+	return sm.RegisterService(ctx, config, app, strings.Contains(string(current), " --masternode"))
+}
+
+// IsUpToDate checks if unit of the registered service is the same as RegisterService would render now
+func (sm LinuxSystemdManager) IsUpToDate(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	current, err := ioutil.ReadFile(filepath.Join(sm.unitDir(config), sm.ServiceName(app)))
+	if err != nil {
+		return false, err
+	}
+	unit, err := sm.renderUnit(ctx, config, app, strings.Contains(string(current), " --masternode"))
+	if err != nil {
+		return false, err
+	}
+	return string(current) == unit, nil
+}
+
+// renderUnit returns unit file of the service, empty unit is returned if the app can't be run as a service
+func (sm LinuxSystemdManager) renderUnit(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) (string, error) {
+	execCmd, workDir, err := getServiceExecCmd(ctx, config, app, isMn)
+	if err != nil || len(execCmd) == 0 {
+		return "", err
+	}
+
+	script := &configs.SystemdServiceScript{
+		Desc:     getServiceDesc(sm.instance, app),
+		ExecCmd:  execCmd,
+		WorkDir:  workDir,
+		EnvFile:  filepath.Join(config.WorkingDir, constants.ServiceEnvDir, string(app)+".env"),
+		WantedBy: "default.target",
+	}
+
+	after, wants, requires := getServiceDependencies(app)
+	// units of the services which are not registered are skipped, as missing required unit fails the start
+	var afterUnits, wantsUnits, requiresUnits []string
+	for _, dep := range after {
+		afterUnits = append(afterUnits, sm.ServiceName(dep))
+	}
+	for _, dep := range wants {
+		wantsUnits = append(wantsUnits, sm.ServiceName(dep))
+	}
+	for _, dep := range requires {
+		if utils.CheckFileExist(filepath.Join(sm.unitDir(config), sm.ServiceName(dep))) {
+			requiresUnits = append(requiresUnits, sm.ServiceName(dep))
+		}
+	}
+
+	// user services run as the user, are started with the user manager, and can't raise limits or use system units
+	if !sm.userMode {
+		if script.User, err = getServiceUser(app); err != nil {
+			return "", err
+		}
+		script.WantedBy = "multi-user.target"
+		script.LimitNOFile = constants.SystemdLimitNOFile
+		script.Hardening = true
+		afterUnits = append([]string{"network-online.target"}, afterUnits...)
+		wantsUnits = append([]string{"network-online.target"}, wantsUnits...)
+	}
+	script.After = strings.Join(afterUnits, " ")
+	script.Wants = strings.Join(wantsUnits, " ")
+	script.Requires = strings.Join(requiresUnits, " ")
+
+	unit, err := utils.GetServiceConfig(string(app), configs.SystemdService, script)
+	if err != nil {
+		e := fmt.Errorf("unable ot create service file for (%v): %v", app, err)
+		log.WithContext(ctx).WithError(err).Error(e.Error())
+		return "", e
+	}
+	return unit, nil
+}
+
+func (sm LinuxSystemdManager) writeFile(config *configs.Config, path string, data string) error {
+	if !sm.userMode {
+		return writePrivilegedFile(config, path, data, 0644)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("unable to create %s: %v", filepath.Dir(path), err)
+	}
+	return ioutil.WriteFile(path, []byte(data), 0644)
+}
+
+// updateOverridesDropIn copies user overrides of the unit from the working directory to the drop-in file of the unit,
+// so they survive re-rendering of the unit, returns true if the drop-in was changed
+func (sm LinuxSystemdManager) updateOverridesDropIn(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	overridesPath := filepath.Join(config.WorkingDir, constants.ConfigOverridesDir, string(app)+".service")
+	dropInDir := filepath.Join(sm.unitDir(config), sm.ServiceName(app)+".d")
+	dropInPath := filepath.Join(dropInDir, constants.SystemdOverridesDropIn)
+
+	current, currentErr := ioutil.ReadFile(dropInPath)
+	overrides, err := ioutil.ReadFile(overridesPath)
+	if os.IsNotExist(err) {
+		if currentErr != nil {
+			return false, nil
+		}
+		log.WithContext(ctx).Infof("Removing %s, as %s doesn't exist", dropInPath, overridesPath)
+		if sm.userMode {
+			err = os.Remove(dropInPath)
+		} else {
+			_, err = RunSudoCMD(config, "rm", "-f", dropInPath)
+		}
+		if err != nil {
+			return false, fmt.Errorf("unable to remove %s: %v", dropInPath, err)
+		}
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("unable to read %s: %v", overridesPath, err)
+	}
+
+	if currentErr == nil && string(current) == string(overrides) {
+		return false, nil
+	}
+	log.WithContext(ctx).Infof("Applying overrides %s to %s", overridesPath, sm.ServiceName(app))
+	if !sm.userMode {
+		if out, err := RunSudoCMD(config, "mkdir", "-p", dropInDir); err != nil {
+			return false, fmt.Errorf("unable to create %s: %v: %s", dropInDir, err, out)
+		}
+	}
+	if err = sm.writeFile(config, dropInPath, string(overrides)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // StartService starts the given service as long as it is registered
 func (sm LinuxSystemdManager) StartService(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
 	isRegistered := sm.IsRegistered(ctx, config, app)
@@ -267,7 +648,7 @@ func (sm LinuxSystemdManager) StartService(ctx context.Context, config *configs.
 		log.WithContext(ctx).Infof("service %v is already running: noop", app)
 		return true, nil
 	}
-	_, err := RunSudoCMD(config, "systemctl", "start", sm.ServiceName(app))
+	_, err := sm.systemctl(config, "start", sm.ServiceName(app))
 	if err != nil {
 		return false, fmt.Errorf("unable to start service (%v): %v", app, err)
 	}
@@ -281,7 +662,7 @@ func (sm LinuxSystemdManager) StopService(ctx context.Context, config *configs.C
 		log.WithContext(ctx).Infof("Service %s is not running", string(app))
 		return nil // service isn't running, no need to stop
 	}
-	_, err := RunSudoCMD(config, "systemctl", "stop", sm.ServiceName(app))
+	_, err := sm.systemctl(config, "stop", sm.ServiceName(app))
 	if err != nil {
 		return fmt.Errorf("unable to stop service (%v): %v", app, err)
 	}
@@ -292,7 +673,7 @@ func (sm LinuxSystemdManager) StopService(ctx context.Context, config *configs.C
 func (sm LinuxSystemdManager) EnableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
 	appServiceFileName := sm.ServiceName(app)
 	log.WithContext(ctx).Info("Enabling service for auto-start")
-	if out, err := RunSudoCMD(config, "systemctl", "enable", appServiceFileName); err != nil {
+	if out, err := sm.systemctl(config, "enable", appServiceFileName); err != nil {
 		log.WithContext(ctx).WithFields(log.Fields{"message": out}).
 			WithError(err).Error("unable to enable " + appServiceFileName + " service")
 		return fmt.Errorf("err enabling "+appServiceFileName+" - err: %s", err)
@@ -304,7 +685,7 @@ func (sm LinuxSystemdManager) EnableService(ctx context.Context, config *configs
 func (sm LinuxSystemdManager) DisableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
 	appServiceFileName := sm.ServiceName(app)
 	log.WithContext(ctx).Info("Disabling service", appServiceFileName)
-	if out, err := RunSudoCMD(config, "systemctl", "disable", appServiceFileName); err != nil {
+	if out, err := sm.systemctl(config, "disable", appServiceFileName); err != nil {
 		log.WithContext(ctx).WithFields(log.Fields{"message": out}).
 			WithError(err).Error("unable to disable " + appServiceFileName + " service")
 		return fmt.Errorf("err enabling "+appServiceFileName+" - err: %s", err)
@@ -312,9 +693,33 @@ func (sm LinuxSystemdManager) DisableService(ctx context.Context, config *config
 	return nil
 }
 
+// RemoveService removes unit of the stopped service and its drop-in directory
+func (sm LinuxSystemdManager) RemoveService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	unitPath := filepath.Join(sm.unitDir(config), sm.ServiceName(app))
+	if !utils.CheckFileExist(unitPath) {
+		return nil // not registered
+	}
+	log.WithContext(ctx).Infof("Removing %s", unitPath)
+	dropInDir := unitPath + ".d"
+	if sm.userMode {
+		if err := os.Remove(unitPath); err != nil {
+			return fmt.Errorf("unable to remove %s: %v", unitPath, err)
+		}
+		if err := os.RemoveAll(dropInDir); err != nil {
+			return fmt.Errorf("unable to remove %s: %v", dropInDir, err)
+		}
+	} else if out, err := RunSudoCMD(config, "rm", "-rf", unitPath, dropInDir); err != nil {
+		return fmt.Errorf("unable to remove %s: %v: %s", unitPath, err, out)
+	}
+	if _, err := sm.systemctl(config, "daemon-reload"); err != nil {
+		return fmt.Errorf("unable to reload systemctl daemon (%v): %v", app, err)
+	}
+	return nil
+}
+
 // IsRunning checks to see if the service is running
 func (sm LinuxSystemdManager) IsRunning(ctx context.Context, config *configs.Config, app constants.ToolType) bool {
-	res, _ := RunSudoCMD(config, "systemctl", "is-active", sm.ServiceName(app))
+	res, _ := sm.systemctl(config, "is-active", sm.ServiceName(app))
 	res = strings.TrimSpace(res)
 	log.WithContext(ctx).Infof("%v is-active status: %v", sm.ServiceName(app), res)
 	return res == "active" || res == "activating"
@@ -322,14 +727,322 @@ func (sm LinuxSystemdManager) IsRunning(ctx context.Context, config *configs.Con
 
 // IsRegistered checks if the associated app's system command file exists, if it does, it returns true, else it returns false
 func (sm LinuxSystemdManager) IsRegistered(ctx context.Context, config *configs.Config, app constants.ToolType) bool {
-	res, _ := RunSudoCMD(config, "systemctl", "list-unit-files", sm.ServiceName(app))
+	res, _ := sm.systemctl(config, "list-unit-files", sm.ServiceName(app))
 	res = strings.TrimSpace(res)
 	log.WithContext(ctx).Infof("%v list-unit-files status: %v", sm.ServiceName(app), res)
 
 	return !strings.Contains(res, "0 unit files listed.")
 }
 
-// ServiceName returns the formatted service name given a tooltype
+// ServiceName returns the formatted service name given a tooltype, services of the named instance are prefixed with the instance name
 func (sm LinuxSystemdManager) ServiceName(app constants.ToolType) string {
-	return fmt.Sprintf("%v%v.service", constants.SystemdServicePrefix, app)
+	return getServiceBaseName(sm.instance, app) + ".service"
+}
+
+///// supervisord
+
+// SupervisordManager is a service manager for hosts without systemd, e.g. containers, services are supervisord programs
+type SupervisordManager struct {
+	instance string
+}
+
+// Name returns name of the service manager
+func (sm SupervisordManager) Name() string {
+	return serviceManagerSupervisord
+}
+
+// programPath returns path of the program file, Debian and RHEL based distributions use different locations
+func (sm SupervisordManager) programPath(app constants.ToolType) string {
+	if utils.CheckFileExist(constants.SupervisordRHELDir) && !utils.CheckFileExist(constants.SupervisordDebianDir) {
+		return filepath.Join(constants.SupervisordRHELDir, sm.ServiceName(app)+".ini")
+	}
+	return filepath.Join(constants.SupervisordDebianDir, sm.ServiceName(app)+".conf")
+}
+
+func (sm SupervisordManager) supervisorctl(config *configs.Config, args ...string) (string, error) {
+	return runPrivilegedCMD(config, append([]string{"supervisorctl"}, args...)...)
+}
+
+func (sm SupervisordManager) writeProgram(ctx context.Context, config *configs.Config, app constants.ToolType, execCmd, workDir string, autoStart bool) error {
+	username, err := getServiceUser(app)
+	if err != nil {
+		return err
+	}
+	program, err := utils.GetServiceConfig(string(app), configs.SupervisordProgram,
+		&configs.SupervisordProgramScript{
+			Name:      sm.ServiceName(app),
+			ExecCmd:   execCmd,
+			WorkDir:   workDir,
+			User:      username,
+			AutoStart: autoStart,
+		})
+	if err != nil {
+		return fmt.Errorf("unable to create program file for (%v): %v", app, err)
+	}
+	current, err := ioutil.ReadFile(sm.programPath(app))
+	if err == nil && string(current) == program {
+		return nil // up to date
+	}
+	if err == nil {
+		log.WithContext(ctx).Infof("Program %s is changed, it is restarted by supervisord to apply it", sm.ServiceName(app))
+	}
+	if err = writePrivilegedFile(config, sm.programPath(app), program, 0644); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to update")
+		return err
+	}
+	// reread configs and add or update the program
+	if out, err := sm.supervisorctl(config, "update"); err != nil {
+		return fmt.Errorf("unable to update supervisord (%v): %v: %s", app, err, out)
+	}
+	return nil
+}
+
+// RegisterService adds program to supervisord, program is not started automatically until the service is enabled,
+// program of the registered service is re-rendered if it was changed and keeps its auto-start setting
+func (sm SupervisordManager) RegisterService(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) error {
+	log.WithContext(ctx).Infof("Installing %v as supervisord program", app)
+
+	execCmd, workDir, err := getServiceExecCmd(ctx, config, app, isMn)
+	if err != nil || len(execCmd) == 0 {
+		return err
+	}
+	autoStart := false
+	if current, err := ioutil.ReadFile(sm.programPath(app)); err == nil {
+		autoStart = supervisordAutoStartRegexp.FindString(string(current)) == "autostart=true"
+	}
+	return sm.writeProgram(ctx, config, app, execCmd, workDir, autoStart)
+}
+
+// StartService starts the given service as long as it is registered
+func (sm SupervisordManager) StartService(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	if !sm.IsRegistered(ctx, config, app) {
+		log.WithContext(ctx).Infof("skipping start service because %v is not a registered service", app)
+		return false, nil
+	}
+	if sm.IsRunning(ctx, config, app) {
+		log.WithContext(ctx).Infof("service %v is already running: noop", app)
+		return true, nil
+	}
+	if out, err := sm.supervisorctl(config, "start", sm.ServiceName(app)); err != nil {
+		return false, fmt.Errorf("unable to start service (%v): %v: %s", app, err, out)
+	}
+	return true, nil
+}
+
+// StopService stops a running service, it isn't running it is a no-op
+func (sm SupervisordManager) StopService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if !sm.IsRunning(ctx, config, app) {
+		log.WithContext(ctx).Infof("Service %s is not running", string(app))
+		return nil
+	}
+	if out, err := sm.supervisorctl(config, "stop", sm.ServiceName(app)); err != nil {
+		return fmt.Errorf("unable to stop service (%v): %v: %s", app, err, out)
+	}
+	return nil
+}
+
+var supervisordAutoStartRegexp = regexp.MustCompile(`(?m)^autostart=.*$`)
+
+func (sm SupervisordManager) setAutoStart(ctx context.Context, config *configs.Config, app constants.ToolType, autoStart bool) error {
+	data, err := ioutil.ReadFile(sm.programPath(app))
+	if err != nil {
+		return fmt.Errorf("unable to read program file of %v: %v", app, err)
+	}
+	program := supervisordAutoStartRegexp.ReplaceAllString(string(data), fmt.Sprintf("autostart=%v", autoStart))
+	if err = writePrivilegedFile(config, sm.programPath(app), program, 0644); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to update")
+		return err
+	}
+	if out, err := sm.supervisorctl(config, "reread"); err != nil {
+		return fmt.Errorf("unable to reread supervisord configs (%v): %v: %s", app, err, out)
+	}
+	return nil
+}
+
+// EnableService makes supervisord start the program on its start
+func (sm SupervisordManager) EnableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	log.WithContext(ctx).Info("Enabling service for auto-start")
+	return sm.setAutoStart(ctx, config, app, true)
+}
+
+// DisableService stops supervisord from starting the program on its start
+func (sm SupervisordManager) DisableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	log.WithContext(ctx).Info("Disabling service", sm.ServiceName(app))
+	return sm.setAutoStart(ctx, config, app, false)
+}
+
+// RemoveService removes the program file and the program from supervisord
+func (sm SupervisordManager) RemoveService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if !sm.IsRegistered(ctx, config, app) {
+		return nil
+	}
+	log.WithContext(ctx).Infof("Removing %s", sm.programPath(app))
+	if out, err := runPrivilegedCMD(config, "rm", "-f", sm.programPath(app)); err != nil {
+		return fmt.Errorf("unable to remove %s: %v: %s", sm.programPath(app), err, out)
+	}
+	if out, err := sm.supervisorctl(config, "update"); err != nil {
+		return fmt.Errorf("unable to update supervisord (%v): %v: %s", app, err, out)
+	}
+	return nil
+}
+
+// IsRunning checks to see if the service is running
+func (sm SupervisordManager) IsRunning(ctx context.Context, config *configs.Config, app constants.ToolType) bool {
+	// status exits with non zero code if the program is not running
+	res, _ := sm.supervisorctl(config, "status", sm.ServiceName(app))
+	log.WithContext(ctx).Infof("%v status: %v", sm.ServiceName(app), strings.TrimSpace(res))
+	return strings.Contains(res, "RUNNING") || strings.Contains(res, "STARTING")
+}
+
+// IsRegistered checks if the program file exists
+func (sm SupervisordManager) IsRegistered(_ context.Context, _ *configs.Config, app constants.ToolType) bool {
+	return utils.CheckFileExist(sm.programPath(app))
+}
+
+// ServiceName returns the program name given a tooltype
+func (sm SupervisordManager) ServiceName(app constants.ToolType) string {
+	return getServiceBaseName(sm.instance, app)
+}
+
+///// OpenRC
+
+// OpenRCManager is a service manager for OpenRC based distributions, e.g. Alpine and Gentoo
+type OpenRCManager struct {
+	instance string
+}
+
+// Name returns name of the service manager
+func (sm OpenRCManager) Name() string {
+	return serviceManagerOpenRC
+}
+
+func (sm OpenRCManager) scriptPath(app constants.ToolType) string {
+	return filepath.Join(constants.OpenRCInitDir, sm.ServiceName(app))
+}
+
+// RegisterService writes init script of the service, which is supervised by supervise-daemon,
+// script of the registered service is re-rendered if it was changed
+func (sm OpenRCManager) RegisterService(ctx context.Context, config *configs.Config, app constants.ToolType, isMn bool) error {
+	log.WithContext(ctx).Infof("Installing %v as OpenRC service", app)
+
+	execCmd, workDir, err := getServiceExecCmd(ctx, config, app, isMn)
+	if err != nil || len(execCmd) == 0 {
+		return err
+	}
+	username, err := getServiceUser(app)
+	if err != nil {
+		return err
+	}
+
+	// supervise-daemon requires full path of the command
+	args := strings.Fields(execCmd)
+	command := args[0]
+	if !filepath.IsAbs(command) {
+		if command, err = exec.LookPath(command); err != nil {
+			return fmt.Errorf("unable to find %s: %v", args[0], err)
+		}
+	}
+
+	script, err := utils.GetServiceConfig(string(app), configs.OpenRCService,
+		&configs.OpenRCServiceScript{
+			Desc:    getServiceDesc(sm.instance, app),
+			Command: command,
+			Args:    strings.Join(args[1:], " "),
+			WorkDir: workDir,
+			User:    username,
+		})
+	if err != nil {
+		return fmt.Errorf("unable to create init script for (%v): %v", app, err)
+	}
+	current, err := ioutil.ReadFile(sm.scriptPath(app))
+	if err == nil && string(current) == script {
+		return nil // up to date
+	}
+	if err == nil {
+		// openrc reads init script on each invocation, there is no daemon to reload
+		log.WithContext(ctx).Infof("Init script %s is changed, service has to be restarted to apply it", sm.ServiceName(app))
+	}
+	if err = writePrivilegedFile(config, sm.scriptPath(app), script, 0755); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to update")
+		return err
+	}
+	return nil
+}
+
+// StartService starts the given service as long as it is registered
+func (sm OpenRCManager) StartService(ctx context.Context, config *configs.Config, app constants.ToolType) (bool, error) {
+	if !sm.IsRegistered(ctx, config, app) {
+		log.WithContext(ctx).Infof("skipping start service because %v is not a registered service", app)
+		return false, nil
+	}
+	if sm.IsRunning(ctx, config, app) {
+		log.WithContext(ctx).Infof("service %v is already running: noop", app)
+		return true, nil
+	}
+	if out, err := runPrivilegedCMD(config, "rc-service", sm.ServiceName(app), "start"); err != nil {
+		return false, fmt.Errorf("unable to start service (%v): %v: %s", app, err, out)
+	}
+	return true, nil
+}
+
+// StopService stops a running service, it isn't running it is a no-op
+func (sm OpenRCManager) StopService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if !sm.IsRunning(ctx, config, app) {
+		log.WithContext(ctx).Infof("Service %s is not running", string(app))
+		return nil
+	}
+	if out, err := runPrivilegedCMD(config, "rc-service", sm.ServiceName(app), "stop"); err != nil {
+		return fmt.Errorf("unable to stop service (%v): %v: %s", app, err, out)
+	}
+	return nil
+}
+
+// EnableService adds the service to the default runlevel
+func (sm OpenRCManager) EnableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	log.WithContext(ctx).Info("Enabling service for auto-start")
+	if out, err := runPrivilegedCMD(config, "rc-update", "add", sm.ServiceName(app), "default"); err != nil {
+		return fmt.Errorf("err enabling %s - err: %v: %s", sm.ServiceName(app), err, out)
+	}
+	return nil
+}
+
+// DisableService removes the service from the default runlevel
+func (sm OpenRCManager) DisableService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	log.WithContext(ctx).Info("Disabling service", sm.ServiceName(app))
+	if out, err := runPrivilegedCMD(config, "rc-update", "del", sm.ServiceName(app), "default"); err != nil {
+		return fmt.Errorf("err disabling %s - err: %v: %s", sm.ServiceName(app), err, out)
+	}
+	return nil
+}
+
+// RemoveService removes init script of the service
+func (sm OpenRCManager) RemoveService(ctx context.Context, config *configs.Config, app constants.ToolType) error {
+	if !sm.IsRegistered(ctx, config, app) {
+		return nil
+	}
+	log.WithContext(ctx).Infof("Removing %s", sm.scriptPath(app))
+	if out, err := runPrivilegedCMD(config, "rm", "-f", sm.scriptPath(app)); err != nil {
+		return fmt.Errorf("unable to remove %s: %v: %s", sm.scriptPath(app), err, out)
+	}
+	return nil
+}
+
+// IsRunning checks to see if the service is running
+func (sm OpenRCManager) IsRunning(ctx context.Context, config *configs.Config, app constants.ToolType) bool {
+	if !sm.IsRegistered(ctx, config, app) {
+		return false
+	}
+	res, _ := runPrivilegedCMD(config, "rc-service", sm.ServiceName(app), "status")
+	log.WithContext(ctx).Infof("%v status: %v", sm.ServiceName(app), strings.TrimSpace(res))
+	return strings.Contains(res, "started") || strings.Contains(res, "starting")
+}
+
+// IsRegistered checks if the init script exists
+func (sm OpenRCManager) IsRegistered(_ context.Context, _ *configs.Config, app constants.ToolType) bool {
+	return utils.CheckFileExist(sm.scriptPath(app))
+}
+
+// ServiceName returns the init script name given a tooltype
+func (sm OpenRCManager) ServiceName(app constants.ToolType) string {
+	return getServiceBaseName(sm.instance, app)
 }
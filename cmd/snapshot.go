@@ -0,0 +1,391 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/gonode/common/version"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type nodeCommand uint8
+
+const (
+	nodeBootstrap nodeCommand = iota
+	nodeSnapshotCreate
+)
+
+var (
+	nodeCmdName = map[nodeCommand]string{
+		nodeBootstrap:      "bootstrap",
+		nodeSnapshotCreate: "create",
+	}
+	nodeCmdMessage = map[nodeCommand]string{
+		nodeBootstrap:      "Replace blockchain data with the snapshot and start pasteld",
+		nodeSnapshotCreate: "Create blockchain snapshot from the synced node",
+	}
+)
+
+var (
+	flagSnapshot         string
+	flagSnapshotChecksum string
+	flagSnapshotFile     string
+)
+
+// snapshotMetadata is stored in the snapshot archive as constants.SnapshotMetadataName
+type snapshotMetadata struct {
+	Network         string    `json:"network"`
+	Height          int       `json:"height,omitempty"`
+	Created         time.Time `json:"created"`
+	PastelupVersion string    `json:"pastelup_version"`
+}
+
+func setupNodeSubCommand(config *configs.Config,
+	nodeCmd nodeCommand,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+	commonFlags := []*cli.Flag{
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("force", &config.Force).SetAliases("f").
+			SetUsage(green("Optional, stop pasteld without confirmation")),
+	}
+
+	bootstrapFlags := []*cli.Flag{
+		cli.NewFlag("snapshot", &flagSnapshot).
+			SetUsage(red("Required, URL or path of the blockchain snapshot")).SetRequired(),
+		cli.NewFlag("checksum", &flagSnapshotChecksum).
+			SetUsage(green("Optional, sha256 checksum of the snapshot, if omitted, will be read from <snapshot>.sha256")),
+	}
+
+	createFlags := []*cli.Flag{
+		cli.NewFlag("file", &flagSnapshotFile).
+			SetUsage(green("Optional, path of the snapshot to create, if omitted, will be created in the archive dir")),
+		cli.NewFlag("archive-dir", &config.ArchiveDir).
+			SetUsage(green("Optional, Location where to store the snapshot")).SetValue(config.Configurer.DefaultArchiveDir()),
+	}
+
+	commandFlags := commonFlags
+	switch nodeCmd {
+	case nodeBootstrap:
+		commandFlags = append(commandFlags, bootstrapFlags...)
+	case nodeSnapshotCreate:
+		commandFlags = append(commandFlags, createFlags...)
+	}
+
+	commandName := nodeCmdName[nodeCmd]
+	commandMessage := nodeCmdMessage[nodeCmd]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, commandMessage, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = applyInstance(config); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		if err = ParsePastelConf(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Started")
+		if err = f(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return subCommand
+}
+
+func setupNodeCommand(config *configs.Config) *cli.Command {
+	snapshotCommand := cli.NewCommand("snapshot")
+	snapshotCommand.SetUsage(cyan("Perform blockchain snapshot related operations"))
+	snapshotCommand.AddSubcommands(setupNodeSubCommand(config, nodeSnapshotCreate, runNodeSnapshotCreate))
+
+	command := cli.NewCommand("node")
+	command.SetUsage(blue("Perform pastel node related operations"))
+	command.AddSubcommands(setupNodeSubCommand(config, nodeBootstrap, runNodeBootstrap))
+	command.AddSubcommands(snapshotCommand)
+
+	return command
+}
+
+func runNodeBootstrap(ctx context.Context, config *configs.Config) error {
+	dataDir := getMasternodeConfPath(config, config.WorkingDir, "")
+
+	if err := stopServicesWithConfirmation(ctx, config, []constants.ToolType{constants.PastelD}); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to stop pasteld")
+		return err
+	}
+
+	snapshotPath := flagSnapshot
+	isURL := strings.HasPrefix(flagSnapshot, "http://") || strings.HasPrefix(flagSnapshot, "https://")
+	if isURL {
+		// downloaded next to the data, so the interrupted download is resumed on the next run
+		snapshotPath = filepath.Join(config.WorkingDir, filepath.Base(strings.Split(flagSnapshot, "?")[0]))
+		if err := utils.DownloadFileResume(ctx, snapshotPath, flagSnapshot); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to download snapshot %s", flagSnapshot)
+			return err
+		}
+	}
+
+	if err := verifySnapshotChecksum(ctx, snapshotPath, flagSnapshot, isURL); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Snapshot verification failed")
+		return err
+	}
+
+	// extracted on the same filesystem, so the data is moved in place by renaming
+	stagingDir := filepath.Join(dataDir, fmt.Sprintf(".snapshot_%d", time.Now().Unix()))
+	defer os.RemoveAll(stagingDir)
+
+	log.WithContext(ctx).Infof("Extracting snapshot %s...", snapshotPath)
+	if err := utils.ExtractTarGz(snapshotPath, stagingDir); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to extract snapshot")
+		return err
+	}
+
+	metadata, err := readSnapshotMetadata(stagingDir)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Snapshot has no metadata, its network is not checked")
+	} else {
+		if metadata.Network != config.Network {
+			return errors.Errorf("snapshot is for %s network, but the node is on %s", metadata.Network, config.Network)
+		}
+		log.WithContext(ctx).Infof("Snapshot of %s network at block %d created on %s",
+			metadata.Network, metadata.Height, metadata.Created.Format(time.RFC3339))
+	}
+
+	if err = replaceChainData(ctx, stagingDir, dataDir); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to replace blockchain data")
+		return err
+	}
+	if isURL {
+		os.Remove(snapshotPath)
+	}
+	log.WithContext(ctx).Infof("Blockchain data in %s replaced with the snapshot", dataDir)
+
+	if err = runPastelNode(ctx, config, false, false, "", ""); err != nil {
+		log.WithContext(ctx).WithError(err).Error("pasteld failed to start")
+		return err
+	}
+	return nil
+}
+
+// verifySnapshotChecksum checks the snapshot against --checksum or the checksum published next to it
+func verifySnapshotChecksum(ctx context.Context, snapshotPath string, source string, isURL bool) error {
+	expected := flagSnapshotChecksum
+	if len(expected) == 0 {
+		var data []byte
+		var err error
+		if isURL {
+			data, err = utils.GetURL(source+".sha256", releaseIndexTimeout)
+		} else {
+			data, err = ioutil.ReadFile(source + ".sha256")
+		}
+		if err != nil {
+			return errors.Errorf("failed to get checksum %s.sha256, use --checksum to set it: %v", source, err)
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			return errors.Errorf("checksum %s.sha256 is empty", source)
+		}
+		expected = fields[0]
+	}
+
+	log.WithContext(ctx).Info("Verifying snapshot checksum...")
+	checksum, err := utils.GetChecksum(ctx, snapshotPath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(checksum, expected) {
+		return errors.Errorf("checksum mismatch of %s: expected %s, got %s", snapshotPath, expected, checksum)
+	}
+	return nil
+}
+
+func readSnapshotMetadata(dir string) (*snapshotMetadata, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, constants.SnapshotMetadataName))
+	if err != nil {
+		return nil, err
+	}
+	var metadata snapshotMetadata
+	if err = json.Unmarshal(data, &metadata); err != nil {
+		return nil, errors.Errorf("invalid %s: %v", constants.SnapshotMetadataName, err)
+	}
+	return &metadata, nil
+}
+
+// replaceChainData moves only the chain dirs extracted from the snapshot into the data dir, anything else
+// in the snapshot is ignored, so wallet, keys and configs of the node can't be replaced by it;
+// the old chain dirs are moved aside into the staging dir and are restored if the replacement fails
+func replaceChainData(ctx context.Context, stagingDir string, dataDir string) error {
+	entries, err := ioutil.ReadDir(stagingDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if name != constants.SnapshotMetadataName && !utils.Contains(constants.SnapshotChainDirs, name) {
+			log.WithContext(ctx).Warnf("Snapshot contains %s, which is not blockchain data, it is ignored", name)
+		}
+	}
+	for _, name := range constants.SnapshotChainDirs {
+		if info, err := os.Stat(filepath.Join(stagingDir, name)); err != nil || !info.IsDir() {
+			return errors.Errorf("snapshot has no %s directory", name)
+		}
+	}
+
+	// moved are the chain dirs already replaced, their old data is in <staging dir>/<name>.old
+	var moved []string
+	rollback := func() {
+		for _, name := range moved {
+			target := filepath.Join(dataDir, name)
+			old := filepath.Join(stagingDir, name+".old")
+			if err := os.RemoveAll(target); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("Failed to remove %s", target)
+				continue
+			}
+			if utils.CheckFileExist(old) {
+				if err := os.Rename(old, target); err != nil {
+					log.WithContext(ctx).WithError(err).Errorf("Failed to restore %s from %s", target, old)
+				}
+			}
+		}
+	}
+	for _, name := range constants.SnapshotChainDirs {
+		target := filepath.Join(dataDir, name)
+		old := filepath.Join(stagingDir, name+".old")
+		if utils.CheckFileExist(target) {
+			if err := os.Rename(target, old); err != nil {
+				rollback()
+				return errors.Errorf("failed to move %s aside: %v", target, err)
+			}
+		}
+		moved = append(moved, name)
+		if err := os.Rename(filepath.Join(stagingDir, name), target); err != nil {
+			rollback()
+			return errors.Errorf("failed to move %s to %s: %v", name, dataDir, err)
+		}
+	}
+	return nil
+}
+
+func runNodeSnapshotCreate(ctx context.Context, config *configs.Config) error {
+	dataDir := getMasternodeConfPath(config, config.WorkingDir, "")
+	for _, dir := range constants.SnapshotChainDirs {
+		if !utils.CheckFileExist(filepath.Join(dataDir, dir)) {
+			return errors.Errorf("%s not found in %s, is the node synced?", dir, dataDir)
+		}
+	}
+
+	metadata := snapshotMetadata{
+		Network:         config.Network,
+		Created:         time.Now().UTC(),
+		PastelupVersion: version.Version(),
+	}
+	// data must not change while it's archived
+	wasRunning := false
+	if info, err := GetPastelInfo(ctx, config); err == nil {
+		wasRunning = true
+		metadata.Height = info.Result.Blocks
+	} else {
+		log.WithContext(ctx).Warn("pasteld is not running, the height of the snapshot is unknown")
+	}
+	if err := stopServicesWithConfirmation(ctx, config, []constants.ToolType{constants.PastelD}); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to stop pasteld")
+		return err
+	}
+
+	snapshotPath := flagSnapshotFile
+	if len(snapshotPath) == 0 {
+		name := fmt.Sprintf("pastel-%s-%s.tar.gz", config.Network, metadata.Created.Format("20060102"))
+		if metadata.Height > 0 {
+			name = fmt.Sprintf("pastel-%s-%d-%s.tar.gz", config.Network, metadata.Height, metadata.Created.Format("20060102"))
+		}
+		snapshotPath = filepath.Join(config.ArchiveDir, name)
+	}
+	if err := os.MkdirAll(filepath.Dir(snapshotPath), 0755); err != nil {
+		return err
+	}
+
+	err := createSnapshot(ctx, dataDir, snapshotPath, metadata)
+	if wasRunning {
+		restartPastelD(ctx, config)
+	}
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to create snapshot")
+		return err
+	}
+	log.WithContext(ctx).Infof("Snapshot %s and its checksum %s.sha256 created", snapshotPath, snapshotPath)
+	return nil
+}
+
+func createSnapshot(ctx context.Context, dataDir string, snapshotPath string, metadata snapshotMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	metadataPath := filepath.Join(dataDir, constants.SnapshotMetadataName)
+	if err = ioutil.WriteFile(metadataPath, data, 0644); err != nil {
+		return err
+	}
+	defer os.Remove(metadataPath)
+
+	log.WithContext(ctx).Infof("Archiving %s of %s...", strings.Join(constants.SnapshotChainDirs, ", "), dataDir)
+	paths := append([]string{constants.SnapshotMetadataName}, constants.SnapshotChainDirs...)
+	if err = utils.CreateTarGz(snapshotPath, dataDir, paths); err != nil {
+		os.Remove(snapshotPath)
+		return err
+	}
+
+	checksum, err := utils.GetChecksum(ctx, snapshotPath)
+	if err != nil {
+		return err
+	}
+	// the format of sha256sum, so the snapshot can be checked with `sha256sum -c`
+	return ioutil.WriteFile(snapshotPath+".sha256", []byte(fmt.Sprintf("%s  %s\n", checksum, filepath.Base(snapshotPath))), 0644)
+}
+
+// restartPastelD starts pasteld stopped for the snapshot, only the registered service can be restarted
+// with the same parameters, otherwise the user has to start it
+func restartPastelD(ctx context.Context, config *configs.Config) {
+	if sm, err := NewServiceManager(config); err == nil {
+		if started, err := sm.StartService(ctx, config, constants.PastelD); err == nil && started {
+			log.WithContext(ctx).Info("pasteld service restarted")
+			if WaitingForPastelDToStart(ctx, config) {
+				relockCollateralsAfterStart(ctx, config)
+			}
+			return
+		}
+	}
+	log.WithContext(ctx).Warn(red("pasteld was stopped to create the snapshot, start it again with 'pastelup start node|supernode'"))
+}
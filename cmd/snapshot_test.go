@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/tj/assert"
+)
+
+func TestReplaceChainData(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	stagingDir := filepath.Join(dataDir, ".snapshot")
+
+	writeFile := func(path string, data string) {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(data), 0644))
+	}
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		assert.Nil(t, err)
+		return string(data)
+	}
+
+	writeFile(filepath.Join(dataDir, "blocks", "blk00000.dat"), "old blocks")
+	writeFile(filepath.Join(dataDir, "chainstate", "CURRENT"), "old chainstate")
+	writeFile(filepath.Join(dataDir, "pastelkeys", "jXY"), "pastel id")
+	writeFile(filepath.Join(dataDir, "wallet.dat"), "wallet")
+
+	// snapshot without chainstate is refused, data is not touched
+	writeFile(filepath.Join(stagingDir, "blocks", "blk00000.dat"), "new blocks")
+	assert.NotNil(t, replaceChainData(ctx, stagingDir, dataDir))
+	assert.Equal(t, "old blocks", readFile(filepath.Join(dataDir, "blocks", "blk00000.dat")))
+
+	writeFile(filepath.Join(stagingDir, "chainstate", "CURRENT"), "new chainstate")
+	writeFile(filepath.Join(stagingDir, "pastelkeys", "jXY"), "other pastel id")
+	writeFile(filepath.Join(stagingDir, "wallet.dat"), "other wallet")
+	writeFile(filepath.Join(stagingDir, constants.SnapshotMetadataName), "{}")
+
+	assert.Nil(t, replaceChainData(ctx, stagingDir, dataDir))
+	assert.Equal(t, "new blocks", readFile(filepath.Join(dataDir, "blocks", "blk00000.dat")))
+	assert.Equal(t, "new chainstate", readFile(filepath.Join(dataDir, "chainstate", "CURRENT")))
+	// everything else of the snapshot is ignored
+	assert.Equal(t, "pastel id", readFile(filepath.Join(dataDir, "pastelkeys", "jXY")))
+	assert.Equal(t, "wallet", readFile(filepath.Join(dataDir, "wallet.dat")))
+	// old chain data is kept in the staging dir until it is removed
+	assert.Equal(t, "old blocks", readFile(filepath.Join(stagingDir, "blocks.old", "blk00000.dat")))
+}
@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -119,6 +118,8 @@ func setupStartSubCommand(config *configs.Config,
 			SetUsage(red("name of the Masternode to start")),
 		cli.NewFlag("activate", &flagMasterNodeIsActivate).
 			SetUsage(green("Optional, if specified, will try to enable node as Masternode (start-alias).")),
+		cli.NewFlag("sync-timeout", &config.SyncTimeout).
+			SetUsage(green("Optional, how long to wait for the node to sync, e.g. 6h, fails if it is not synced by then (default: wait until synced)")),
 	}
 
 	masternodeFlags := []*cli.Flag{
@@ -135,9 +136,8 @@ func setupStartSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, SSH user")),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key")),
-		cli.NewFlag("inventory", &config.InventoryFile).
-			SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
 	}
+	remoteStartFlags = append(remoteStartFlags, getInventoryFlags(config)...)
 
 	var commandName, commandMessage string
 	if !remote {
@@ -164,12 +164,18 @@ func setupStartSubCommand(config *configs.Config,
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
 	if f != nil {
 		subCommand.SetActionFunc(func(ctx context.Context, args []string) error {
 			ctx, err := configureLogging(ctx, commandMessage, config)
 			if err != nil {
 				return err
 			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
 
 			// Register interrupt handler
 			ctx, cancel := context.WithCancel(ctx)
@@ -209,13 +215,13 @@ func setupStartCommand(config *configs.Config) *cli.Command {
 	startWalletNodeSubCommand := setupStartSubCommand(config, walletStart, false, runStartWalletNodeSubCommand)
 	startSuperNodeSubCommand := setupStartSubCommand(config, superNodeStart, false, runStartSuperNodeSubCommand)
 
-	startRQServiceCommand := setupStartSubCommand(config, rqService, false, runRQService)
-	startDDServiceCommand := setupStartSubCommand(config, ddService, false, runDDService)
-	startWNServiceCommand := setupStartSubCommand(config, wnService, false, runWalletNodeService)
-	startSNServiceCommand := setupStartSubCommand(config, snService, false, runSuperNodeService)
+	startRQServiceCommand := setupStartSubCommand(config, rqService, false, startComponent(constants.RQService, runRQService))
+	startDDServiceCommand := setupStartSubCommand(config, ddService, false, startComponent(constants.DDService, runDDService))
+	startWNServiceCommand := setupStartSubCommand(config, wnService, false, startComponent(constants.WalletNode, runWalletNodeService))
+	startSNServiceCommand := setupStartSubCommand(config, snService, false, startComponent(constants.SuperNode, runSuperNodeService))
 	startMasternodeCommand := setupStartSubCommand(config, masterNode, false, runStartMasternode)
-	startHermesServiceCommand := setupStartSubCommand(config, hermesService, false, runHermesService)
-	startBridgeServiceCommand := setupStartSubCommand(config, bridgeService, false, runBridgeService)
+	startHermesServiceCommand := setupStartSubCommand(config, hermesService, false, startComponent(constants.Hermes, runHermesService))
+	startBridgeServiceCommand := setupStartSubCommand(config, bridgeService, false, startComponent(constants.Bridge, runBridgeService))
 	startDDImgServerCommand := setupStartSubCommand(config, ddImgServer, false, runDDImgServer)
 
 	startSuperNodeRemoteSubCommand := setupStartSubCommand(config, superNodeStart, true, runRemoteSuperNodeStartSubCommand)
@@ -282,36 +288,26 @@ func runStartNodeSubCommand(ctx context.Context, config *configs.Config) error {
 
 // Sub Command
 func runStartWalletNodeSubCommand(ctx context.Context, config *configs.Config) error {
-	// *************  1. Start pastel node  *************
-	if err := runPastelNode(ctx, config, config.TxIndex == 1, config.ReIndex, flagNodeExtIP, ""); err != nil {
-		log.WithContext(ctx).WithError(err).Error("pasteld failed to start")
-		return err
+	steps := []componentStep{
+		{constants.PastelD, func(ctx context.Context, config *configs.Config) error {
+			return runPastelNode(ctx, config, config.TxIndex == 1, config.ReIndex, flagNodeExtIP, "")
+		}},
+		{constants.RQService, runRQService},
 	}
 
-	// *************  2. Start rq-servce    *************
-	if err := runRQService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("rqservice failed to start")
-		return err
-	}
-
-	// *************  3. Check & Start bridge node  *************
 	walletConf := config.Configurer.GetWalletNodeConfFile(config.WorkingDir)
 	enable, err := checkBridgeEnabled(ctx, walletConf)
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Error("unable to check bridge enabled, skipping start bridge service")
 	} else if enable {
-		if err := runBridgeService(ctx, config); err != nil {
-			log.WithContext(ctx).WithError(err).Error("bridge failed to start")
-			return err
-		}
+		steps = append(steps, componentStep{constants.Bridge, runBridgeService})
 	}
+	steps = append(steps, componentStep{constants.WalletNode, runWalletNodeService})
 
-	// *************  4. Start wallet node  *************
-	if err := runWalletNodeService(ctx, config); err != nil {
+	if err := startComponents(ctx, config, steps); err != nil {
 		log.WithContext(ctx).WithError(err).Error("walletnode failed to start")
 		return err
 	}
-
 	return nil
 }
 
@@ -345,7 +341,7 @@ func runStartSuperNode(ctx context.Context, config *configs.Config) error {
 	log.WithContext(ctx).Info("Finished checking arguments!")
 
 	pastelDIsRunning := false
-	if CheckProcessRunning(constants.PastelD) {
+	if CheckProcessRunning(config, constants.PastelD) {
 		log.WithContext(ctx).Infof("pasteld is already running")
 		if yes, _ := AskUserToContinue(ctx,
 			"Do you want to stop it and continue? Y/N"); !yes {
@@ -378,18 +374,34 @@ func runStartSuperNode(ctx context.Context, config *configs.Config) error {
 		}
 	}
 
-	// *************  3. Start Node as Masternode  *************
+	// *************  3. Start components  *************
+	// pasteld is started as masternode and synced before supernode, rq-service and dd-service start along with it
+	steps := []componentStep{
+		{constants.PastelD, runStartSyncedMasternode},
+		{constants.RQService, runRQService},
+		{constants.DDService, runDDService},
+		{constants.SuperNode, runSuperNodeOnly},
+		{constants.Hermes, runHermesService},
+	}
+	if err := startComponents(ctx, config, steps); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to start supernode")
+		return err
+	}
+
+	return nil
+}
+
+// runStartSyncedMasternode starts pasteld as masternode, waits for blockchain and masternodes sync and enables masternode
+func runStartSyncedMasternode(ctx context.Context, config *configs.Config) error {
 	if err := runStartMasternode(ctx, config); err != nil { //in masternode mode pasteld MUST be started with reindex flag
 		return err
 	}
 
-	// *************  4. Wait for blockchain and masternodes sync  *************
 	if _, err := CheckMasterNodeSync(ctx, config); err != nil {
 		log.WithContext(ctx).WithError(err).Error("pasteld failed to synchronize, add some peers and try again")
 		return err
 	}
 
-	// *************  5. Enable Masternode  ***************
 	if flagMasterNodeIsActivate {
 		log.WithContext(ctx).Infof("Starting MN alias - %s", flagMasterNodeName)
 		if err := runStartAliasMasternode(ctx, config, flagMasterNodeName); err != nil {
@@ -397,31 +409,6 @@ func runStartSuperNode(ctx context.Context, config *configs.Config) error {
 			return err
 		}
 	}
-
-	// *************  6. Start rq-service    *************
-	if err := runRQService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("rqservice failed to start")
-		return err
-	}
-
-	// *************  7. Start dd-service    *************
-	if err := runDDService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("ddservice failed to start")
-		return err
-	}
-
-	// *************  8. Start supernode  **************
-	if err := runSuperNodeService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to start supernode service")
-		return err
-	}
-
-	// *************  9. Start hermes  **************
-	if err := runHermesService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("Failed to start hermes service")
-		return err
-	}
-
 	return nil
 }
 
@@ -459,7 +446,19 @@ func runRemoteDDImgServerSubCommand(ctx context.Context, config *configs.Config)
 func runRemoteStart(ctx context.Context, config *configs.Config, tool string) error {
 	log.WithContext(ctx).Infof("Starting remote %s", tool)
 
-	// Start remote node
+	getStartCommand := func() (string, error) {
+		return getRemoteStartCommand(config, tool), nil
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getStartCommand, false); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to start %s on remote host", tool)
+	}
+
+	log.WithContext(ctx).Infof("Remote %s started successfully", tool)
+	return nil
+}
+
+// getRemoteStartCommand returns command line of the remote pastelup to start the tool
+func getRemoteStartCommand(config *configs.Config, tool string) string {
 	startOptions := tool
 
 	if len(flagMasterNodeName) > 0 {
@@ -476,6 +475,9 @@ func runRemoteStart(ctx context.Context, config *configs.Config, tool string) er
 	if config.ReIndex {
 		startOptions = fmt.Sprintf("%s --reindex", startOptions)
 	}
+	if config.SyncTimeout > 0 {
+		startOptions = fmt.Sprintf("%s --sync-timeout=%v", startOptions, config.SyncTimeout)
+	}
 	if config.Legacy {
 		startOptions = fmt.Sprintf("%s --legacy", startOptions)
 	}
@@ -489,13 +491,7 @@ func runRemoteStart(ctx context.Context, config *configs.Config, tool string) er
 		startOptions = fmt.Sprintf("%s --work-dir=%s", startOptions, config.WorkingDir)
 	}
 
-	startSuperNodeCmd := fmt.Sprintf("%s start %s", constants.RemotePastelupPath, startOptions)
-	if err := executeRemoteCommandsWithInventory(ctx, config, []string{startSuperNodeCmd}, false); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to start %s on remote host", tool)
-	}
-
-	log.WithContext(ctx).Infof("Remote %s started successfully", tool)
-	return nil
+	return fmt.Sprintf("%s start %s", constants.RemotePastelupPath, startOptions)
 }
 
 func runStartMasternode(ctx context.Context, config *configs.Config) error {
@@ -542,7 +538,7 @@ func runStartMasternode(ctx context.Context, config *configs.Config) error {
 // Sub Command
 func runRQService(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -573,7 +569,7 @@ func runRQService(ctx context.Context, config *configs.Config) error {
 // Sub Command
 func runDDService(ctx context.Context, config *configs.Config) (err error) {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -597,35 +593,20 @@ func runDDService(ctx context.Context, config *configs.Config) (err error) {
 		return err
 	}
 
-	ddConfigFilePath := filepath.Join(config.Configurer.DefaultHomeDir(),
-		constants.DupeDetectionServiceDir,
-		constants.DupeDetectionSupportFilePath,
-		constants.DupeDetectionConfigFilename)
+	ddConfigFilePath := getDDConfigFilePath(config)
 
 	python := "python3"
 	if utils.GetOS() == constants.Windows {
 		python = "python"
 	}
-	venv := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, "venv")
+	venv := getDDVenvDir(config)
 	cmd := fmt.Sprintf("source %v/bin/activate && %v %v %v", venv, python, execPath, ddConfigFilePath)
 	go RunCMD("bash", "-c", cmd)
-
-	time.Sleep(10 * time.Second)
-
-	if output, err := FindRunningProcess(constants.DupeDetectionExecFileName); len(output) == 0 {
-		err = errors.Errorf("dd-service failed to start")
-		log.WithContext(ctx).WithError(err).Error("dd-service failed to start")
-		return err
-	} else if err != nil {
-		log.WithContext(ctx).WithError(err).Error("failed to test if dd-service is running")
-	} else {
-		log.WithContext(ctx).Info("dd-service is successfully started")
-	}
 	return nil
 }
 
 func runDDImgServer(ctx context.Context, config *configs.Config) (err error) {
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Error(err.Error())
 		return err
@@ -644,7 +625,7 @@ func runDDImgServer(ctx context.Context, config *configs.Config) (err error) {
 // Sub Command
 func runWalletNodeService(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -688,7 +669,7 @@ func runBridgeService(ctx context.Context, config *configs.Config) error {
 	}
 
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -725,8 +706,17 @@ func runBridgeService(ctx context.Context, config *configs.Config) error {
 
 // Sub Command
 func runSuperNodeService(ctx context.Context, config *configs.Config) error {
+	if err := runSuperNodeOnly(ctx, config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// runSuperNodeOnly starts supernode without hermes
+func runSuperNodeOnly(ctx context.Context, config *configs.Config) error {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -756,11 +746,6 @@ func runSuperNodeService(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	if err := runHermesService(ctx, config); err != nil {
-		log.WithContext(ctx).WithError(err).Error("start hermes service failed")
-		return err
-	}
-
 	return nil
 }
 
@@ -772,7 +757,7 @@ func runHermesService(ctx context.Context, config *configs.Config) error {
 	}
 
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -862,7 +847,7 @@ func setPastelIDAndPassphraseInHermesConf(ctx context.Context, config *configs.C
 ///// Run helpers
 func runPastelNode(ctx context.Context, config *configs.Config, txIndexOne bool, reindex bool, extIP string, mnPrivKey string) (err error) {
 	serviceEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warn(err.Error())
 	} else {
@@ -928,6 +913,7 @@ func runPastelNode(ctx context.Context, config *configs.Config, txIndexOne bool,
 		log.WithContext(ctx).WithError(err).Error("pasteld didn't start")
 		return err
 	}
+	relockCollateralsAfterStart(ctx, config)
 	return nil
 }
 
@@ -941,20 +927,12 @@ func runPastelService(ctx context.Context, config *configs.Config, toolType cons
 		return err
 	}
 
-	go RunCMD(execPath, args...)
-	time.Sleep(10 * time.Second)
-
-	log.WithContext(ctx).Infof("Check %s is running...", toolType)
-	isServiceRunning := CheckProcessRunning(toolType)
-	if isServiceRunning {
-		log.WithContext(ctx).Infof("The %s started succesfully!", toolType)
-	} else {
+	// readiness is awaited by the orchestrator, the output is reported if the process fails
+	go func() {
 		if output, err := RunCMD(execPath, args...); err != nil {
 			log.WithContext(ctx).Errorf("%s start failed! : %s", toolType, output)
-			return err
 		}
-	}
-
+	}()
 	return nil
 }
 
@@ -1244,17 +1222,10 @@ func checkCollateral(ctx context.Context, config *configs.Config) error {
 
 	if len(flagMasterNodeTxID) == 0 || len(flagMasterNodeInd) == 0 {
 
-		collateralAmount := "5"
-		collateralCoins := "PSL"
-		if config.Network == constants.NetworkTestnet {
-			collateralAmount = "1"
-			collateralCoins = "LSP"
-		} else if config.Network == constants.NetworkRegTest {
-			collateralAmount = "0.1"
-			collateralCoins = "REG"
-		}
+		collateralAmount := formatCollateralAmount(constants.MasternodeCollateralAmount[config.Network])
+		collateralCoins := constants.CoinName[config.Network]
 
-		yes, _ := AskUserToContinue(ctx, fmt.Sprintf("Do you want to generate new local address and send %sM %s to it from another wallet? Y/N",
+		yes, _ := AskUserToContinue(ctx, fmt.Sprintf("Do you want to generate new local address and send %s %s to it from another wallet? Y/N",
 			collateralAmount, collateralCoins))
 
 		if !yes {
@@ -1262,52 +1233,26 @@ func checkCollateral(ctx context.Context, config *configs.Config) error {
 			log.WithContext(ctx).WithError(err).Error("No collateral funds - exiting")
 			return err
 		}
-		var address string
-		err := pastelcore.NewClient(config).RunCommand(pastelcore.GetNewAddressCmd, &address)
+		address, err := getNewAddress(ctx, config)
 		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("Failed to get new address")
 			return err
 		}
-		address = strings.Trim(address, "\n")
 		log.WithContext(ctx).Warnf(red(fmt.Sprintf("Your new address for collateral payment is %s", address)))
-		log.WithContext(ctx).Warnf(red(fmt.Sprintf("Use another wallet to send exactly %sM %s to that address.", collateralAmount, collateralCoins)))
+		log.WithContext(ctx).Warnf(red(fmt.Sprintf("Use another wallet to send exactly %s %s to that address.", collateralAmount, collateralCoins)))
 		_, newTxid := AskUserToContinue(ctx, "Enter txid of the send and press Enter to continue when ready")
 		flagMasterNodeTxID = strings.Trim(newTxid, "\n")
 	}
 
-	for i := 1; i <= 10; i++ {
-
-		var mnOutputs map[string]interface{}
-		mnOutputs, err = getMasternodeOutputs(ctx, config)
-		if err != nil {
-			log.WithContext(ctx).WithError(err).Error("Failed")
-			return err
-		}
-
-		txind, ok := mnOutputs[flagMasterNodeTxID].(string)
-		if ok {
-			flagMasterNodeInd = txind
-			break
-		}
-
-		log.WithContext(ctx).Info("Waiting for transaction...")
-		time.Sleep(10 * time.Second)
-		if i == 10 {
-			yes, _ := AskUserToContinue(ctx, "Still no collateral transaction. Continue? - Y/N")
-			if !yes {
-				err := fmt.Errorf("user terminated")
-				log.WithContext(ctx).WithError(err).Error("Exiting")
-				return err
-			}
-			i = 1
-		}
+	vout, err := waitForCollateral(ctx, config, flagMasterNodeTxID, flagMasterNodeInd,
+		constants.CollateralDefaultConfirmations, constants.CollateralDefaultTimeout)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Cannot find masternode outputs = %s:%s", flagMasterNodeTxID, flagMasterNodeInd)
+		return err
 	}
+	flagMasterNodeInd = strconv.Itoa(vout)
 
-	if len(flagMasterNodeTxID) == 0 || len(flagMasterNodeInd) == 0 {
-
-		err := errors.Errorf("Cannot find masternode outputs = %s:%s", flagMasterNodeTxID, flagMasterNodeInd)
-		log.WithContext(ctx).WithError(err).Error("Try again after some time")
-		return err
+	if err := lockCollateral(ctx, config, flagMasterNodeTxID, vout); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Collateral output is not locked, make sure not to spend it")
 	}
 
 	// if receives PSL go to next step
@@ -1349,12 +1294,13 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 		}
 
 		portList := GetSNPortList(config)
+		ports := getComponentPorts(config)
 
 		snTempDirPath := filepath.Join(config.WorkingDir, constants.TempDir)
 		rqWorkDirPath := filepath.Join(config.WorkingDir, constants.RQServiceDir)
 		p2pDataPath := filepath.Join(config.WorkingDir, constants.P2PDataDir)
 		mdlDataPath := filepath.Join(config.WorkingDir, constants.MDLDataDir)
-		ddDirPath := filepath.Join(config.Configurer.DefaultHomeDir(), constants.DupeDetectionServiceDir)
+		ddDirPath := getDDServiceDir(config)
 
 		toolConfig, err := utils.GetServiceConfig(string(constants.SuperNode), configs.SupernodeDefaultConfig, &configs.SuperNodeConfig{
 			LogFilePath:                     config.Configurer.GetSuperNodeLogFile(config.WorkingDir),
@@ -1376,8 +1322,8 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			MDLPort:                         portList[constants.MDLPort],
 			RAFTPort:                        portList[constants.RAFTPort],
 			MDLDataDir:                      mdlDataPath,
-			RaptorqPort:                     constants.RQServiceDefaultPort,
-			DDServerPort:                    constants.DDServerDefaultPort,
+			RaptorqPort:                     ports[constants.PortNameRQService],
+			DDServerPort:                    ports[constants.PortNameDDServer],
 			NumberOfChallengeReplicas:       constants.NumberOfChallengeReplicas,
 			StorageChallengeExpiredDuration: constants.StorageChallengeExpiredDuration,
 		})
@@ -1385,6 +1331,7 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			log.WithContext(ctx).WithError(err).Error("Failed to get supernode config")
 			return err
 		}
+		toolConfig = setConfigVersion(toolConfig, configs.ConfigVersions[string(constants.SuperNode)])
 		if err = utils.WriteFile(supernodeConfigPath, toolConfig); err != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update new supernode.yml file at - %s", supernodeConfigPath)
 			return err
@@ -1416,6 +1363,9 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 			log.WithContext(ctx).WithError(err).Errorf("Failed to unparse yml for supernode.yml file at - %s", supernodeConfigPath)
 			return err
 		}
+		if version, ok := getConfigVersion(string(snConfFile)); ok {
+			snConfFileUpdated = []byte(setConfigVersion(string(snConfFileUpdated), version))
+		}
 		if ioutil.WriteFile(supernodeConfigPath, snConfFileUpdated, 0644) != nil {
 			log.WithContext(ctx).WithError(err).Errorf("Failed to update supernode.yml file at - %s", supernodeConfigPath)
 			return err
@@ -1424,6 +1374,9 @@ func createOrUpdateSuperNodeConfig(ctx context.Context, config *configs.Config)
 		log.WithContext(ctx).WithError(err).Errorf("Failed to update or create supernode.yml file at - %s", supernodeConfigPath)
 		return err
 	}
+	if err := applyConfigOverridesToFile(ctx, config, string(constants.SuperNode), supernodeConfigPath); err != nil {
+		return err
+	}
 	log.WithContext(ctx).Info("Supernode config updated")
 	return nil
 }
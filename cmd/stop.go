@@ -98,9 +98,8 @@ func setupStopSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, Username of user at remote host")),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
-		cli.NewFlag("inventory", &config.InventoryFile).
-			SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
 	}
+	remoteStopFlags = append(remoteStopFlags, getInventoryFlags(config)...)
 
 	var commandName, commandMessage string
 	if !remote {
@@ -117,6 +116,9 @@ func setupStopSubCommand(config *configs.Config,
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
 
 	if f != nil {
 		subCommand.SetActionFunc(func(ctx context.Context, args []string) error {
@@ -124,6 +126,9 @@ func setupStopSubCommand(config *configs.Config,
 			if err != nil {
 				return err
 			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
 
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
@@ -201,7 +206,7 @@ func runStopWalletSubCommand(ctx context.Context, config *configs.Config) {
 		constants.PastelD,
 		constants.Bridge,
 	}
-	_ = stopServices(ctx, servicesToStop, config)
+	_ = stopComponents(ctx, config, servicesToStop)
 	log.WithContext(ctx).Info("Walletnode stopped successfully")
 }
 
@@ -214,7 +219,7 @@ func runStopSuperNodeSubCommand(ctx context.Context, config *configs.Config) {
 		constants.PastelD,
 		constants.Hermes,
 	}
-	_ = stopServices(ctx, servicesToStop, config)
+	_ = stopComponents(ctx, config, servicesToStop)
 	log.WithContext(ctx).Info("Suppernode stopped successfully")
 }
 
@@ -256,6 +261,18 @@ func runStopBridgeServiceRemoteSubCommand(ctx context.Context, config *configs.C
 func runRemoteStop(ctx context.Context, config *configs.Config, tool string) {
 	log.WithContext(ctx).Infof("Stopping remote %s", tool)
 
+	getStopCommand := func() (string, error) {
+		return getRemoteStopCommand(config, tool), nil
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getStopCommand, false); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to stop %s on remote host", tool)
+	}
+
+	log.WithContext(ctx).Infof("Remote %s stopped successfully", tool)
+}
+
+// getRemoteStopCommand returns command line of the remote pastelup to stop the tool
+func getRemoteStopCommand(config *configs.Config, tool string) string {
 	stopOptions := tool
 	if len(config.PastelExecDir) > 0 {
 		stopOptions = fmt.Sprintf("%s --dir %s", stopOptions, config.PastelExecDir)
@@ -264,12 +281,7 @@ func runRemoteStop(ctx context.Context, config *configs.Config, tool string) {
 		stopOptions = fmt.Sprintf("%s --work-dir %s", stopOptions, config.WorkingDir)
 	}
 
-	stopSuperNodeCmd := fmt.Sprintf("%s stop %s", constants.RemotePastelupPath, stopOptions)
-	if err := executeRemoteCommandsWithInventory(ctx, config, []string{stopSuperNodeCmd}, false); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to stop %s on remote host", tool)
-	}
-
-	log.WithContext(ctx).Infof("Remote %s stopped successfully", tool)
+	return fmt.Sprintf("%s stop %s", constants.RemotePastelupPath, stopOptions)
 }
 
 func runStopAllSubCommand(ctx context.Context, config *configs.Config) {
@@ -283,7 +295,7 @@ func runStopAllSubCommand(ctx context.Context, config *configs.Config) {
 		constants.Hermes,
 		constants.Bridge,
 	}
-	_ = stopServices(ctx, servicesToStop, config)
+	_ = stopComponents(ctx, config, servicesToStop)
 	log.WithContext(ctx).Info("All stopped successfully")
 }
 
@@ -326,7 +338,7 @@ func stopPatelCLI(ctx context.Context, config *configs.Config) error {
 	if err != nil {
 		log.WithContext(ctx).WithError(err).Errorf("Failed to stop Pasteld")
 	}
-	if CheckProcessRunning(constants.PastelD) {
+	if CheckProcessRunning(config, constants.PastelD) {
 		log.WithContext(ctx).Warn("Failed to stop Pasteld")
 		return errors.Errorf("Failed to stop Pasteld")
 	}
@@ -345,7 +357,7 @@ func stopServicesWithConfirmation(ctx context.Context, config *configs.Config, s
 			}
 			continue
 		}
-		pid, err := GetRunningProcessPid(service)
+		pid, err := GetRunningProcessPid(config, service)
 		if err != nil {
 			log.WithContext(ctx).Error(fmt.Sprintf("Failed validating if '%v' service is running: %v", service, err))
 			return err
@@ -359,18 +371,18 @@ func stopServicesWithConfirmation(ctx context.Context, config *configs.Config, s
 		return nil
 	}
 	if !config.Force {
-		question := fmt.Sprintf("To perform this update, we need to kill these services: %v. Is this ok? Y/N", servicesToStop)
+		question := fmt.Sprintf("To continue, we need to kill these services: %v. Is this ok? Y/N", servicesToStop)
 		ok, _ := AskUserToContinue(ctx, question)
 		if !ok {
 			return fmt.Errorf("user did not accept confirmation to stop services")
 		}
 	}
-	return stopServices(ctx, servicesToStop, config)
+	return stopComponents(ctx, config, servicesToStop)
 }
 
 func stopServices(ctx context.Context, services []constants.ToolType, config *configs.Config) error {
 	servicesEnabled := false
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		log.WithContext(ctx).Warnf("services not enabled for your OS %v", utils.GetOS())
 	} else {
@@ -402,12 +414,16 @@ func stopServices(ctx context.Context, services []constants.ToolType, config *co
 				return err
 			}
 		case constants.DDService:
-			searchTerm := constants.DupeDetectionExecFileName
+			searchTerm := getDDConfigFilePath(config)
 			pid, err := FindRunningProcessPid(ctx, searchTerm)
 			if err != nil {
 				log.WithContext(ctx).Errorf("unable to find service %v to stop it: %v", service, err)
 				return err
 			}
+			if pid == 0 {
+				log.WithContext(ctx).Infof("Application %s is not running", service)
+				continue
+			}
 			log.WithContext(ctx).Infof("Killing process: %v\n", pid)
 			err = KillProcessByPid(ctx, pid)
 			if err != nil {
@@ -420,7 +436,7 @@ func stopServices(ctx context.Context, services []constants.ToolType, config *co
 			if ok {
 				process = constants.ToolType(override)
 			}
-			err := KillProcess(ctx, process) // kill process in case the service wasn't registered
+			err := KillProcess(ctx, config, process) // kill process in case the service wasn't registered
 			if err != nil {
 				log.WithContext(ctx).Error(fmt.Sprintf("unable to kill process %v: %v", service, err))
 				return err
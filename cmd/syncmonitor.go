@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/services/pastelcore"
+	"github.com/pastelnetwork/pastelup/structure"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	syncProbeInterval = 10 * time.Second
+	// how often the progress line is logged when stdout is not a terminal
+	syncLogInterval = time.Minute
+	// node is stalled if it makes no progress for that long
+	syncStallTimeout = 10 * time.Minute
+	// sync rate, and so ETA, is calculated over that window
+	syncRateWindow = 5 * time.Minute
+)
+
+// syncStatus is the state of the node sync at the moment
+type syncStatus struct {
+	Blocks      int
+	PeerHeight  int
+	Connections int
+	AssetName   string
+	IsSynced    bool
+}
+
+type syncSample struct {
+	at     time.Time
+	blocks int
+}
+
+// syncMonitor waits for the node to sync, reports progress and ETA and detects stalled sync
+type syncMonitor struct {
+	config   *configs.Config
+	terminal bool
+
+	started      time.Time
+	samples      []syncSample
+	lastProgress time.Time
+	lastBlocks   int
+	lastAsset    string
+	stallWarned  bool
+	lastLogged   time.Time
+	lineLength   int
+}
+
+func newSyncMonitor(config *configs.Config) *syncMonitor {
+	now := time.Now()
+	return &syncMonitor{
+		config:       config,
+		terminal:     utils.IsTerminal(os.Stdout),
+		started:      now,
+		lastProgress: now,
+	}
+}
+
+// wait blocks until mnsync is finished, returns number of synced blocks
+func (m *syncMonitor) wait(ctx context.Context) (int, error) {
+	for {
+		status, err := getSyncStatus(ctx, m.config)
+		if err != nil {
+			m.endLine()
+			return 0, err
+		}
+		m.update(status)
+		m.report(ctx, status)
+
+		if status.IsSynced {
+			m.endLine()
+			log.WithContext(ctx).Infof("masternodes lists are synced at block %d (elapsed: %v)", status.Blocks, m.elapsed())
+			return status.Blocks, nil
+		}
+
+		if status.AssetName == "Initial" {
+			var output interface{}
+			if err := pastelcore.NewClient(m.config).RunCommandWithArgs(pastelcore.MasterNodeSyncCmd, []string{"reset"}, &output); err != nil {
+				m.endLine()
+				log.WithContext(ctx).WithError(err).Error("master node reset has failed")
+				return 0, err
+			}
+		}
+
+		m.checkStall(ctx, status)
+
+		if m.config.SyncTimeout > 0 && time.Since(m.started) > m.config.SyncTimeout {
+			m.endLine()
+			return 0, errors.Errorf("node is not synced after %v: %s, use --sync-timeout to wait longer",
+				m.config.SyncTimeout, m.describe(status))
+		}
+
+		select {
+		case <-ctx.Done():
+			m.endLine()
+			return 0, ctx.Err()
+		case <-time.After(syncProbeInterval):
+		}
+	}
+}
+
+// getSyncStatus queries the local node for its blocks, mnsync status and heights of its peers
+func getSyncStatus(ctx context.Context, config *configs.Config) (syncStatus, error) {
+	var status syncStatus
+
+	getinfo, err := GetPastelInfo(ctx, config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("master node getinfo call has failed")
+		return status, err
+	}
+	status.Blocks = getinfo.Result.Blocks
+	status.Connections = getinfo.Result.Connections
+
+	mnstatus, err := GetMNSyncInfo(ctx, config)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("master node mnsync status call has failed")
+		return status, err
+	}
+	status.AssetName = mnstatus.Result.AssetName
+	status.IsSynced = mnstatus.Result.IsSynced
+
+	// peer heights are only used for the progress, so node without them is still monitored
+	var peers structure.RPCGetPeerInfo
+	if err := pastelcore.NewClient(config).RunCommand(pastelcore.GetPeerInfoCmd, &peers); err != nil {
+		log.WithContext(ctx).WithError(err).Debug("getpeerinfo call has failed")
+	}
+	status.PeerHeight = getPeerHeight(peers.Result)
+	return status, nil
+}
+
+// getPeerHeight returns the best height reported by the peers, 0 if there are no peers
+func getPeerHeight(peers []structure.PeerInfo) int {
+	var height int
+	for _, peer := range peers {
+		if peer.StartingHeight > height {
+			height = peer.StartingHeight
+		}
+		if peer.SyncedHeaders > height {
+			height = peer.SyncedHeaders
+		}
+	}
+	return height
+}
+
+// behind checks if the node still loads blocks
+func (s syncStatus) behind() bool {
+	return s.PeerHeight == 0 || s.Blocks < s.PeerHeight
+}
+
+func (m *syncMonitor) update(status syncStatus) {
+	now := time.Now()
+	if status.AssetName != m.lastAsset || (status.behind() && status.Blocks > m.lastBlocks) {
+		m.lastProgress = now
+		m.stallWarned = false
+	}
+	m.lastBlocks = status.Blocks
+	m.lastAsset = status.AssetName
+
+	m.samples = append(m.samples, syncSample{at: now, blocks: status.Blocks})
+	for len(m.samples) > 2 && now.Sub(m.samples[0].at) > syncRateWindow {
+		m.samples = m.samples[1:]
+	}
+}
+
+// progress returns percent of loaded blocks and ETA, ETA is 0 if it is unknown
+func (m *syncMonitor) progress(status syncStatus) (float64, time.Duration) {
+	if status.PeerHeight == 0 {
+		return 0, 0
+	}
+	if !status.behind() {
+		return 100, 0
+	}
+	percent := float64(status.Blocks) * 100 / float64(status.PeerHeight)
+
+	first, last := m.samples[0], m.samples[len(m.samples)-1]
+	period := last.at.Sub(first.at)
+	loaded := last.blocks - first.blocks
+	if period <= 0 || loaded <= 0 {
+		return percent, 0
+	}
+	rate := float64(loaded) / period.Seconds()
+	eta := time.Duration(float64(status.PeerHeight-status.Blocks)/rate) * time.Second
+	return percent, eta
+}
+
+func (m *syncMonitor) describe(status syncStatus) string {
+	var blocks string
+	if status.PeerHeight == 0 {
+		blocks = fmt.Sprintf("block %d (peer height unknown)", status.Blocks)
+	} else {
+		percent, eta := m.progress(status)
+		blocks = fmt.Sprintf("block %d of %d (%.1f%%)", status.Blocks, status.PeerHeight, percent)
+		if eta > 0 {
+			blocks = fmt.Sprintf("%s, ETA %v", blocks, eta.Round(time.Second))
+		}
+	}
+	return fmt.Sprintf("%s; %d connections; mnsync=%s", blocks, status.Connections, status.AssetName)
+}
+
+func (m *syncMonitor) elapsed() time.Duration {
+	return time.Since(m.started).Round(time.Second)
+}
+
+func (m *syncMonitor) report(ctx context.Context, status syncStatus) {
+	line := fmt.Sprintf("Waiting for sync... %s (elapsed: %v)", m.describe(status), m.elapsed())
+	if !m.terminal {
+		if time.Since(m.lastLogged) >= syncLogInterval {
+			m.lastLogged = time.Now()
+			log.WithContext(ctx).Info(line)
+		}
+		return
+	}
+
+	// line overwrites itself to avoid abundant logging, pad it to erase the end of the longer previous line
+	padding := ""
+	if len(line) < m.lineLength {
+		padding = strings.Repeat(" ", m.lineLength-len(line))
+	}
+	fmt.Printf("\r%s%s", line, padding)
+	m.lineLength = len(line)
+}
+
+// endLine moves the cursor off the progress line, so the following logs don't overwrite it
+func (m *syncMonitor) endLine() {
+	if m.terminal && m.lineLength > 0 {
+		fmt.Println()
+		m.lineLength = 0
+	}
+}
+
+func (m *syncMonitor) checkStall(ctx context.Context, status syncStatus) {
+	stalled := time.Since(m.lastProgress)
+	if m.stallWarned || stalled < syncStallTimeout {
+		return
+	}
+	m.stallWarned = true
+
+	var hint string
+	switch {
+	case status.Connections == 0:
+		hint = "Node has no connections, check that the network port is open and add peers with 'addnode=<ip>' " +
+			"in pastel.conf (or --peers of install/update)"
+	case !status.behind():
+		hint = fmt.Sprintf("Blocks are loaded, but masternode sync is stuck at %s, try 'pastel-cli mnsync reset'", status.AssetName)
+	default:
+		hint = "Node doesn't get new blocks, add more peers with 'pastel-cli addnode <ip> add' or try 'pastel-cli mnsync reset'"
+	}
+	m.endLine()
+	log.WithContext(ctx).Warnf("Sync made no progress for %v: %s. %s", stalled.Round(time.Second), m.describe(status), hint)
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/pastelnetwork/gonode/common/log"
 	"github.com/pastelnetwork/pastelup/configs"
 	"github.com/pastelnetwork/pastelup/constants"
-	"github.com/pastelnetwork/pastelup/utils"
 )
 
 // ToolTypeServices represents the list of tool types that can be enabled as system services
@@ -107,7 +106,7 @@ func installSystemService(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		return err // services feature not configured for users OS
 	}
@@ -120,7 +119,10 @@ func installSystemService(ctx context.Context, config *configs.Config) error {
 		if err != nil {
 			return err
 		}
-		log.WithContext(ctx).Infof("System service %s is registered for auto start on boot", app)
+		log.WithContext(ctx).Infof("System service %s is registered with %s", app, sm.Name())
+		if err = saveServiceManagerName(config, sm.Name()); err != nil {
+			log.WithContext(ctx).WithError(err).Warn("Failed to save service manager, pass --service-manager to other commands")
+		}
 
 		if config.EnableService {
 			err := sm.EnableService(ctx, config, tool)
@@ -138,12 +140,36 @@ func installSystemService(ctx context.Context, config *configs.Config) error {
 			log.WithContext(ctx).Infof("Started %s as a system service", app)
 		}
 	}
+	// units registered before have to get dependencies on the new ones
+	refreshSystemServices(ctx, config)
 	return nil
 }
 
+// refreshSystemServices re-renders units of the registered services, so they get the current template, dependencies and overrides
+func refreshSystemServices(ctx context.Context, config *configs.Config) {
+	sm, err := NewServiceManager(config)
+	if err != nil {
+		return
+	}
+	systemd, ok := sm.(LinuxSystemdManager)
+	if !ok {
+		return
+	}
+	refreshed := make(map[constants.ToolType]bool)
+	for _, app := range installServiceFlag {
+		tool := toolToToolType[app]
+		if refreshed[tool] {
+			continue
+		}
+		refreshed[tool] = true
+		if err = systemd.RefreshService(ctx, config, tool); err != nil {
+			log.WithContext(ctx).WithError(err).Warnf("Failed to refresh %s service", app)
+		}
+	}
+}
+
 // removeSystemService stops and remove an installed system service. For example, on linux, a user
 // may run ./pastelup update remove-service --tool node and this would stop and remove the systemd service running via systemtctl
-// TODO: REMOVE service part is not yet implemented
 //lint:ignore U1000 Ignore unused function temporarily
 func removeSystemService(ctx context.Context, config *configs.Config) error {
 
@@ -152,19 +178,33 @@ func removeSystemService(ctx context.Context, config *configs.Config) error {
 		return err
 	}
 
-	sm, err := NewServiceManager(utils.GetOS(), config.Configurer.DefaultHomeDir())
+	sm, err := NewServiceManager(config)
 	if err != nil {
 		return err // services feature not configured for users OS
 	}
 	for _, app := range toolsToUnInstall {
-
-		tool := toolToToolType[app]
-
-		err = sm.StopService(ctx, config, tool)
-		if err != nil {
-			return fmt.Errorf("unable to stop %s as a system service: %v", app, err)
+		if err = unregisterSystemService(ctx, config, sm, toolToToolType[app]); err != nil {
+			return err
 		}
-		log.WithContext(ctx).Infof("Stopped %s as a system service", app)
 	}
 	return nil
 }
+
+// unregisterSystemService stops, disables and removes the system service of the app, if it is registered
+func unregisterSystemService(ctx context.Context, config *configs.Config, sm ServiceManager, app constants.ToolType) error {
+	if !sm.IsRegistered(ctx, config, app) {
+		return nil
+	}
+	if err := sm.StopService(ctx, config, app); err != nil {
+		return fmt.Errorf("unable to stop %s as a system service: %v", app, err)
+	}
+	log.WithContext(ctx).Infof("Stopped %s as a system service", app)
+	if err := sm.DisableService(ctx, config, app); err != nil {
+		log.WithContext(ctx).WithError(err).Warnf("Unable to disable %s system service", app)
+	}
+	if err := sm.RemoveService(ctx, config, app); err != nil {
+		return fmt.Errorf("unable to remove %s system service: %v", app, err)
+	}
+	log.WithContext(ctx).Infof("Removed %s system service", app)
+	return nil
+}
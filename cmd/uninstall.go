@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+type uninstallCommand uint8
+
+const (
+	nodeUninstall uninstallCommand = iota
+	walletUninstall
+	superNodeUninstall
+	rqServiceUninstall
+	ddServiceUninstall
+	wnServiceUninstall
+	snServiceUninstall
+	bridgeServiceUninstall
+	hermesServiceUninstall
+)
+
+var (
+	uninstallCmdName = map[uninstallCommand]string{
+		nodeUninstall:          "node",
+		walletUninstall:        "walletnode",
+		superNodeUninstall:     "supernode",
+		rqServiceUninstall:     "rq-service",
+		ddServiceUninstall:     "dd-service",
+		wnServiceUninstall:     "walletnode-service",
+		snServiceUninstall:     "supernode-service",
+		bridgeServiceUninstall: "bridge-service",
+		hermesServiceUninstall: "hermes-service",
+	}
+	uninstallCmdMessage = map[uninstallCommand]string{
+		nodeUninstall:          "Uninstall node and all components installed with it",
+		walletUninstall:        "Uninstall Walletnode",
+		superNodeUninstall:     "Uninstall Supernode",
+		rqServiceUninstall:     "Uninstall RaptorQ service only",
+		ddServiceUninstall:     "Uninstall Dupe Detection service only",
+		wnServiceUninstall:     "Uninstall Walletnode service only",
+		snServiceUninstall:     "Uninstall Supernode service only",
+		bridgeServiceUninstall: "Uninstall bridge-service only",
+		hermesServiceUninstall: "Uninstall hermes-service only",
+	}
+	// uninstallComponents are components removed by the command, in the order they are stopped
+	uninstallComponents = map[uninstallCommand][]constants.ToolType{
+		nodeUninstall: {
+			constants.SuperNode,
+			constants.Hermes,
+			constants.WalletNode,
+			constants.Bridge,
+			constants.RQService,
+			constants.DDImgService,
+			constants.DDService,
+			constants.PastelD,
+		},
+		walletUninstall: {
+			constants.WalletNode,
+			constants.Bridge,
+			constants.RQService,
+			constants.PastelD,
+		},
+		superNodeUninstall: {
+			constants.SuperNode,
+			constants.Hermes,
+			constants.RQService,
+			constants.DDImgService,
+			constants.DDService,
+			constants.PastelD,
+		},
+		rqServiceUninstall:     {constants.RQService},
+		ddServiceUninstall:     {constants.DDImgService, constants.DDService},
+		wnServiceUninstall:     {constants.WalletNode},
+		snServiceUninstall:     {constants.SuperNode},
+		bridgeServiceUninstall: {constants.Bridge},
+		hermesServiceUninstall: {constants.Hermes},
+	}
+)
+
+var (
+	flagUninstallArchive bool
+	flagUninstallPurge   bool
+)
+
+// uninstallKeepFiles are files and directories (of every network) kept in the working directory, unless --purge is set,
+// pastelkeys has PastelID secure containers, which are as unrecoverable as the wallet
+var uninstallKeepFiles = []string{"wallet.dat", "masternode.conf", "pastelkeys"}
+
+// componentDataDirs are data directories in the working directory removed along with the component with --purge
+var componentDataDirs = map[constants.ToolType][]string{
+	constants.RQService: {constants.RQServiceDir},
+	constants.SuperNode: {constants.P2PDataDir, constants.MDLDataDir, constants.TempDir},
+}
+
+func setupUninstallSubCommand(config *configs.Config,
+	uninstallCmd uninstallCommand,
+	f func(context.Context, *configs.Config, []constants.ToolType) error,
+) *cli.Command {
+
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("archive-dir", &config.ArchiveDir).
+			SetUsage(green("Optional, Location where to store archived working directory")).SetValue(config.Configurer.DefaultArchiveDir()),
+		cli.NewFlag("archive", &flagUninstallArchive).
+			SetUsage(green("Optional, archive working directory (dupe detection directory for dd-service) before removing it")),
+		cli.NewFlag("purge", &flagUninstallPurge).
+			SetUsage(green("Optional, also remove wallet.dat, masternode.conf, PastelID keys (pastelkeys), ZKSnark parameters and data of rq-service and supernode uninstalled without the node")),
+		cli.NewFlag("force", &config.Force).SetAliases("f").
+			SetUsage(green("Optional, uninstall without confirmation")),
+		cli.NewFlag("user-pw", &config.UserPw).
+			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		cli.NewFlag("service-manager", &config.ServiceManager).
+			SetUsage(green("Optional, service manager used to register services, one of: " + strings.Join(ServiceManagerNames, ", ") + ", if omitted, will be detected")),
+		cli.NewFlag("firewall", &config.Firewall).
+			SetUsage(green("Optional, firewall used to open ports, one of: " + strings.Join(FirewallNames, ", ") + ", if omitted, will be detected")),
+	}
+
+	commandName := uninstallCmdName[uninstallCmd]
+	commandMessage := uninstallCmdMessage[uninstallCmd]
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	addInstanceFlag(subCommand, config)
+	addLogFlags(subCommand, config)
+
+	if f != nil {
+		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+			ctx, err := configureLogging(ctx, commandMessage, config)
+			if err != nil {
+				return err
+			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			sys.RegisterInterruptHandler(cancel, func() {
+				log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+				os.Exit(0)
+			})
+			if err = ParsePastelConf(ctx, config); err != nil {
+				log.WithContext(ctx).WithError(err).Warn("Unable to parse pastel.conf, default ports are used")
+			}
+
+			log.WithContext(ctx).Info("Started")
+			if err = f(ctx, config, uninstallComponents[uninstallCmd]); err != nil {
+				return err
+			}
+			log.WithContext(ctx).Info("Finished successfully!")
+			return nil
+		})
+	}
+	return subCommand
+}
+
+func setupUninstallCommand(config *configs.Config) *cli.Command {
+	uninstallCmd := cli.NewCommand("uninstall")
+	uninstallCmd.SetUsage(blue("Stops and removes installed components, their services, firewall rules and files"))
+	for _, subCmd := range []uninstallCommand{
+		nodeUninstall,
+		walletUninstall,
+		superNodeUninstall,
+		rqServiceUninstall,
+		ddServiceUninstall,
+		wnServiceUninstall,
+		snServiceUninstall,
+		bridgeServiceUninstall,
+		hermesServiceUninstall,
+	} {
+		uninstallCmd.AddSubcommands(setupUninstallSubCommand(config, subCmd, runUninstall))
+	}
+	return uninstallCmd
+}
+
+// runUninstall removes the components. When pasteld is among them, the whole installation of the instance is removed:
+// pastel directory, dupe detection directory and working directory, except wallet.dat, masternode.conf and pastelkeys without --purge
+func runUninstall(ctx context.Context, config *configs.Config, tools []constants.ToolType) error {
+	removeNode := utils.ContainsToolType(tools, constants.PastelD)
+
+	if !config.Force {
+		question := fmt.Sprintf("%v will be stopped and removed with all their files. Is this ok? Y/N", tools)
+		if removeNode {
+			question = fmt.Sprintf("%v will be stopped and removed, %s and %s will be deleted. Is this ok? Y/N",
+				tools, config.PastelExecDir, config.WorkingDir)
+		}
+		if ok, _ := AskUserToContinue(ctx, question); !ok {
+			return errors.Errorf("user did not accept confirmation to uninstall")
+		}
+	}
+
+	if err := stopComponents(ctx, config, tools); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to stop components")
+		return err
+	}
+
+	if sm, err := NewServiceManager(config); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Service manager is not available, skipping removal of system services")
+	} else {
+		for _, tool := range tools {
+			if err = unregisterSystemService(ctx, config, sm, tool); err != nil {
+				log.WithContext(ctx).WithError(err).Errorf("Failed to remove %s system service", tool)
+				return err
+			}
+		}
+	}
+
+	if err := closeUninstalledFirewallPorts(ctx, config, tools, removeNode); err != nil {
+		log.WithContext(ctx).WithError(err).Warn("Unable to close firewall ports, close them with 'pastelup firewall close'")
+	}
+
+	if removeNode {
+		return removeInstallation(ctx, config)
+	}
+	for _, tool := range tools {
+		if err := removeComponentFiles(ctx, config, tool); err != nil {
+			log.WithContext(ctx).WithError(err).Errorf("Failed to remove %s files", tool)
+			return err
+		}
+	}
+	return nil
+}
+
+func closeUninstalledFirewallPorts(ctx context.Context, config *configs.Config, tools []constants.ToolType, removeNode bool) error {
+	if removeNode {
+		return closeFirewallRules(ctx, config)
+	}
+	for _, tool := range tools {
+		if err := closeFirewallPorts(ctx, config, tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeInstallation removes directories of the instance
+func removeInstallation(ctx context.Context, config *configs.Config) error {
+	for _, dir := range []string{config.PastelExecDir, getDDServiceDir(config)} {
+		log.WithContext(ctx).Infof("Removing %s", dir)
+		if err := os.RemoveAll(dir); err != nil {
+			return errors.Errorf("unable to remove %s: %v", dir, err)
+		}
+	}
+
+	if utils.CheckFileExist(config.WorkingDir) {
+		if flagUninstallArchive {
+			if err := archiveDir(ctx, config, config.WorkingDir, filepath.Base(config.WorkingDir)); err != nil {
+				log.WithContext(ctx).Error(fmt.Sprintf("Failed to archive %v directory: %v", config.WorkingDir, err))
+				return err
+			}
+		}
+
+		if flagUninstallPurge {
+			log.WithContext(ctx).Infof("Removing %s", config.WorkingDir)
+			if err := os.RemoveAll(config.WorkingDir); err != nil {
+				return errors.Errorf("unable to remove %s: %v", config.WorkingDir, err)
+			}
+		} else {
+			log.WithContext(ctx).Infof("Removing %s, except %s", config.WorkingDir, strings.Join(uninstallKeepFiles, ", "))
+			kept, err := utils.RemoveDirExcept(config.WorkingDir, uninstallKeepFiles)
+			if err != nil {
+				return errors.Errorf("unable to remove %s: %v", config.WorkingDir, err)
+			}
+			if kept {
+				log.WithContext(ctx).Warnf("%s are kept in %s, pass --purge to remove them",
+					strings.Join(uninstallKeepFiles, ", "), config.WorkingDir)
+			}
+		}
+	}
+
+	// ZKSnark parameters are shared by all instances on the host
+	if flagUninstallPurge && len(getOtherInstancesWorkDirs(config)) == 0 {
+		zksnarkDir := config.Configurer.DefaultZksnarkDir()
+		log.WithContext(ctx).Infof("Removing %s", zksnarkDir)
+		if err := os.RemoveAll(zksnarkDir); err != nil {
+			return errors.Errorf("unable to remove %s: %v", zksnarkDir, err)
+		}
+	}
+	return nil
+}
+
+// removeComponentFiles removes executable and config file of the component, its data is removed with --purge only
+func removeComponentFiles(ctx context.Context, config *configs.Config, tool constants.ToolType) error {
+	var files []string
+	if execNames, ok := constants.ServiceName[tool]; ok {
+		files = append(files, filepath.Join(config.PastelExecDir, execNames[utils.GetOS()]))
+	}
+
+	switch tool {
+	case constants.RQService:
+		files = append(files, config.Configurer.GetRQServiceConfFile(config.WorkingDir))
+	case constants.WalletNode:
+		files = append(files, config.Configurer.GetWalletNodeConfFile(config.WorkingDir))
+	case constants.SuperNode:
+		files = append(files, config.Configurer.GetSuperNodeConfFile(config.WorkingDir))
+	case constants.Bridge:
+		files = append(files, config.Configurer.GetBridgeConfFile(config.WorkingDir))
+	case constants.Hermes:
+		files = append(files, config.Configurer.GetHermesConfFile(config.WorkingDir))
+	case constants.DDService:
+		if flagUninstallArchive && utils.CheckFileExist(getDDServiceDir(config)) {
+			if err := archiveDDDir(ctx, config); err != nil {
+				return err
+			}
+		}
+		// scripts and venv of dd-service, and its support files
+		files = append(files, filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder), getDDServiceDir(config))
+	}
+
+	if flagUninstallPurge {
+		for _, dir := range componentDataDirs[tool] {
+			files = append(files, filepath.Join(config.WorkingDir, dir))
+		}
+	}
+
+	for _, file := range files {
+		if !utils.CheckFileExist(file) {
+			continue
+		}
+		log.WithContext(ctx).Infof("Removing %s", file)
+		if err := os.RemoveAll(file); err != nil {
+			return errors.Errorf("unable to remove %s: %v", file, err)
+		}
+	}
+	return nil
+}
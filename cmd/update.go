@@ -83,6 +83,8 @@ func setupUpdateSubCommand(config *configs.Config,
 			SetUsage(green("Optional, List of peers to add into pastel.conf file, must be in the format - \"ip\" or \"ip:port\"")),
 		cli.NewFlag("release", &config.Version).SetAliases("r").
 			SetUsage(green("Optional, Pastel version to install")),
+		cli.NewFlag("ignore-compat", &config.IgnoreCompat).
+			SetUsage(green("Optional, skip the check of compatibility of the release with installed components")),
 		cli.NewFlag("clean", &config.Clean).SetAliases("c").
 			SetUsage(green("Optional, Clean .pastel folder")),
 		cli.NewFlag("user-pw", &config.UserPw).
@@ -124,9 +126,8 @@ func setupUpdateSubCommand(config *configs.Config,
 			SetUsage(red("Required, password of remote user - so no sudo password request is prompted")).SetRequired(),
 		cli.NewFlag("ssh-key", &config.RemoteSSHKey).
 			SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
-		cli.NewFlag("inventory", &config.InventoryFile).
-			SetUsage(red("Optional, Path to the file with configuration of the remote hosts")),
 	}
+	remoteFlags = append(remoteFlags, getInventoryFlags(config)...)
 
 	systemServiceFlags := []*cli.Flag{
 		cli.NewFlag("tool", &config.ServiceTool).
@@ -140,6 +141,8 @@ func setupUpdateSubCommand(config *configs.Config,
 			SetUsage(yellow("Optional, Enable service for auto start after OS boot")),
 		cli.NewFlag("start", &config.StartService).
 			SetUsage(yellow("Optional, Start service right away")),
+		cli.NewFlag("service-manager", &config.ServiceManager).
+			SetUsage(yellow("Optional, service manager to use, one of: " + strings.Join(ServiceManagerNames, ", ") + ", if omitted, will be detected")),
 	}
 
 	var commandName, commandMessage string
@@ -158,6 +161,10 @@ func setupUpdateSubCommand(config *configs.Config,
 	} else {
 		commandFlags = append(dirsFlags, commonFlags[:]...)
 	}
+	if updateCmd == updatePastelup {
+		commandFlags = append(commandFlags, cli.NewFlag("skip-verify", &config.SkipVerify).
+			SetUsage(green("Optional, install pastelup even if its checksum is not published")))
+	}
 	if remote {
 		commandFlags = append(commandFlags, remoteFlags[:]...)
 	}
@@ -165,6 +172,9 @@ func setupUpdateSubCommand(config *configs.Config,
 	subCommand := cli.NewCommand(commandName)
 	subCommand.SetUsage(cyan(commandMessage))
 	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
 
 	if f != nil {
 		subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
@@ -172,6 +182,9 @@ func setupUpdateSubCommand(config *configs.Config,
 			if err != nil {
 				return fmt.Errorf("failed to configure logging option - %v", err)
 			}
+			if err = applyInstance(config); err != nil {
+				return err
+			}
 
 			ctx, cancel := context.WithCancel(ctx)
 			defer cancel()
@@ -184,14 +197,19 @@ func setupUpdateSubCommand(config *configs.Config,
 			if utils.Contains(config.Args, "install-service") || utils.Contains(config.Args, "remove-service") {
 				requiresVersion = false
 			}
-			if config.Version == "" && requiresVersion {
+			// release of the remote host may be set by the inventory, it is checked for every host
+			if config.Version == "" && requiresVersion && !remote {
+				err = constants.NoVersionSetErr{}
 				log.WithContext(ctx).
-					WithError(constants.NoVersionSetErr{}).
+					WithError(err).
 					Error("Failed to process update command")
 				return err
 			}
-			if err = ParsePastelConf(ctx, config); err != nil {
-				return err
+			// network of the remote host is set by the command line or the inventory, not by local pastel.conf
+			if !remote {
+				if err = ParsePastelConf(ctx, config); err != nil {
+					return err
+				}
 			}
 			log.WithContext(ctx).Infof("Started update... ")
 			if config.Version != "" {
@@ -277,6 +295,24 @@ func runUpdateRemoteSNService(ctx context.Context, config *configs.Config) (err
 func runRemoteUpdate(ctx context.Context, config *configs.Config, tool string) (err error) {
 	log.WithContext(ctx).Infof("Updating remote %s", tool)
 
+	getUpdateCommand := func() (string, error) {
+		return getRemoteUpdateCommand(config, tool)
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getUpdateCommand, false); err != nil {
+		log.WithContext(ctx).WithError(err).Errorf("Failed to update %s on remote host", tool)
+		return err
+	}
+	log.WithContext(ctx).Infof("Remote %s updated", tool)
+
+	return nil
+}
+
+// getRemoteUpdateCommand returns command line of the remote pastelup to update the tool,
+// fails if the release is set neither by the command line nor by the inventory
+func getRemoteUpdateCommand(config *configs.Config, tool string) (string, error) {
+	if len(config.Version) == 0 {
+		return "", constants.NoVersionSetErr{}
+	}
 	updateOptions := tool
 
 	if len(config.PastelExecDir) > 0 {
@@ -292,20 +328,22 @@ func runRemoteUpdate(ctx context.Context, config *configs.Config, tool string) (
 	}
 
 	if len(config.UserPw) > 0 {
-		updateOptions = fmt.Sprintf("--user-pw %s", config.UserPw)
+		updateOptions = fmt.Sprintf("%s --user-pw %s", updateOptions, config.UserPw)
 	}
 
-	if len(config.Version) > 0 {
-		updateOptions = fmt.Sprintf("%s --release=%s", updateOptions, config.Version)
+	updateOptions = fmt.Sprintf("%s --release=%s", updateOptions, config.Version)
+
+	if config.IgnoreCompat {
+		updateOptions = fmt.Sprintf("%s --ignore-compat", updateOptions)
 	}
 
-	updateSuperNodeCmd := fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, updateOptions)
-	if err := executeRemoteCommandsWithInventory(ctx, config, []string{updateSuperNodeCmd}, false); err != nil {
-		log.WithContext(ctx).WithError(err).Errorf("Failed to update %s on remote host", tool)
+	if config.Network == constants.NetworkTestnet {
+		updateOptions = fmt.Sprintf("%s -n=testnet", updateOptions)
+	} else if config.Network == constants.NetworkRegTest {
+		updateOptions = fmt.Sprintf("%s -n=regtest", updateOptions)
 	}
-	log.WithContext(ctx).Infof("Remote %s updated", tool)
 
-	return nil
+	return fmt.Sprintf("yes Y | %s update %s", constants.RemotePastelupPath, updateOptions), nil
 }
 
 func runUpdateNodeSubCommand(ctx context.Context, config *configs.Config) (err error) {
@@ -341,6 +379,11 @@ func stopAndUpdateService(ctx context.Context, config *configs.Config, updateCom
 
 	log.WithContext(ctx).Infof("Updating %s component ...", string(updateCommand))
 
+	if err := checkReleaseCompatibility(ctx, config, updateCommand, withDependencies); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Compatibility check failed")
+		return err
+	}
+
 	var servicesToStop []constants.ToolType
 	if withDependencies {
 		servicesToStop = appToServiceMap[updateCommand]
@@ -369,6 +412,7 @@ func stopAndUpdateService(ctx context.Context, config *configs.Config, updateCom
 		log.WithContext(ctx).WithError(err).Error(fmt.Printf("Failed to update '%v': %v", updateCommand, err))
 		return err
 	}
+	refreshSystemServices(ctx, config)
 	log.WithContext(ctx).Infof("Successfully updated %s component and its dependencies", string(updateCommand))
 
 	log.WithContext(ctx).Info("Updated services need to be restarted:")
@@ -473,9 +517,8 @@ func archiveDir(ctx context.Context, config *configs.Config, dirToArchive, archi
 }
 
 func archiveDDDir(ctx context.Context, config *configs.Config) error {
-	homeDir := config.Configurer.DefaultHomeDir()
-	dirToArchive := filepath.Join(homeDir, constants.DupeDetectionServiceDir)
-	if err := archiveDir(ctx, config, dirToArchive, constants.DupeDetectionServiceDir); err != nil {
+	dirToArchive := getDDServiceDir(config)
+	if err := archiveDir(ctx, config, dirToArchive, filepath.Base(dirToArchive)); err != nil {
 		log.WithContext(ctx).Error(fmt.Sprintf("Failed to archive %v directory: %v", dirToArchive, err))
 		return err
 	}
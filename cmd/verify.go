@@ -0,0 +1,479 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+var flagVerifyRepair bool
+
+// verifyComponents are components which installation is verified, in the order of the checks
+var verifyComponents = []constants.ToolType{
+	constants.PastelD,
+	constants.RQService,
+	constants.DDService,
+	constants.WalletNode,
+	constants.Bridge,
+	constants.SuperNode,
+	constants.Hermes,
+}
+
+// ddRequirementsCheckScript checks that requirements of dd-service are installed into venv and their modules are importable
+const ddRequirementsCheckScript = `
+import re, sys
+from importlib import metadata
+failed = []
+for line in open(sys.argv[1]):
+    line = line.split("#")[0].strip()
+    if not line or line.startswith("-"):
+        continue
+    name = re.split(r"[\s<>=!~;\[@]", line, maxsplit=1)[0]
+    try:
+        dist = metadata.distribution(name)
+        for module in (dist.read_text("top_level.txt") or "").split():
+            __import__(module)
+    except Exception as e:
+        failed.append("%s: %s" % (name, e))
+if failed:
+    print("\n".join(failed))
+    sys.exit(1)
+`
+
+// verifyCheck is the check of the installed artifact, repair is nil if the artifact can't be repaired by pastelup
+type verifyCheck struct {
+	tool   constants.ToolType
+	name   string
+	check  func() error
+	repair func(ctx context.Context) error
+}
+
+func setupVerifyCommand(config *configs.Config) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+			SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+		cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+			SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		cli.NewFlag("repair", &flagVerifyRepair).
+			SetUsage(green("Optional, re-download or regenerate broken artifacts")),
+		cli.NewFlag("release", &config.Version).SetAliases("r").
+			SetUsage(green("Optional, Pastel version to download broken executables of, required to repair them")),
+		cli.NewFlag("network", &config.Network).SetAliases("n").
+			SetUsage(green("Optional, network type, can be - \"mainnet\", \"testnet\" or \"regtest\", if omitted, will be read from pastel.conf")),
+		cli.NewFlag("legacy", &config.Legacy).
+			SetUsage(green("Optional, pasteld version is < 1.1, ZKSnark parameters of the old version are checked too")),
+		cli.NewFlag("no-cache", &config.NoCache).
+			SetUsage(green("Optional, runs the installation of python dependencies with caching turned off")),
+		cli.NewFlag("user-pw", &config.UserPw).
+			SetUsage(green("Optional, password of current sudo user - so no sudo password request is prompted")),
+		cli.NewFlag("service-manager", &config.ServiceManager).
+			SetUsage(green("Optional, service manager used to register services, one of: " + strings.Join(ServiceManagerNames, ", ") + ", if omitted, will be detected")),
+	}
+
+	verifyCommand := cli.NewCommand("verify")
+	verifyCommand.SetUsage(blue("Verifies installed executables, ZKSnark parameters, dupe detection files, configs and services"))
+	verifyCommand.AddFlags(commandFlags...)
+	addInstanceFlag(verifyCommand, config)
+	addLogFlags(verifyCommand, config)
+
+	verifyCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "verify", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		if err = applyInstance(config); err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		network := config.Network
+		if err = ParsePastelConf(ctx, config); err != nil {
+			log.WithContext(ctx).Warn("pastel.conf not found")
+		}
+		if len(network) != 0 {
+			if !utils.IsValidNetworkOpt(network) {
+				return fmt.Errorf("invalid --network provided. valid opts: %s", strings.Join(constants.NetworkModes, ","))
+			}
+			config.Network = network
+		} else if len(config.Network) == 0 {
+			config.Network = constants.NetworkMainnet
+		}
+
+		log.WithContext(ctx).Info("Started")
+		if err = runVerify(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return verifyCommand
+}
+
+func runVerify(ctx context.Context, config *configs.Config) error {
+	checks := getVerifyChecks(ctx, config)
+	if len(checks) == 0 {
+		return errors.Errorf("nothing is installed in %s and %s", config.PastelExecDir, config.WorkingDir)
+	}
+
+	failed := 0
+	for _, c := range checks {
+		err := c.check()
+		status := "OK"
+		if err != nil && flagVerifyRepair && c.repair != nil {
+			log.WithContext(ctx).Infof("Repairing %s %s...", c.tool, c.name)
+			if err = c.repair(ctx); err == nil {
+				// artifacts are re-checked, some of them are repaired along with the others
+				err = c.check()
+			}
+			status = "REPAIRED"
+		}
+		if err != nil {
+			status = "FAILED: " + err.Error()
+			failed++
+		}
+		fmt.Printf("%-12s %-40s %s\n", c.tool, c.name, status)
+	}
+
+	if failed > 0 {
+		if !flagVerifyRepair {
+			return errors.Errorf("%d of %d check(s) failed, run with --repair to fix them", failed, len(checks))
+		}
+		return errors.Errorf("%d of %d check(s) failed", failed, len(checks))
+	}
+	log.WithContext(ctx).Infof("All %d check(s) passed", len(checks))
+	return nil
+}
+
+// getVerifyChecks returns checks of the installed components, component is installed if any of its artifacts exists
+func getVerifyChecks(ctx context.Context, config *configs.Config) []verifyCheck {
+	var checks []verifyCheck
+	var installed []constants.ToolType
+	for _, tool := range verifyComponents {
+		tool := tool
+		if !isComponentInstalled(config, tool) {
+			continue
+		}
+		installed = append(installed, tool)
+
+		for _, execPath := range getVerifyExecPaths(config, tool) {
+			execPath := execPath
+			checks = append(checks, verifyCheck{
+				tool:   tool,
+				name:   filepath.Base(execPath),
+				check:  func() error { return checkExecutable(execPath, tool != constants.DDService) },
+				repair: func(ctx context.Context) error { return repairExecutable(ctx, config, tool, execPath) },
+			})
+		}
+
+		switch tool {
+		case constants.PastelD:
+			checks = append(checks, getZksnarkParamsCheck(ctx, config))
+		case constants.DDService:
+			checks = append(checks, getDDChecks(ctx, config)...)
+		}
+
+		if configPath := getVerifyConfigPath(config, tool); len(configPath) != 0 {
+			checks = append(checks, verifyCheck{
+				tool:   tool,
+				name:   filepath.Base(configPath),
+				check:  func() error { return checkFile(configPath) },
+				repair: func(ctx context.Context) error { return repairConfigFile(ctx, config, tool, configPath) },
+			})
+		}
+	}
+	if utils.ContainsToolType(installed, constants.DDService) {
+		installed = append(installed, constants.DDImgService)
+	}
+	return append(checks, getServiceChecks(ctx, config, installed)...)
+}
+
+func isComponentInstalled(config *configs.Config, tool constants.ToolType) bool {
+	if tool == constants.DDService {
+		return utils.CheckFileExist(filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder)) ||
+			utils.CheckFileExist(getDDServiceDir(config))
+	}
+	for _, execPath := range getVerifyExecPaths(config, tool) {
+		if utils.CheckFileExist(execPath) {
+			return true
+		}
+	}
+	return utils.CheckFileExist(getVerifyConfigPath(config, tool))
+}
+
+func getVerifyExecPaths(config *configs.Config, tool constants.ToolType) []string {
+	switch tool {
+	case constants.PastelD:
+		return []string{
+			filepath.Join(config.PastelExecDir, constants.PasteldName[utils.GetOS()]),
+			filepath.Join(config.PastelExecDir, constants.PastelCliName[utils.GetOS()]),
+		}
+	case constants.DDService:
+		return []string{filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, constants.DupeDetectionExecFileName)}
+	}
+	if execNames, ok := constants.ServiceName[tool]; ok {
+		return []string{filepath.Join(config.PastelExecDir, execNames[utils.GetOS()])}
+	}
+	return nil
+}
+
+func getVerifyConfigPath(config *configs.Config, tool constants.ToolType) string {
+	switch tool {
+	case constants.PastelD:
+		return filepath.Join(config.WorkingDir, constants.PastelConfName)
+	case constants.RQService:
+		return config.Configurer.GetRQServiceConfFile(config.WorkingDir)
+	case constants.WalletNode:
+		return config.Configurer.GetWalletNodeConfFile(config.WorkingDir)
+	case constants.Bridge:
+		return config.Configurer.GetBridgeConfFile(config.WorkingDir)
+	case constants.SuperNode:
+		return config.Configurer.GetSuperNodeConfFile(config.WorkingDir)
+	case constants.Hermes:
+		return config.Configurer.GetHermesConfFile(config.WorkingDir)
+	case constants.DDService:
+		return getDDConfigFilePath(config)
+	}
+	return ""
+}
+
+func checkFile(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return errors.Errorf("%s is missing", path)
+	} else if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return errors.Errorf("%s is not a file", path)
+	}
+	return nil
+}
+
+func checkExecutable(path string, executable bool) error {
+	if err := checkFile(path); err != nil {
+		return err
+	}
+	if !executable || utils.GetOS() == constants.Windows {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return errors.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+// repairExecutable makes existing file executable, or downloads missing one
+func repairExecutable(ctx context.Context, config *configs.Config, tool constants.ToolType, execPath string) error {
+	if tool != constants.DDService && checkFile(execPath) == nil {
+		return makeExecutable(ctx, filepath.Dir(execPath), filepath.Base(execPath))
+	}
+	if len(config.Version) == 0 {
+		return constants.NoVersionSetErr{}
+	}
+
+	dstFolder := ""
+	if tool == constants.DDService {
+		dstFolder = constants.DupeDetectionSubFolder
+	}
+	if err := downloadComponents(ctx, config, tool, config.Version, dstFolder); err != nil {
+		return err
+	}
+	if tool == constants.DDService {
+		return nil
+	}
+	for _, path := range getVerifyExecPaths(config, tool) {
+		if err := makeExecutable(ctx, filepath.Dir(path), filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getZksnarkParamsCheck(ctx context.Context, config *configs.Config) verifyCheck {
+	return verifyCheck{
+		tool:  constants.PastelD,
+		name:  "zksnark params",
+		check: func() error { return CheckZksnarkParams(ctx, config) },
+		repair: func(ctx context.Context) error {
+			zksnarkDir := config.Configurer.DefaultZksnarkDir()
+			if err := os.MkdirAll(zksnarkDir, 0755); err != nil {
+				return err
+			}
+			// params of the old version are downloaded for any version but beta
+			version := "beta"
+			if config.Legacy {
+				version = config.Version
+			}
+			// with force only params with wrong checksum are downloaded
+			return downloadZksnarkParams(ctx, zksnarkDir, true, version)
+		},
+	}
+}
+
+func getDDChecks(ctx context.Context, config *configs.Config) []verifyCheck {
+	var checks []verifyCheck
+
+	supportDir := filepath.Join(getDDServiceDir(config), constants.DupeDetectionSupportFilePath)
+	var names []string
+	for name := range constants.DupeDetectionSupportChecksum {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		supportPath := filepath.Join(supportDir, name)
+		checks = append(checks, verifyCheck{
+			tool:  constants.DDService,
+			name:  name,
+			check: func() error { return checkDDSupportFile(ctx, supportPath) },
+			repair: func(ctx context.Context) error {
+				if err := os.MkdirAll(supportDir, 0755); err != nil {
+					return err
+				}
+				return downloadDDSupportFiles(ctx, config)
+			},
+		})
+	}
+
+	checks = append(checks, verifyCheck{
+		tool:   constants.DDService,
+		name:   "venv requirements",
+		check:  func() error { return checkDDVenv(config) },
+		repair: func(ctx context.Context) error { return setupDDVenv(ctx, config) },
+	})
+	return checks
+}
+
+func checkDDSupportFile(ctx context.Context, path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return errors.Errorf("%s is missing", path)
+	} else if err != nil {
+		return err
+	}
+
+	var checksum string
+	if info.IsDir() {
+		checksum, err = utils.CalChecksumOfFolder(ctx, path)
+	} else {
+		checksum, err = utils.GetChecksum(ctx, path)
+	}
+	if err != nil {
+		return err
+	}
+	if checksum != constants.DupeDetectionSupportChecksum[filepath.Base(path)] {
+		return errors.Errorf("wrong checksum of %s", path)
+	}
+	return nil
+}
+
+// checkDDVenv checks that every requirement of dd-service is installed into its venv and can be imported
+func checkDDVenv(config *configs.Config) error {
+	python := filepath.Join(getDDVenvDir(config), "bin", "python")
+	if err := checkExecutable(python, true); err != nil {
+		return err
+	}
+	requirementsFile := filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder, constants.PipRequirmentsFileName)
+	if err := checkFile(requirementsFile); err != nil {
+		return err
+	}
+	if out, err := RunCMD(python, "-c", ddRequirementsCheckScript, requirementsFile); err != nil {
+		return errors.Errorf("requirements are broken: %s", strings.Join(strings.Fields(out), " "))
+	}
+	return nil
+}
+
+// repairConfigFile regenerates missing config file, the existing one is kept as is
+func repairConfigFile(ctx context.Context, config *configs.Config, tool constants.ToolType, configPath string) error {
+	if utils.CheckFileExist(configPath) {
+		return errors.Errorf("%s is not a file, remove it to regenerate", configPath)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+
+	switch tool {
+	case constants.PastelD:
+		// pastel.conf is regenerated with the new RPC credentials
+		config.RPCPort = GetSNPortList(config)[constants.NodeRPCPort]
+		config.RPCUser = utils.GenerateRandomString(8)
+		config.RPCPwd = utils.GenerateRandomString(15)
+		return updatePastelConfigFile(ctx, configPath, config)
+	case constants.DDService:
+		ddConfig := *config
+		ddConfig.Force = true
+		pathList, err := createDDDirs(ctx, &ddConfig)
+		if err != nil {
+			return err
+		}
+		return setupDDConfigFile(ctx, &ddConfig, pathList)
+	}
+
+	toolConfig, err := renderComponentConfig(ctx, config, tool)
+	if err != nil {
+		return err
+	}
+	if tool == constants.SuperNode || tool == constants.Hermes || tool == constants.Bridge {
+		log.WithContext(ctx).Warnf("%s is regenerated from the default template, set PastelID and passphrase in it", configPath)
+	}
+	return ioutil.WriteFile(configPath, []byte(toolConfig), 0644)
+}
+
+// getServiceChecks checks that systemd units of the registered services are the same as RegisterService would render
+func getServiceChecks(ctx context.Context, config *configs.Config, tools []constants.ToolType) []verifyCheck {
+	sm, err := NewServiceManager(config)
+	if err != nil {
+		return nil
+	}
+	systemd, ok := sm.(LinuxSystemdManager)
+	if !ok {
+		log.WithContext(ctx).Infof("Services registered with %s are not verified", sm.Name())
+		return nil
+	}
+
+	var checks []verifyCheck
+	for _, tool := range tools {
+		tool := tool
+		if !systemd.IsRegistered(ctx, config, tool) {
+			continue
+		}
+		checks = append(checks, verifyCheck{
+			tool: tool,
+			name: systemd.ServiceName(tool),
+			check: func() error {
+				upToDate, err := systemd.IsUpToDate(ctx, config, tool)
+				if err != nil {
+					return err
+				}
+				if !upToDate {
+					return errors.Errorf("unit differs from the one pastelup renders")
+				}
+				return nil
+			},
+			repair: func(ctx context.Context) error { return systemd.RefreshService(ctx, config, tool) },
+		})
+	}
+	return checks
+}
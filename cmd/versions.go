@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+
+	"github.com/pastelnetwork/gonode/common/cli"
+	"github.com/pastelnetwork/gonode/common/log"
+	"github.com/pastelnetwork/gonode/common/sys"
+	"github.com/pastelnetwork/gonode/common/version"
+	"github.com/pastelnetwork/pastelup/configs"
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	notInstalled      = "not installed"
+	versionCmdTimeout = 30 * time.Second
+)
+
+var (
+	flagVersionsOutput string
+	flagReleasesLimit  int
+)
+
+// releaseComponents are components which releases are published to the download server
+var releaseComponents = []constants.ToolType{
+	constants.Pastelup,
+	constants.PastelD,
+	constants.RQService,
+	constants.DDService,
+	constants.WalletNode,
+	constants.Bridge,
+	constants.SuperNode,
+	constants.Hermes,
+}
+
+// versionRegexp matches version in the output of `--version` of pastel executables
+var versionRegexp = regexp.MustCompile(`v?[0-9]+\.[0-9]+\.[0-9]+[0-9A-Za-z.+\-]*`)
+
+// ddVersionRegexp matches version in python package metadata: PKG-INFO, pyproject.toml, setup.cfg or setup.py
+var ddVersionRegexp = regexp.MustCompile(`(?m)^\s*[Vv]ersion\s*[:=]\s*["']?([0-9A-Za-z.+\-]+)["']?|\bversion\s*=\s*["']([0-9A-Za-z.+\-]+)["']`)
+
+// ddMetadataFiles are files in dd-service folder version is read from, in the order of preference
+var ddMetadataFiles = []string{"PKG-INFO", "pyproject.toml", "setup.cfg", "setup.py", "VERSION", "version.txt"}
+
+type componentVersion struct {
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	Path      string `json:"path,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type componentReleases struct {
+	Component string   `json:"component"`
+	Releases  []string `json:"releases"`
+}
+
+func setupVersionsSubCommand(config *configs.Config, remote bool,
+	f func(context.Context, *configs.Config) error,
+) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("output", &flagVersionsOutput).
+			SetUsage(green("Optional, how to present versions. Available choices are: 'console' and 'json'")).SetValue("console"),
+	}
+
+	if !remote {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory")).SetValue(config.Configurer.DefaultPastelExecutableDir()),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, location of working directory")).SetValue(config.Configurer.DefaultWorkingDir()),
+		)
+	} else {
+		commandFlags = append(commandFlags,
+			cli.NewFlag("dir", &config.PastelExecDir).SetAliases("d").
+				SetUsage(green("Optional, Location of pastel node directory on the remote computer (default: $HOME/pastel)")),
+			cli.NewFlag("work-dir", &config.WorkingDir).SetAliases("w").
+				SetUsage(green("Optional, Location of working directory on the remote computer (default: $HOME/.pastel)")),
+			cli.NewFlag("ssh-ip", &config.RemoteIP).
+				SetUsage(red("Required (if `inventory` is not used), SSH address of the remote host")),
+			cli.NewFlag("ssh-port", &config.RemotePort).
+				SetUsage(yellow("Optional, SSH port of the remote host, default is 22")).SetValue(22),
+			cli.NewFlag("ssh-user", &config.RemoteUser).
+				SetUsage(yellow("Optional, Username of user at remote host")),
+			cli.NewFlag("ssh-key", &config.RemoteSSHKey).
+				SetUsage(yellow("Optional, Path to SSH private key for SSH Key Authentication")),
+		)
+		commandFlags = append(commandFlags, getInventoryFlags(config)...)
+	}
+
+	commandName := "versions"
+	commandMessage := "Shows installed versions of pastel components"
+	if remote {
+		commandName = "remote"
+		commandMessage = "Shows installed versions of pastel components on the remote hosts"
+	}
+
+	subCommand := cli.NewCommand(commandName)
+	subCommand.SetUsage(cyan(commandMessage))
+	subCommand.AddFlags(commandFlags...)
+	if !remote {
+		addInstanceFlag(subCommand, config)
+	}
+	addLogFlags(subCommand, config)
+
+	subCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, commandName, config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		// logs must not be mixed with the json document
+		if flagVersionsOutput == "json" && !config.Quiet {
+			log.SetOutput(os.Stderr)
+		}
+		if !remote {
+			if err = applyInstance(config); err != nil {
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		log.WithContext(ctx).Info("Started")
+		if err = f(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return subCommand
+}
+
+func setupVersionsCommand(config *configs.Config) *cli.Command {
+	versionsCommand := setupVersionsSubCommand(config, false, runVersions)
+	versionsCommand.AddSubcommands(setupVersionsSubCommand(config, true, runRemoteVersions))
+	return versionsCommand
+}
+
+func setupReleasesCommand(config *configs.Config) *cli.Command {
+	commandFlags := []*cli.Flag{
+		cli.NewFlag("output", &flagVersionsOutput).
+			SetUsage(green("Optional, how to present releases. Available choices are: 'console' and 'json'")).SetValue("console"),
+		cli.NewFlag("limit", &flagReleasesLimit).SetAliases("l").
+			SetUsage(green("Optional, number of the newest releases to check, 0 - all")).SetValue(10),
+	}
+
+	releasesCommand := cli.NewCommand("releases")
+	releasesCommand.SetUsage(blue("Shows releases of pastel components published to " + constants.DownloadBaseURL))
+	releasesCommand.AddFlags(commandFlags...)
+	addLogFlags(releasesCommand, config)
+
+	releasesCommand.SetActionFunc(func(ctx context.Context, _ []string) error {
+		ctx, err := configureLogging(ctx, "releases", config)
+		if err != nil {
+			return fmt.Errorf("failed to configure logging option - %v", err)
+		}
+		// logs must not be mixed with the json document
+		if flagVersionsOutput == "json" && !config.Quiet {
+			log.SetOutput(os.Stderr)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		sys.RegisterInterruptHandler(cancel, func() {
+			log.WithContext(ctx).Info("Interrupt signal received. Gracefully shutting down...")
+			os.Exit(0)
+		})
+
+		log.WithContext(ctx).Info("Started")
+		if err = runReleases(ctx, config); err != nil {
+			return err
+		}
+		log.WithContext(ctx).Info("Finished successfully!")
+		return nil
+	})
+	return releasesCommand
+}
+
+func runVersions(ctx context.Context, config *configs.Config) error {
+	versions := getComponentVersions(ctx, config)
+
+	switch flagVersionsOutput {
+	case "json":
+		return writeJSON(os.Stdout, versions)
+	case "console":
+		writeVersionsTable(os.Stdout, versions)
+		return nil
+	}
+	return errors.Errorf("unknown output format - %s", flagVersionsOutput)
+}
+
+func runRemoteVersions(ctx context.Context, config *configs.Config) error {
+	getVersionsCommand := func() (string, error) {
+		versionsOptions := fmt.Sprintf(" --output %s", flagVersionsOutput)
+		if len(config.PastelExecDir) > 0 {
+			versionsOptions = fmt.Sprintf("%s --dir %s", versionsOptions, config.PastelExecDir)
+		}
+		if len(config.WorkingDir) > 0 {
+			versionsOptions = fmt.Sprintf("%s --work-dir %s", versionsOptions, config.WorkingDir)
+		}
+		// remote pastelup of older versions logs to stdout, which would break json output
+		if config.Quiet || flagVersionsOutput == "json" {
+			versionsOptions = fmt.Sprintf("%s -q", versionsOptions)
+		}
+		if len(config.LogLevel) > 0 {
+			versionsOptions = fmt.Sprintf("%s --log-level %s", versionsOptions, config.LogLevel)
+		}
+		return fmt.Sprintf("%s versions%s", constants.RemotePastelupPath, versionsOptions), nil
+	}
+
+	if flagVersionsOutput == "json" {
+		return printRemoteResultsJSON(ctx, config, getVersionsCommand)
+	}
+	if err := executeRemoteCommandWithInventory(ctx, config, getVersionsCommand, false); err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to get versions from remote hosts")
+		return err
+	}
+	return nil
+}
+
+func runReleases(ctx context.Context, config *configs.Config) error {
+	releases, err := getComponentReleases(ctx, config, flagReleasesLimit)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).Error("Failed to get releases")
+		return err
+	}
+
+	switch flagVersionsOutput {
+	case "json":
+		return writeJSON(os.Stdout, releases)
+	case "console":
+		writeReleasesTable(os.Stdout, releases)
+		return nil
+	}
+	return errors.Errorf("unknown output format - %s", flagVersionsOutput)
+}
+
+// getComponentVersions returns versions of pastelup and installed components
+func getComponentVersions(ctx context.Context, config *configs.Config) []componentVersion {
+	versions := []componentVersion{{Component: string(constants.Pastelup), Version: version.Version()}}
+	if exePath, err := os.Executable(); err == nil {
+		versions[0].Path = exePath
+	}
+
+	for _, tool := range verifyComponents {
+		v := componentVersion{Component: string(tool), Version: notInstalled}
+
+		var err error
+		if tool == constants.DDService {
+			v.Path = filepath.Join(config.PastelExecDir, constants.DupeDetectionSubFolder)
+			if utils.CheckFileExist(v.Path) {
+				v.Version, err = getDDServiceVersion(v.Path)
+			}
+		} else {
+			v.Path = getVerifyExecPaths(config, tool)[0]
+			if utils.CheckFileExist(v.Path) {
+				v.Version, err = getExecutableVersion(ctx, v.Path)
+			}
+		}
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Debugf("Failed to get version of %s", tool)
+			v.Version = "unknown"
+			v.Error = err.Error()
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// getExecutableVersion returns version reported by `<exec> --version`
+func getExecutableVersion(ctx context.Context, execPath string) (string, error) {
+	// not RunCMD, output must not be echoed
+	ctx, cancel := context.WithTimeout(ctx, versionCmdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, execPath, "--version").CombinedOutput()
+	if err != nil {
+		return "", errors.Errorf("--version failed: %v", err)
+	}
+	if v := versionRegexp.Find(out); len(v) != 0 {
+		return string(v), nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines[0]) == 0 {
+		return "", errors.New("--version returned nothing")
+	}
+	return strings.TrimSpace(lines[0]), nil
+}
+
+// getDDServiceVersion returns version of dd-service from the python package metadata in its folder
+func getDDServiceVersion(ddDir string) (string, error) {
+	for _, name := range ddMetadataFiles {
+		data, err := ioutil.ReadFile(filepath.Join(ddDir, name))
+		if err != nil {
+			continue
+		}
+		if name == "VERSION" || name == "version.txt" {
+			if v := strings.TrimSpace(string(data)); len(v) != 0 {
+				return v, nil
+			}
+			continue
+		}
+		if match := ddVersionRegexp.FindStringSubmatch(string(data)); match != nil {
+			if len(match[1]) != 0 {
+				return match[1], nil
+			}
+			return match[2], nil
+		}
+	}
+	return "", errors.Errorf("no package metadata with version found in %s", ddDir)
+}
+
+// getComponentReleases returns published releases of each component from the newest to the oldest,
+// limit is the number of the newest releases to check, 0 - all
+func getComponentReleases(ctx context.Context, config *configs.Config, limit int) ([]componentReleases, error) {
+	versions, err := getReleaseVersions(ctx, releaseIndexTimeout)
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(versions)-1; i < j; i, j = i+1, j-1 {
+		versions[i], versions[j] = versions[j], versions[i]
+	}
+	if limit > 0 && len(versions) > limit {
+		versions = versions[:limit]
+	}
+
+	releases := make([]componentReleases, len(releaseComponents))
+	for i, tool := range releaseComponents {
+		releases[i] = componentReleases{Component: string(tool), Releases: []string{}}
+	}
+
+	for _, v := range versions {
+		log.WithContext(ctx).Debugf("Checking release %s", v)
+
+		// listings of the release folder and its sub folders
+		listings := make(map[string]map[string]bool)
+		getListing := func(dir string) map[string]bool {
+			if listing, ok := listings[dir]; ok {
+				return listing
+			}
+			listing := make(map[string]bool)
+			entries, err := getListingEntries(fmt.Sprintf("%s/%s/", constants.DownloadBaseURL, dir), releaseIndexTimeout)
+			if err != nil {
+				log.WithContext(ctx).WithError(err).Warnf("Failed to get listing of %s", dir)
+			}
+			for _, entry := range entries {
+				listing[entry] = true
+			}
+			listings[dir] = listing
+			return listing
+		}
+
+		for i, tool := range releaseComponents {
+			downloadURL, name, err := config.Configurer.GetDownloadURL(v, tool)
+			if err != nil {
+				return nil, err
+			}
+			dir := strings.TrimPrefix(path.Dir(downloadURL.Path), "/")
+			if getListing(dir)[name] {
+				releases[i].Releases = append(releases[i].Releases, v)
+			}
+		}
+	}
+	return releases, nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeVersionsTable(w io.Writer, versions []componentVersion) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Component", "Version", "Path"})
+	table.SetAutoWrapText(false)
+	for _, v := range versions {
+		ver := v.Version
+		if len(v.Error) != 0 {
+			ver = fmt.Sprintf("%s (%s)", v.Version, v.Error)
+		}
+		table.Append([]string{v.Component, ver, v.Path})
+	}
+	table.Render()
+}
+
+func writeReleasesTable(w io.Writer, releases []componentReleases) {
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Component", "Latest", "Releases"})
+	table.SetAutoWrapText(false)
+	for _, r := range releases {
+		latest := ""
+		for _, v := range r.Releases {
+			if !utils.IsPreRelease(v) {
+				latest = v
+				break
+			}
+		}
+		table.Append([]string{r.Component, latest, strings.Join(r.Releases, ", ")})
+	}
+	table.Render()
+}
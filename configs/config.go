@@ -26,7 +26,7 @@ rq-files-dir: {{.RQDir}}
 node:
   api:
     hostname: "localhost"
-    port: 8080
+    port: {{.APIPort}}
   burn_address: {{.BurnAddress}} 
 raptorq:
   host: "localhost"
@@ -62,12 +62,12 @@ sn_port: {{.SNPort}}`
 	// BridgeDefaultConfig - default config for bridge
 	BridgeDefaultConfig = `
 log-config:
-log-level: {{.LogLevel}}
-log-file: {{.LogFilePath}}
-log-compress: {{.LogCompress}}
-log-max-size-mb: {{.LogMaxSizeMB}}
-log-max-age-days: {{.LogMaxAgeDays}}
-log-max-backups: {{.LogMaxBackups}}
+  log-level: {{.LogLevel}}
+  log-file: {{.LogFilePath}}
+  log-compress: {{.LogCompress}}
+  log-max-size-mb: {{.LogMaxSizeMB}}
+  log-max-age-days: {{.LogMaxAgeDays}}
+  log-max-backups: {{.LogMaxBackups}}
 quiet: true
 temp-dir: {{.WNTempDir}}
 work-dir: {{.WNWorkDir}}
@@ -156,17 +156,114 @@ python3 -m  http.server 80`
 	// SystemdService - /etc/systemd/sysstem/rq-service.service
 	SystemdService = `[Unit]
 Description={{.Desc}}
-    
+{{- if .After}}
+After={{.After}}
+{{- end}}
+{{- if .Wants}}
+Wants={{.Wants}}
+{{- end}}
+{{- if .Requires}}
+Requires={{.Requires}}
+{{- end}}
+
 [Service]
 Type=simple
 Restart=always
 RestartSec=10
 WorkingDirectory={{.WorkDir}}
+EnvironmentFile=-{{.EnvFile}}
 ExecStart={{.ExecCmd}}
+{{- if .User}}
 User={{.User}}
+{{- end}}
+{{- if .LimitNOFile}}
+LimitNOFILE={{.LimitNOFile}}
+{{- end}}
+{{- if .Hardening}}
+NoNewPrivileges=true
+ProtectSystem=full
+PrivateTmp=true
+{{- end}}
 
 [Install]
-WantedBy=multi-user.target
+WantedBy={{.WantedBy}}
+`
+
+	// SupervisordProgram - /etc/supervisor/conf.d/pastel-rq-service.conf
+	SupervisordProgram = `[program:{{.Name}}]
+command={{.ExecCmd}}
+directory={{.WorkDir}}
+user={{.User}}
+autostart={{.AutoStart}}
+autorestart=true
+startsecs=10
+stopasgroup=true
+killasgroup=true
+`
+
+	// OpenRCService - /etc/init.d/pastel-rq-service
+	OpenRCService = `#!/sbin/openrc-run
+
+description="{{.Desc}}"
+supervisor=supervise-daemon
+command="{{.Command}}"
+command_args="{{.Args}}"
+command_user="{{.User}}"
+directory="{{.WorkDir}}"
+respawn_delay=10
+
+depend() {
+	need net
+}
+`
+
+	// DockerCompose - docker-compose.yml of the generated docker deployment
+	DockerCompose = `version: "3.9"
+
+services:
+{{- range .Services}}
+  {{.Name}}:
+    build: .
+    image: {{$.Image}}
+    restart: unless-stopped
+{{- if .NetworkMode}}
+    network_mode: "{{.NetworkMode}}"
+{{- end}}
+{{- if .Ports}}
+    ports:
+{{- range .Ports}}
+      - "{{.}}:{{.}}"
+{{- end}}
+{{- end}}
+{{- if .DependsOn}}
+    depends_on:
+{{- range .DependsOn}}
+      - {{.}}
+{{- end}}
+{{- end}}
+    command: {{.Command}}
+    volumes:
+{{- range $.Mounts}}
+      - {{.}}
+{{- end}}
+{{- end}}
+
+volumes:
+{{- range .Volumes}}
+  {{.}}:
+{{- end}}
+`
+
+	// Dockerfile - Dockerfile of the generated docker deployment
+	Dockerfile = `FROM ubuntu:20.04
+ARG DEBIAN_FRONTEND=noninteractive
+RUN apt-get update \
+  && apt-get install -y wget curl libgomp1 iputils-ping zip unzip sudo python3-pip python3-venv \
+  && rm -rf /var/lib/apt/lists/*
+COPY ./pastelup /pastelup
+RUN chmod 755 /pastelup \
+  && yes | /pastelup install {{.Component}} --force --network={{.Network}}{{if .Release}} --release={{.Release}}{{end}}{{if .Peers}} --peers={{.Peers}}{{end}}
+WORKDIR {{.WorkDir}}
 `
 )
 
@@ -204,6 +301,7 @@ type WalletNodeConfig struct {
 	BurnAddress   string
 	BridgePort    int
 	BridgeOn      bool
+	APIPort       int
 }
 
 // SuperNodeConfig defines configurations for supernode
@@ -273,12 +371,63 @@ type DDImgServerStartScript struct {
 
 // SystemdServiceScript defines service file for /etc/systemd/system
 type SystemdServiceScript struct {
-	ExecCmd string
+	ExecCmd     string
+	Desc        string
+	WorkDir     string
+	User        string
+	WantedBy    string
+	After       string
+	Wants       string
+	Requires    string
+	EnvFile     string
+	LimitNOFile int
+	Hardening   bool
+}
+
+// SupervisordProgramScript defines program file for supervisord
+type SupervisordProgramScript struct {
+	Name      string
+	ExecCmd   string
+	WorkDir   string
+	User      string
+	AutoStart bool
+}
+
+// OpenRCServiceScript defines service file for /etc/init.d
+type OpenRCServiceScript struct {
 	Desc    string
+	Command string
+	Args    string
 	WorkDir string
 	User    string
 }
 
+// DockerComposeScript defines docker-compose.yml of the generated docker deployment
+type DockerComposeScript struct {
+	Image    string
+	Services []DockerComposeService
+	Mounts   []string
+	Volumes  []string
+}
+
+// DockerComposeService defines service of the component in docker-compose.yml
+type DockerComposeService struct {
+	Name        string
+	Command     string
+	NetworkMode string
+	Ports       []int
+	DependsOn   []string
+}
+
+// DockerfileScript defines Dockerfile of the generated docker deployment
+type DockerfileScript struct {
+	Component string
+	Network   string
+	Release   string
+	Peers     string
+	WorkDir   string
+}
+
 // ZksnarkParamsNamesV2 - slice of zksnark parameters
 var ZksnarkParamsNamesV2 = []string{
 	"sapling-spend.params",
@@ -1,5 +1,7 @@
 package configs
 
+import "time"
+
 // Init contains config of the Init command
 type Init struct {
 	WorkingDir      string `json:"workdir,omitempty"`
@@ -28,6 +30,19 @@ type Init struct {
 	ServiceTool     string `json:"tool,omitempty"`
 	ServiceSolution string `json:"solution,omitempty"`
 	DevMode         bool   `json:"devmode,omitempty"`
+	Ports           string `json:"ports,omitempty"`
+	Instance        string `json:"instance,omitempty"`
+	Firewall        string `json:"firewall,omitempty"`
+	ServiceManager  string `json:"service-manager,omitempty"`
+	IgnoreCompat    bool   `json:"ignore-compat,omitempty"`
+	SkipVerify      bool   `json:"skip-verify,omitempty"`
+
+	// SyncTimeout is how long to wait for the node to sync, 0 - wait until synced
+	SyncTimeout time.Duration `json:"sync-timeout,omitempty"`
+
+	// DefaultConfigs renders configs of the components with default ports and without user overrides,
+	// ports.json and overrides of the working directory are ignored (e.g. configs of docker containers)
+	DefaultConfigs bool `json:"-"`
 
 	// Configs for remote session
 	RemoteHotHomeDir       string `json:"remotehomedir,omitempty"`
@@ -38,6 +53,8 @@ type Init struct {
 	RemoteUser             string `json:"remote-user,omitempty"`
 	RemoteSSHKey           string `json:"remote-ssh-key,omitempty"`
 	InventoryFile          string `json:"inventory-file,omitempty"`
+	InventoryLimit         string `json:"inventory-limit,omitempty"`
+	InventoryTags          string `json:"inventory-tags,omitempty"`
 }
 
 /*
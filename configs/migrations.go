@@ -0,0 +1,56 @@
+package configs
+
+import (
+	"github.com/pastelnetwork/pastelup/constants"
+	"github.com/pastelnetwork/pastelup/utils"
+)
+
+// ConfigVersionHeader is the comment line prefix which keeps template version of the generated component config
+const ConfigVersionHeader = "# config-version: "
+
+// ConfigMigration describes changes of the component config template introduced in the specific version
+type ConfigMigration struct {
+	Version int
+	Renames []utils.KeyRename
+}
+
+var (
+	// ConfigVersions - current template versions of the generated component configs.
+	// Should be increased together with adding the migration when template is changed
+	ConfigVersions = map[string]int{
+		string(constants.SuperNode):  1,
+		string(constants.Hermes):     1,
+		string(constants.WalletNode): 1,
+		string(constants.Bridge):     2,
+	}
+
+	// ConfigMigrations - migration steps of the component configs, keys added to the template are filled from it
+	// automatically, so only renamed or moved keys have to be listed here
+	ConfigMigrations = map[string][]ConfigMigration{
+		string(constants.Bridge): {
+			{
+				// log options were generated at the top level instead of log-config section
+				Version: 2,
+				Renames: []utils.KeyRename{
+					{From: "log-level", To: "log-config.log-level"},
+					{From: "log-file", To: "log-config.log-file"},
+					{From: "log-compress", To: "log-config.log-compress"},
+					{From: "log-max-size-mb", To: "log-config.log-max-size-mb"},
+					{From: "log-max-age-days", To: "log-config.log-max-age-days"},
+					{From: "log-max-backups", To: "log-config.log-max-backups"},
+				},
+			},
+		},
+	}
+)
+
+// GetConfigRenames returns key renames of the component config migrations newer than the fromVersion
+func GetConfigRenames(toolName string, fromVersion int) []utils.KeyRename {
+	var renames []utils.KeyRename
+	for _, migration := range ConfigMigrations[toolName] {
+		if migration.Version > fromVersion {
+			renames = append(renames, migration.Renames...)
+		}
+	}
+	return renames
+}
@@ -241,3 +241,8 @@ func NewConfigurer() (IConfigurer, error) {
 		return nil, errors.New("unknown os")
 	}
 }
+
+// NewContainerConfigurer returns a configurer of the Linux container with the given home directory
+func NewContainerConfigurer(homeDir string) IConfigurer {
+	return newLinuxConfigurer(homeDir)
+}
@@ -3,6 +3,7 @@ package constants
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 )
 
 // OSType - Windows, Linux, MAC, Unknown
@@ -18,6 +19,21 @@ const (
 	// DownloadBaseURL - The base URL of the pastel release files
 	DownloadBaseURL string = "https://download.pastel.network"
 
+	// ReleaseHistoryDir - The sub url of the published releases, each release is in its own sub folder
+	ReleaseHistoryDir string = "history"
+
+	// CompatManifestName - The manifest of the release with compatible versions of the components
+	CompatManifestName string = "compatibility.json"
+
+	// UpdateCheckFileName - The file in the home dir where the result of the last check for the new pastelup version is cached
+	UpdateCheckFileName string = ".pastelup_update_check.json"
+
+	// UpdateCheckInterval - How often pastelup checks for its new version on startup
+	UpdateCheckInterval = 24 * time.Hour
+
+	// NoUpdateCheckEnvName - The environment variable to turn off the check for the new pastelup version on startup
+	NoUpdateCheckEnvName string = "PASTELUP_NO_UPDATE_CHECK"
+
 	// PastelConfName - pastel config file name
 	PastelConfName string = "pastel.conf"
 
@@ -93,6 +109,20 @@ const (
 	SystemdServicePrefix = "pastel-"
 	// SystemdSystemDir location of systemd folder in Linux system
 	SystemdSystemDir = "/etc/systemd/system"
+	// SystemdUserDir location of systemd user units relative to the home folder
+	SystemdUserDir = ".config/systemd/user"
+	// SupervisordDebianDir location of supervisord programs in Debian based systems
+	SupervisordDebianDir = "/etc/supervisor/conf.d"
+	// SupervisordRHELDir location of supervisord programs in RHEL based systems
+	SupervisordRHELDir = "/etc/supervisord.d"
+	// SystemdLimitNOFile defines limit of open files of the system services
+	SystemdLimitNOFile = 65536
+	// SystemdOverridesDropIn defines name of the drop-in file with user overrides of the service unit
+	SystemdOverridesDropIn = "pastelup-overrides.conf"
+	// ServiceEnvDir defines location in the working directory of environment files of the services
+	ServiceEnvDir = "env"
+	// OpenRCInitDir location of OpenRC init scripts
+	OpenRCInitDir = "/etc/init.d"
 
 	// RQServiceDir defines location for rq-service file exchange dir
 	RQServiceDir = "rqfiles"
@@ -100,6 +130,8 @@ const (
 	P2PDataDir = "p2pdata"
 	// MDLDataDir defines location for MDL data dir
 	MDLDataDir = "mdldata"
+	// ConfigOverridesDir defines location for user overrides of components configs
+	ConfigOverridesDir = "overrides"
 
 	// LogConfigDefaultCompress defines supernode log compress
 	LogConfigDefaultCompress = true
@@ -131,6 +163,16 @@ const (
 	// DDServerDefaultPort defines dd-server port
 	DDServerDefaultPort = 50052
 
+	// WalletNodeAPIDefaultPort defines walletnode API port
+	WalletNodeAPIDefaultPort = 8080
+
+	// PortsFileName defines file in the working directory with custom ports of the components
+	PortsFileName = "ports.json"
+	// ServicesFileName is the file in the working directory with the service manager used to register services
+	ServicesFileName = "services.json"
+	// ContainerHomeDir is the home directory of the user in the generated docker containers
+	ContainerHomeDir = "/root"
+
 	// StorageChallengeExpiredDuration defines expired duration storage challenge process
 	StorageChallengeExpiredDuration = "3m"
 
@@ -140,10 +182,34 @@ const (
 	// TempDir defines temporary directory
 	TempDir = "tmp"
 
-	// RemotePastelupPath - Remote pastelup path
-	RemotePastelupPath = "/tmp/pastelup"
+	// RemotePastelupPath - Remote pastelup path, in the dir owned by the remote user, $HOME is expanded by the remote shell
+	RemotePastelupPath = "$HOME/.pastelup/bin/pastelup"
+
+	// CollateralDefaultConfirmations defines confirmation depth required for masternode collateral
+	CollateralDefaultConfirmations = 6
+	// CollateralDefaultTimeout defines how long to wait for masternode collateral transaction
+	CollateralDefaultTimeout = 30 * time.Minute
+	// CollateralPollInterval defines how often to check masternode collateral transaction
+	CollateralPollInterval = 10 * time.Second
+
+	// BlocksPerDay defines expected number of blocks per day (2.5 minutes block time)
+	BlocksPerDay = 576
 )
 
+// MasternodeCollateralAmount - Required masternode collateral amount per network
+var MasternodeCollateralAmount = map[string]float64{
+	NetworkMainnet: 5000000,
+	NetworkTestnet: 1000000,
+	NetworkRegTest: 100000,
+}
+
+// CoinName - Coin ticker per network
+var CoinName = map[string]string{
+	NetworkMainnet: "PSL",
+	NetworkTestnet: "LSP",
+	NetworkRegTest: "REG",
+}
+
 // ServiceName defines services name
 var ServiceName = map[ToolType]map[OSType]string{
 	PastelD:    PasteldName,
@@ -277,6 +343,48 @@ const (
 	RAFTPort    int = 5
 )
 
+// Port names of the components, used in --ports option and ports.json
+const (
+	PortNameNode          = "node"
+	PortNameNodeRPC       = "rpc"
+	PortNameSuperNode     = "supernode"
+	PortNameP2P           = "p2p"
+	PortNameMetaDB        = "metadb"
+	PortNameRaft          = "raft"
+	PortNameRQService     = "rq-service"
+	PortNameDDServer      = "dd-server"
+	PortNameBridge        = "bridge"
+	PortNameWalletNodeAPI = "walletnode-api"
+)
+
+// PortNames - names of all configurable ports
+var PortNames = []string{
+	PortNameNode,
+	PortNameNodeRPC,
+	PortNameSuperNode,
+	PortNameP2P,
+	PortNameMetaDB,
+	PortNameRaft,
+	PortNameRQService,
+	PortNameDDServer,
+	PortNameBridge,
+	PortNameWalletNodeAPI,
+}
+
+// PortOwners - components which listen on the ports
+var PortOwners = map[string]ToolType{
+	PortNameNode:          PastelD,
+	PortNameNodeRPC:       PastelD,
+	PortNameSuperNode:     SuperNode,
+	PortNameP2P:           SuperNode,
+	PortNameMetaDB:        SuperNode,
+	PortNameRaft:          SuperNode,
+	PortNameRQService:     RQService,
+	PortNameDDServer:      DDService,
+	PortNameBridge:        Bridge,
+	PortNameWalletNodeAPI: WalletNode,
+}
+
 // PastelRQServiceExecName - The name of the rqservice executable files
 var PastelRQServiceExecName = map[OSType]string{
 	Windows: "rq-service-win-amd64.exe",
@@ -314,6 +422,12 @@ var DupeDetectionSupportChecksum = map[string]string{
 	"mobilenet_v2_140_224":                    "825a4298a25334201ad5fb29e089fce0258c9a13793cc0d0b6a7dbe9c96ad9f3",
 }
 
+// SnapshotChainDirs - The dirs of pasteld data dir which are included into the blockchain snapshot
+var SnapshotChainDirs = []string{"blocks", "chainstate"}
+
+// SnapshotMetadataName - The file with the info about the blockchain snapshot, stored in the snapshot archive
+const SnapshotMetadataName = "snapshot.json"
+
 // DupeDetectionSupportFilePath - The target path for downloading dupe detection support files
 var DupeDetectionSupportFilePath = "support_files"
 
@@ -323,7 +437,7 @@ func GetVersionSubURL(version string) string {
 	case "latest", "beta":
 		return version
 	default:
-		return fmt.Sprintf("history/%s", version)
+		return fmt.Sprintf("%s/%s", ReleaseHistoryDir, version)
 	}
 }
 
@@ -37,6 +37,20 @@ const (
 	GetNewAddressCmd = "getnewaddress"
 	// TicketsCmd is an RPC command
 	TicketsCmd = "tickets"
+	// GetTransactionCmd is an RPC command
+	GetTransactionCmd = "gettransaction"
+	// ListTransactionsCmd is an RPC command
+	ListTransactionsCmd = "listtransactions"
+	// GetTxOutCmd is an RPC command
+	GetTxOutCmd = "gettxout"
+	// LockUnspentCmd is an RPC command
+	LockUnspentCmd = "lockunspent"
+	// ListLockUnspentCmd is an RPC command
+	ListLockUnspentCmd = "listlockunspent"
+	// GetPeerInfoCmd is an RPC command
+	GetPeerInfoCmd = "getpeerinfo"
+	// GetBlockSubsidyCmd is an RPC command
+	GetBlockSubsidyCmd = "getblocksubsidy"
 )
 
 // RPCRequest represents a jsonrpc request object.
@@ -87,24 +87,116 @@ func (s RPCGetInfo) String() string {
 	return toString(s)
 }
 
+// RPCError is the error field for the RPC command response
+type RPCError struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error returns the RPC error as a string
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// RPCGetTransaction RPC result structure from gettransaction
+type RPCGetTransaction struct {
+	Result GetTransactionResult `json:"result"`
+	Error  *RPCError            `json:"error"`
+}
+
+// GetTransactionResult is the result field for the RPC command response
+type GetTransactionResult struct {
+	TxID          string     `json:"txid"`
+	Amount        float64    `json:"amount"`
+	Confirmations int64      `json:"confirmations"`
+	BlockHash     string     `json:"blockhash"`
+	Time          int64      `json:"time"`
+	Details       []TxDetail `json:"details"`
+}
+
+// TxDetail is the single output entry of gettransaction details
+type TxDetail struct {
+	Address  string  `json:"address"`
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+	Vout     int     `json:"vout"`
+}
+
+// String returns the struct as a string
+func (s RPCGetTransaction) String() string {
+	return toString(s)
+}
+
+// RPCListLockUnspent RPC result structure from listlockunspent
+type RPCListLockUnspent struct {
+	Result []Outpoint `json:"result"`
+	Error  *RPCError  `json:"error"`
+}
+
+// Outpoint is the transaction output reference used by lockunspent
+type Outpoint struct {
+	TxID string `json:"txid"`
+	Vout int    `json:"vout"`
+}
+
+// RPCGetTxOut RPC result structure from gettxout, Result is nil when the output is spent
+type RPCGetTxOut struct {
+	Result *GetTxOutResult `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// GetTxOutResult is the result field for the RPC command response
+type GetTxOutResult struct {
+	BestBlock     string  `json:"bestblock"`
+	Confirmations int64   `json:"confirmations"`
+	Value         float64 `json:"value"`
+}
+
+// RPCLockUnspent RPC result structure from lockunspent
+type RPCLockUnspent struct {
+	Result bool      `json:"result"`
+	Error  *RPCError `json:"error"`
+}
+
+// RPCListTransactions RPC result structure from listtransactions
+type RPCListTransactions struct {
+	Result []TxInfo  `json:"result"`
+	Error  *RPCError `json:"error"`
+}
+
 // TxInfo Transaction information
 type TxInfo struct {
-	Account         string
-	Address         string
-	Category        string
-	Amount          float64
-	Vout            uint64
-	Confirmation    uint64
-	BlockHash       string
-	BlockIndex      uint64
-	BlockTime       uint64
-	Expiryheight    uint64
-	TxID            string
-	WalletConflicts []string
-	Time            uint64
-	TimeReceived    uint64
-	Vjoinsplit      []string
-	Size            uint64
+	Account         string        `json:"account"`
+	Address         string        `json:"address"`
+	Category        string        `json:"category"`
+	Amount          float64       `json:"amount"`
+	Vout            uint64        `json:"vout"`
+	Confirmation    uint64        `json:"confirmations"`
+	BlockHash       string        `json:"blockhash"`
+	BlockIndex      uint64        `json:"blockindex"`
+	BlockTime       uint64        `json:"blocktime"`
+	Expiryheight    uint64        `json:"expiryheight"`
+	TxID            string        `json:"txid"`
+	WalletConflicts []string      `json:"walletconflicts"`
+	Time            uint64        `json:"time"`
+	TimeReceived    uint64        `json:"timereceived"`
+	Vjoinsplit      []interface{} `json:"vjoinsplit"`
+	Size            uint64        `json:"size"`
+}
+
+// RPCGetPeerInfo RPC result structure from getpeerinfo
+type RPCGetPeerInfo struct {
+	Result []PeerInfo `json:"result"`
+	Error  *RPCError  `json:"error"`
+}
+
+// PeerInfo is the information about the connected peer
+type PeerInfo struct {
+	Addr           string `json:"addr"`
+	Inbound        bool   `json:"inbound"`
+	StartingHeight int    `json:"startingheight"`
+	SyncedHeaders  int    `json:"synced_headers"`
+	SyncedBlocks   int    `json:"synced_blocks"`
 }
 
 func toString(s interface{}) string {
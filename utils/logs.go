@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LogLevels are levels of the log records from the lowest to the highest
+var LogLevels = []string{"trace", "debug", "info", "warn", "error", "fatal"}
+
+const defaultLogLevel = 2 // info
+
+var (
+	// [Jan 02 15:04:05.000] - log files of gonode components (supernode, walletnode, hermes, bridge)
+	gonodeTimeRegexp = regexp.MustCompile(`^\[([A-Z][a-z]{2} \d{2} \d{2}:\d{2}:\d{2}\.\d{3})\]`)
+	// 2006-01-02 15:04:05 - debug.log of pasteld, the time is UTC
+	pastelDTimeRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})\s`)
+	// 2006-01-02T15:04:05+0000 - journalctl -o short-iso
+	isoTimeRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2}))`)
+
+	logLevelRegexp = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL|PANIC|CRITICAL)\b`)
+	logLevelNames  = map[string]int{
+		"TRACE": 0, "DEBUG": 1, "INFO": 2, "WARN": 3, "WARNING": 3, "ERROR": 4, "FATAL": 5, "PANIC": 5, "CRITICAL": 5,
+	}
+)
+
+// LogRecord is the log line with the following lines without timestamp, e.g. stack trace
+type LogRecord struct {
+	Time time.Time
+	// Level is index in LogLevels, records without level are info
+	Level int
+	Text  string
+}
+
+// ParseLogLevel returns index of the level name in LogLevels
+func ParseLogLevel(name string) (int, error) {
+	for i, level := range LogLevels {
+		if strings.EqualFold(name, level) {
+			return i, nil
+		}
+	}
+	return 0, errors.Errorf("unknown log level %q, must be one of: %s", name, strings.Join(LogLevels, ", "))
+}
+
+// ParseLogTime returns time of the log line, false if the line has no timestamp.
+// Timestamps without year are in the last 12 months before now
+func ParseLogTime(line string, now time.Time) (time.Time, bool) {
+	if m := gonodeTimeRegexp.FindStringSubmatch(line); m != nil {
+		t, err := time.ParseInLocation("2006 Jan 02 15:04:05.000", now.Format("2006 ")+m[1], time.Local)
+		if err != nil {
+			return time.Time{}, false
+		}
+		if t.After(now.Add(24 * time.Hour)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+	if m := pastelDTimeRegexp.FindStringSubmatch(line); m != nil {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", m[1], time.UTC)
+		return t, err == nil
+	}
+	if m := isoTimeRegexp.FindStringSubmatch(line); m != nil {
+		for _, layout := range []string{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05-0700"} {
+			if t, err := time.Parse(layout, m[1]); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseLogLineLevel returns level of the log line, info if the line has no level
+func parseLogLineLevel(line string) int {
+	if m := logLevelRegexp.FindString(line); len(m) != 0 {
+		return logLevelNames[m]
+	}
+	return defaultLogLevel
+}
+
+// LogParser splits log lines into records, lines without timestamp are added to the previous record
+type LogParser struct {
+	now     time.Time
+	pending *LogRecord
+}
+
+// NewLogParser returns a new LogParser
+func NewLogParser() *LogParser {
+	return &LogParser{now: time.Now()}
+}
+
+// Add adds the line, returns the previous record if the line starts a new one
+func (p *LogParser) Add(line string) *LogRecord {
+	line = strings.TrimRight(line, "\r\n")
+	t, ok := ParseLogTime(line, p.now)
+	if !ok && p.pending != nil {
+		p.pending.Text += "\n" + line
+		return nil
+	}
+
+	// lines before the first timestamp, e.g. the log is read from the middle, have zero time
+	record := p.pending
+	p.pending = &LogRecord{Time: t, Level: parseLogLineLevel(line), Text: line}
+	return record
+}
+
+// Flush returns the pending record, nil if there is no one
+func (p *LogParser) Flush() *LogRecord {
+	record := p.pending
+	p.pending = nil
+	return record
+}
+
+// ReadLogRecords reads all records of the log
+func ReadLogRecords(r io.Reader) ([]LogRecord, error) {
+	var records []LogRecord
+	parser := NewLogParser()
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if record := parser.Add(scanner.Text()); record != nil {
+			records = append(records, *record)
+		}
+	}
+	if record := parser.Flush(); record != nil {
+		records = append(records, *record)
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListenState is state of the listening socket in /proc/net/tcp
+const tcpListenState = "0A"
+
+// PortListener describes process which listens on the TCP port
+type PortListener struct {
+	PID  int
+	Name string
+}
+
+// String returns process description
+func (l *PortListener) String() string {
+	if l.PID == 0 {
+		return "unknown process"
+	}
+	return fmt.Sprintf("%s (pid %d)", l.Name, l.PID)
+}
+
+// GetPortListener checks if TCP port is already bound on the host.
+// Returns nil if port is free, otherwise process which owns it (PID is 0 when the owner cannot be found)
+func GetPortListener(port int) *PortListener {
+	inodes, err := getListenSocketInodes(port)
+	if err != nil {
+		// no procfs - just try to bind the port
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+		if err != nil {
+			return &PortListener{}
+		}
+		ln.Close()
+		return nil
+	}
+	if len(inodes) == 0 {
+		return nil
+	}
+
+	procs, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range procs {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		if !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid, _ := strconv.Atoi(filepath.Base(pidDir))
+		name, _ := ioutil.ReadFile(filepath.Join(pidDir, "comm"))
+		return &PortListener{PID: pid, Name: strings.TrimSpace(string(name))}
+	}
+	// socket of the process of another user
+	return &PortListener{}
+}
+
+// getListenSocketInodes returns inodes of the sockets listening on the port from /proc/net/tcp and /proc/net/tcp6
+func getListenSocketInodes(port int) (map[string]bool, error) {
+	inodes := make(map[string]bool)
+	found := false
+	for _, name := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		found = true
+
+		scanner := bufio.NewScanner(f)
+		scanner.Scan() // header
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 10 || fields[3] != tcpListenState {
+				continue
+			}
+			idx := strings.LastIndex(fields[1], ":")
+			localPort, err := strconv.ParseInt(fields[1][idx+1:], 16, 32)
+			if err == nil && int(localPort) == port {
+				inodes[fields[9]] = true
+			}
+		}
+		f.Close()
+	}
+	if !found {
+		return nil, os.ErrNotExist
+	}
+	return inodes, nil
+}
@@ -178,6 +178,34 @@ func (c *Client) Scp(srcFile string, destFile string, perm string) error {
 	return nil
 }
 
+// ScpFrom copies remote file to the local host. The file is streamed over the ssh session,
+// because scp client doesn't reliably report errors of the download (e.g. missing remote file)
+func (c *Client) ScpFrom(srcFile string, destFile string) error {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return errors.Errorf("failed to create ssh session: %v", err)
+	}
+	defer session.Close()
+
+	tmpFile := destFile + ".tmp"
+	f, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Errorf("failed to create %s file: %v", tmpFile, err)
+	}
+	defer os.Remove(tmpFile)
+
+	var stderr bytes.Buffer
+	session.Stdout = f
+	session.Stderr = &stderr
+	err = session.Run(fmt.Sprintf(`cat "%s"`, srcFile))
+	f.Close()
+	if err != nil {
+		return errors.Errorf("failed to transfer file: %v: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return os.Rename(tmpFile, destFile)
+}
+
 // ScriptFile creates a RemoteScript that can read a local script file and run it remotely on the client.
 func (c *Client) ScriptFile(fname string) *RemoteScript {
 	return &RemoteScript{
@@ -206,6 +234,84 @@ func (c *Client) ShellCmd(ctx context.Context, cmd string) error {
 	return c.Shell().SetStdio(stdin, stdout, stderr).Start()
 }
 
+// ShellCmdOutput executes a remote command like ShellCmd, but writes its output to stdout and stderr
+// and returns its exit status, the error is returned only if the command couldn't be executed
+func (c *Client) ShellCmdOutput(ctx context.Context, cmd string, stdout, stderr io.Writer) (int, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+
+	session.Stdin = bytes.NewBufferString(cmd)
+	session.Stdout = stdout
+	session.Stderr = stderr
+	if err := session.Shell(); err != nil {
+		return -1, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case err = <-done:
+	}
+
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+// PrefixWriter writes every line to the underlying writer with the prefix, e.g. to tell output of the hosts apart
+type PrefixWriter struct {
+	w      io.Writer
+	prefix []byte
+	buf    []byte
+}
+
+// NewPrefixWriter returns a new PrefixWriter
+func NewPrefixWriter(w io.Writer, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+// Write writes complete lines of p, the rest is kept until the line is completed or Flush is called
+func (pw *PrefixWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexByte(pw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := pw.writeLine(pw.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		pw.buf = pw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes the last incomplete line
+func (pw *PrefixWriter) Flush() error {
+	if len(pw.buf) == 0 {
+		return nil
+	}
+	line := append(pw.buf, '\n')
+	pw.buf = nil
+	return pw.writeLine(line)
+}
+
+func (pw *PrefixWriter) writeLine(line []byte) error {
+	_, err := pw.w.Write(append(append([]byte{}, pw.prefix...), line...))
+	return err
+}
+
 // A RemoteScript represents script that can be run remotely.
 type RemoteScript struct {
 	client     *ssh.Client
@@ -20,7 +20,9 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"text/template"
@@ -142,17 +144,43 @@ func CreateAndWrite(ctx context.Context, force bool, filePath string, fileConten
 type WriteCounter struct {
 	Total   uint64
 	Context context.Context
+
+	lastLogged time.Time
+}
+
+// progressLogInterval is how often the progress is logged when stdout is not a terminal
+const progressLogInterval = 10 * time.Second
+
+// IsTerminal checks if the file is a terminal, progress lines overwriting themselves are only printed to terminals
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
 
 // Write wites the number of bytes written to it.
 func (wc *WriteCounter) Write(p []byte) (int, error) {
 	n := len(p)
 	wc.Total += uint64(n)
-	wc.PrintProgress()
+	if IsTerminal(os.Stdout) {
+		wc.PrintProgress()
+	} else if time.Since(wc.lastLogged) >= progressLogInterval {
+		// log files and pipes get a line now and then instead of the line overwriting itself
+		wc.lastLogged = time.Now()
+		log.WithContext(wc.Context).Infof("Downloading... %s complete", humanize.Bytes(wc.Total))
+	}
 
 	return n, nil
 }
 
+// Finish ends the progress line once the download is finished
+func (wc *WriteCounter) Finish() {
+	if IsTerminal(os.Stdout) {
+		// The progress use the same line so print a new line once it's finished downloading
+		fmt.Print("\n")
+	} else {
+		log.WithContext(wc.Context).Infof("Downloaded %s", humanize.Bytes(wc.Total))
+	}
+}
+
 // PrintProgress make logs of the downloading file.
 func (wc WriteCounter) PrintProgress() {
 	// Clear the line by using a character return to go back to the start and remove
@@ -196,14 +224,72 @@ func DownloadFile(ctx context.Context, filepath string, url string) error {
 		return errors.Errorf("write file failed: %v", err)
 	}
 
-	// The progress use the same line so print a new line once it's finished downloading
-	fmt.Print("\n")
+	counter.Finish()
 	// Close the file without defer so it can happen before Rename()
 	out.Close()
 
 	return os.Rename(filepath+".tmp", filepath)
 }
 
+// DownloadFileResume downloads url to the file like DownloadFile, but if the previous download was interrupted,
+// it continues the download from the end of the partially downloaded .tmp file
+func DownloadFileResume(ctx context.Context, filePath string, url string) error {
+	log.WithContext(ctx).Infof("Download url: %s \n", url)
+
+	tmpPath := filePath + ".tmp"
+	var offset int64
+	if info, err := os.Stat(tmpPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Errorf("failed to create http request: %v", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Errorf("http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		log.WithContext(ctx).Infof("Resuming download from %s", humanize.Bytes(uint64(offset)))
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// server doesn't support ranges, start from scratch
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			// the file was downloaded completely, but wasn't renamed
+			return os.Rename(tmpPath, filePath)
+		}
+		fallthrough
+	default:
+		return errors.Errorf("download failed: %s", resp.Status)
+	}
+
+	out, err := os.OpenFile(tmpPath, flags, 0644)
+	if err != nil {
+		return err
+	}
+
+	counter := &WriteCounter{Total: uint64(offset), Context: ctx}
+	if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
+		out.Close()
+		return errors.Errorf("write file failed: %v", err)
+	}
+	counter.Finish()
+	out.Close()
+
+	return os.Rename(tmpPath, filePath)
+}
+
 // GetOS gets current OS.
 func GetOS() constants.OSType {
 	os := runtime.GOOS
@@ -292,6 +378,120 @@ func Untar(dst string, r io.Reader, filenames ...string) error {
 	}
 }
 
+// CreateTarGz writes gzipped tar archive of the paths relative to baseDir, directories are added recursively
+func CreateTarGz(dst string, baseDir string, paths []string) (err error) {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	gzw := gzip.NewWriter(out)
+	tw := tar.NewWriter(gzw)
+
+	for _, p := range paths {
+		err = filepath.Walk(filepath.Join(baseDir, p), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				// sockets, links etc. are not part of the data
+				return nil
+			}
+			name, err := filepath.Rel(baseDir, path)
+			if err != nil {
+				return err
+			}
+			header, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(name)
+			if info.IsDir() {
+				header.Name += "/"
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err != nil {
+			return errors.Errorf("failed to archive %s: %v", p, err)
+		}
+	}
+
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gzw.Close()
+}
+
+// ExtractTarGz extracts gzipped tar archive into dst directory, entries pointing outside of it are rejected
+func ExtractTarGz(src string, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gzr, err := gzip.NewReader(f)
+	if err != nil {
+		return errors.Errorf("%s is not gzip archive: %v", src, err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Errorf("failed to read %s: %v", src, err)
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(header.Name))
+		if target != filepath.Clean(dst) && !strings.HasPrefix(target, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return errors.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Unzip will decompress a zip archive, moving all files and folders
 // within the zip file (parameter 1) to an output directory (parameter 2).
 func Unzip(src string, dest string) ([]string, error) {
@@ -574,6 +774,204 @@ func IsValidNetworkOpt(val string) bool {
 	return false
 }
 
+// ParseDuration parses duration like time.ParseDuration, but also accepts days and weeks, e.g. "30d" or "2w"
+func ParseDuration(val string) (time.Duration, error) {
+	val = strings.TrimSpace(val)
+	if len(val) > 1 {
+		var unit time.Duration
+		switch val[len(val)-1] {
+		case 'd':
+			unit = 24 * time.Hour
+		case 'w':
+			unit = 7 * 24 * time.Hour
+		}
+		if unit != 0 {
+			n, err := strconv.Atoi(val[:len(val)-1])
+			if err != nil || n < 0 {
+				return 0, errors.Errorf("invalid duration %q", val)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(val)
+}
+
+// IsValidPastelID checks that val looks like PastelID - base58 encoded string of 86 characters starting with "jX"
+func IsValidPastelID(val string) bool {
+	const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	if len(val) != 86 || !strings.HasPrefix(val, "jX") {
+		return false
+	}
+	for _, c := range val {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrNotFound is returned by GetURL if the server responds with 404
+var ErrNotFound = errors.New("not found")
+
+// GetURL returns body of the response to GET request, it fails if the response is not received within the timeout
+func GetURL(url string, timeout time.Duration) ([]byte, error) {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Errorf("http request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("http request to %s failed: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// CompareVersions compares release versions like "v1.2.3" or "1.2.3-beta1", returns -1, 0 or 1 if a is older,
+// the same or newer than b. Pre-release is older than the release of the same version
+func CompareVersions(a, b string) (int, error) {
+	aNums, aPre, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bNums, bPre, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < len(aNums) || i < len(bNums); i++ {
+		var an, bn int
+		if i < len(aNums) {
+			an = aNums[i]
+		}
+		if i < len(bNums) {
+			bn = bNums[i]
+		}
+		if an != bn {
+			if an < bn {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0, nil
+	case len(aPre) == 0:
+		return 1, nil
+	case len(bPre) == 0:
+		return -1, nil
+	}
+	return comparePreReleases(aPre, bPre), nil
+}
+
+// comparePreReleases compares pre-releases by identifiers separated by dots and by digits (semver §11),
+// so "beta10" is newer than "beta2": numbers are compared numerically and are lower than words,
+// pre-release with fewer identifiers is lower if the others are equal
+func comparePreReleases(a, b string) int {
+	aIDs, bIDs := splitPreRelease(a), splitPreRelease(b)
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		an, bn := aIDs[i], bIDs[i]
+		aNum, bNum := isDigits(an), isDigits(bn)
+		switch {
+		case aNum && bNum:
+			an, bn = strings.TrimLeft(an, "0"), strings.TrimLeft(bn, "0")
+			if len(an) != len(bn) {
+				if len(an) < len(bn) {
+					return -1
+				}
+				return 1
+			}
+		case aNum:
+			return -1
+		case bNum:
+			return 1
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(aIDs) < len(bIDs):
+		return -1
+	case len(aIDs) > len(bIDs):
+		return 1
+	}
+	return 0
+}
+
+// splitPreRelease splits pre-release like "beta10.2" to identifiers "beta", "10", "2"
+func splitPreRelease(pre string) []string {
+	var ids []string
+	for _, part := range strings.Split(pre, ".") {
+		start := 0
+		for i := 1; i <= len(part); i++ {
+			if i == len(part) || isDigits(part[i-1:i]) != isDigits(part[i:i+1]) {
+				ids = append(ids, part[start:i])
+				start = i
+			}
+		}
+	}
+	return ids
+}
+
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPreRelease checks if the version is a pre-release, e.g. "v1.2.3-beta1"
+func IsPreRelease(version string) bool {
+	_, pre, err := parseVersion(version)
+	return err == nil && len(pre) != 0
+}
+
+func parseVersion(version string) ([]int, string, error) {
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	// build metadata doesn't affect the order
+	if idx := strings.Index(v, "+"); idx >= 0 {
+		v = v[:idx]
+	}
+	var pre string
+	if idx := strings.Index(v, "-"); idx >= 0 {
+		v, pre = v[:idx], v[idx+1:]
+	}
+
+	var nums []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, "", errors.Errorf("invalid version %q", version)
+		}
+		nums = append(nums, n)
+	}
+	return nums, pre, nil
+}
+
+// IsDirWritable checks that files can be created in the directory
+func IsDirWritable(dir string) bool {
+	f, err := ioutil.TempFile(dir, ".pastelup-")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
 // GetDupeDetectionExecName returns exec file name for dupedetection
 func GetDupeDetectionExecName() string {
 	return filepath.Join(constants.DupeDetectionSubFolder, constants.DupeDetectionExecFileName)
@@ -622,3 +1020,123 @@ func ClearDir(ctx context.Context, dir string, skipFiles []string) error {
 	}
 	return nil
 }
+
+// RemoveDirExcept removes the directory with all its contents except the files and directories named as one of keepFiles,
+// unlike ClearDir they are kept in subdirs too, the directory itself is removed if nothing is kept in it
+func RemoveDirExcept(dir string, keepFiles []string) (bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	kept := false
+	for _, file := range files {
+		filePath := filepath.Join(dir, file.Name())
+		switch {
+		case Contains(keepFiles, file.Name()):
+			kept = true
+		case file.IsDir():
+			subKept, err := RemoveDirExcept(filePath, keepFiles)
+			if err != nil {
+				return false, err
+			}
+			kept = kept || subKept
+		default:
+			if err := os.Remove(filePath); err != nil {
+				return false, err
+			}
+		}
+	}
+	if kept {
+		return true, nil
+	}
+	return false, os.Remove(dir)
+}
+
+// RedactConfig replaces values of the keys in "key=value" (pastel.conf) and "key: value" (yaml) lines,
+// so the config can be shared without secrets
+func RedactConfig(data []byte, keys []string) []byte {
+	if len(keys) == 0 {
+		return data
+	}
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = regexp.QuoteMeta(key)
+	}
+	re := regexp.MustCompile(`(?m)^(\s*(?:` + strings.Join(quoted, "|") + `)\s*[=:][ \t]*)\S.*$`)
+	return re.ReplaceAll(data, []byte("${1}<redacted>"))
+}
+
+// RedactArgs replaces values of the "--name=value", "-name=value", "--name value" command line arguments and
+// "name=value" words (as logged by pasteld), so process lists, command and journal output can be shared without secrets
+func RedactArgs(data []byte, names []string) []byte {
+	if len(names) == 0 {
+		return data
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	alt := strings.Join(quoted, "|")
+	// argument may be a part of the command line or an item of the json array
+	re := regexp.MustCompile(`((?:^|[\s"'\[,:])(?:--?(?:` + alt + `)(?:=|[ \t]+)|(?:` + alt + `)=))[^\s"',\]]+`)
+	return re.ReplaceAll(data, []byte("${1}<redacted>"))
+}
+
+// RedactArgList replaces values of the secret arguments in the argv of the process,
+// value may be a part of the argument or the next argument
+func RedactArgList(args []string, names []string) []string {
+	redacted := make([]string, len(args))
+	for i := 0; i < len(args); i++ {
+		redacted[i] = string(RedactArgs([]byte(args[i]), names))
+		for _, name := range names {
+			if (args[i] == "-"+name || args[i] == "--"+name) && i+1 < len(args) {
+				i++
+				redacted[i] = "<redacted>"
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// TailFile returns the last n lines of the file, the file is read from the end, so it may be large
+func TailFile(filePath string, n int) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	const chunkSize = 64 * 1024
+	var data []byte
+	offset := info.Size()
+	for offset > 0 {
+		size := int64(chunkSize)
+		if offset < size {
+			size = offset
+		}
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, offset); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+		// the last line may not end with new line, one more is needed to get the whole first line
+		if bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, nil), nil
+}
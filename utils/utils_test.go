@@ -1,13 +1,21 @@
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pastelnetwork/pastelup/constants"
 	"github.com/tj/assert"
@@ -292,3 +300,505 @@ func TestIsValidNetworkMode(t *testing.T) {
 	}
 
 }
+
+func TestParseDuration(t *testing.T) {
+	testCases := map[string]struct {
+		val      string
+		expected time.Duration
+		pass     bool
+	}{
+		"days": {
+			val:      "30d",
+			expected: 30 * 24 * time.Hour,
+			pass:     true,
+		},
+		"weeks": {
+			val:      "2w",
+			expected: 14 * 24 * time.Hour,
+			pass:     true,
+		},
+		"hours": {
+			val:      "12h",
+			expected: 12 * time.Hour,
+			pass:     true,
+		},
+		"invalid": {
+			val:  "xd",
+			pass: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		tc := tc
+
+		t.Run(fmt.Sprintf("testCase-%v", name), func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseDuration(tc.val)
+			assert.Equal(t, tc.pass, err == nil)
+			if tc.pass {
+				assert.Equal(t, tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMergeYAML(t *testing.T) {
+	base := `
+log-config:
+  log-level: info
+  log-file: /tmp/sn.log
+node:
+  server:
+    port: 4444
+quiet: true
+`
+	override := `
+log-config:
+  log-level: debug
+node:
+  server:
+    listen_addresses: "127.0.0.1"
+quiet: null
+extra: 1
+`
+	expected := `log-config:
+  log-level: debug
+  log-file: /tmp/sn.log
+node:
+  server:
+    port: 4444
+    listen_addresses: 127.0.0.1
+extra: 1
+`
+	merged, err := MergeYAML([]byte(base), []byte(override))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(merged))
+
+	_, err = MergeYAML([]byte(base), []byte("node: ["))
+	assert.NotNil(t, err)
+}
+
+func TestMigrateYAML(t *testing.T) {
+	t.Parallel()
+
+	current := `
+log-config:
+log-level: info
+pastel_id: null
+node:
+  port: 4444
+`
+	defaults := `
+log-config:
+  log-level: error
+  log-file: /tmp/bridge.log
+pastel_id: ""
+node:
+  port: 5555
+  host: localhost
+`
+	expected := `log-config:
+  log-level: info
+  log-file: /tmp/bridge.log
+pastel_id: null
+node:
+  port: 4444
+  host: localhost
+`
+	renames := []KeyRename{
+		{From: "log-level", To: "log-config.log-level"},
+		{From: "missing", To: "node.missing"},
+	}
+	migrated, changes, err := MigrateYAML([]byte(current), []byte(defaults), renames)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, string(migrated))
+	assert.Equal(t, []string{
+		"renamed log-level -> log-config.log-level",
+		"added log-config.log-file",
+		"added node.host",
+	}, changes)
+
+	// already migrated document is not changed
+	_, changes, err = MigrateYAML(migrated, []byte(defaults), renames)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(changes))
+}
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	diff := DiffLines("a\nb\nc\n", "a\nc\nd\n")
+	assert.Equal(t, []string{" a", "-b", " c", "+d"}, diff)
+}
+
+func TestIsValidPastelID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		id    string
+		valid bool
+	}{
+		{"jXYJud3rmrR1Sk2scvR47N4E4J5Vv48uCC6se2nzHrBRdjaKj3ybPoi1Y2VVoRqi1GnQrYKjSxQAC7NBtvtEdS", true},
+		{"jXYJud3rmrR1Sk2scvR47N4E4J5Vv48uCC6se2nzHrBRdjaKj3ybPoi1Y2VVoRqi1GnQrYKjSxQAC7NBtvtEd", false},
+		{"XjYJud3rmrR1Sk2scvR47N4E4J5Vv48uCC6se2nzHrBRdjaKj3ybPoi1Y2VVoRqi1GnQrYKjSxQAC7NBtvtEdS", false},
+		{"jXYJud3rmrR1Sk2scvR47N4E4J5Vv48uCC6se2nzHrBRdjaKj3ybPoi1Y2VVoRqi1GnQrYKjSxQAC7NBtvtEd0", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.id, func(t *testing.T) {
+			assert.Equal(t, tc.valid, IsValidPastelID(tc.id))
+		})
+	}
+}
+
+func TestGetPortListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	listener := GetPortListener(port)
+	assert.NotNil(t, listener)
+	if listener.PID != 0 {
+		assert.Equal(t, os.Getpid(), listener.PID)
+	}
+
+	ln.Close()
+	assert.Nil(t, GetPortListener(port))
+}
+
+func TestRemoveDirExcept(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"pastel.conf",
+		"wallet.dat",
+		"testnet3/wallet.dat",
+		"testnet3/debug.log",
+		"testnet3/blocks/blk00000.dat",
+		"testnet3/pastelkeys/jXY",
+		"supernode.yml",
+	}
+	for _, file := range files {
+		assert.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0755))
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, file), []byte(file), 0644))
+	}
+
+	kept, err := RemoveDirExcept(dir, []string{"wallet.dat", "masternode.conf", "pastelkeys"})
+	assert.Nil(t, err)
+	assert.True(t, kept)
+	assert.True(t, CheckFileExist(filepath.Join(dir, "wallet.dat")))
+	assert.True(t, CheckFileExist(filepath.Join(dir, "testnet3", "pastelkeys", "jXY")))
+	assert.True(t, CheckFileExist(filepath.Join(dir, "testnet3", "wallet.dat")))
+	assert.False(t, CheckFileExist(filepath.Join(dir, "pastel.conf")))
+	assert.False(t, CheckFileExist(filepath.Join(dir, "testnet3", "debug.log")))
+	assert.False(t, CheckFileExist(filepath.Join(dir, "testnet3", "blocks")))
+	assert.False(t, CheckFileExist(filepath.Join(dir, "supernode.yml")))
+
+	kept, err = RemoveDirExcept(dir, nil)
+	assert.Nil(t, err)
+	assert.False(t, kept)
+	assert.False(t, CheckFileExist(dir))
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "v1.2.3", b: "v1.2.3", want: 0},
+		{a: "v1.2.3", b: "1.2.3", want: 0},
+		{a: "v1.2.4", b: "v1.2.3", want: 1},
+		{a: "v1.10.0", b: "v1.9.9", want: 1},
+		{a: "v1.2", b: "v1.2.0", want: 0},
+		{a: "v1.2.3-beta1", b: "v1.2.3", want: -1},
+		{a: "v1.2.3-beta2", b: "v1.2.3-beta1", want: 1},
+		{a: "v1.2.3-beta10", b: "v1.2.3-beta2", want: 1},
+		{a: "v1.2.3-beta2", b: "v1.2.3-beta10", want: -1},
+		{a: "v1.2.3-rc1", b: "v1.2.3-beta10", want: 1},
+		{a: "v1.2.3-beta.11", b: "v1.2.3-beta.9", want: 1},
+		{a: "v1.2.3-beta", b: "v1.2.3-beta.1", want: -1},
+		{a: "v1.2.3-1", b: "v1.2.3-alpha", want: -1},
+		{a: "v1.2.3-beta02", b: "v1.2.3-beta2", want: 0},
+		{a: "v1.2.3+build5", b: "v1.2.3", want: 0},
+		{a: "v2.0.0-rc1", b: "v1.9.9", want: 1},
+		{a: "latest", b: "v1.2.3", wantErr: true},
+		{a: "v1.2.3", b: "", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			got, err := CompareVersions(tc.a, tc.b)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestIsPreRelease(t *testing.T) {
+	assert.True(t, IsPreRelease("v1.2.3-beta1"))
+	assert.False(t, IsPreRelease("v1.2.3"))
+	assert.False(t, IsPreRelease("beta"))
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "snapshot", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "snapshot")
+
+	// interrupted download
+	err := ioutil.WriteFile(filePath+".tmp", content[:4000], 0644)
+	assert.Nil(t, err)
+
+	err = DownloadFileResume(context.Background(), filePath, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bytes=4000-"}, ranges)
+
+	data, err := ioutil.ReadFile(filePath)
+	assert.Nil(t, err)
+	assert.Equal(t, content, data)
+	assert.False(t, CheckFileExist(filePath+".tmp"))
+
+	// new download
+	err = DownloadFileResume(context.Background(), filepath.Join(dir, "other"), server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "", ranges[1])
+	data, err = ioutil.ReadFile(filepath.Join(dir, "other"))
+	assert.Nil(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestCreateAndExtractTarGz(t *testing.T) {
+	src := t.TempDir()
+	assert.Nil(t, os.MkdirAll(filepath.Join(src, "blocks", "index"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(src, "blocks", "blk00000.dat"), []byte("block"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(src, "blocks", "index", "000001.ldb"), []byte("index"), 0600))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(src, "wallet.dat"), []byte("wallet"), 0600))
+
+	archive := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	err := CreateTarGz(archive, src, []string{"blocks"})
+	assert.Nil(t, err)
+
+	dst := t.TempDir()
+	err = ExtractTarGz(archive, dst)
+	assert.Nil(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dst, "blocks", "blk00000.dat"))
+	assert.Nil(t, err)
+	assert.Equal(t, "block", string(data))
+	info, err := os.Stat(filepath.Join(dst, "blocks", "index", "000001.ldb"))
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.False(t, CheckFileExist(filepath.Join(dst, "wallet.dat")))
+}
+
+func TestExtractTarGzRejectsPathTraversal(t *testing.T) {
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "../evil", Mode: 0644, Size: 4, Typeflag: tar.TypeReg}))
+	_, err := tw.Write([]byte("evil"))
+	assert.Nil(t, err)
+	assert.Nil(t, tw.Close())
+	assert.Nil(t, gzw.Close())
+
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "evil.tar.gz")
+	assert.Nil(t, ioutil.WriteFile(archive, buf.Bytes(), 0644))
+
+	dst := filepath.Join(dir, "dst")
+	err = ExtractTarGz(archive, dst)
+	assert.NotNil(t, err)
+	assert.False(t, CheckFileExist(filepath.Join(dir, "evil")))
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	pw := NewPrefixWriter(&buf, "[host] ")
+
+	_, err := pw.Write([]byte("first\nsec"))
+	assert.Nil(t, err)
+	assert.Equal(t, "[host] first\n", buf.String())
+
+	_, err = pw.Write([]byte("ond\nthird"))
+	assert.Nil(t, err)
+	assert.Nil(t, pw.Flush())
+	assert.Equal(t, "[host] first\n[host] second\n[host] third\n", buf.String())
+
+	assert.Nil(t, pw.Flush())
+	assert.Equal(t, "[host] first\n[host] second\n[host] third\n", buf.String())
+}
+
+func TestRedactConfig(t *testing.T) {
+	conf := "rpcuser=user\nrpcpassword=secret\nrpcport=9932\n"
+	assert.Equal(t, "rpcuser=user\nrpcpassword=<redacted>\nrpcport=9932\n",
+		string(RedactConfig([]byte(conf), []string{"rpcpassword"})))
+
+	yml := "node:\n  pastel_id: jXY\n  pass_phrase: \"secret\"\nempty_pass_phrase:\n  pass_phrase:\n"
+	assert.Equal(t, "node:\n  pastel_id: jXY\n  pass_phrase: <redacted>\nempty_pass_phrase:\n  pass_phrase:\n",
+		string(RedactConfig([]byte(yml), []string{"pass_phrase"})))
+}
+
+func TestRedactArgs(t *testing.T) {
+	names := []string{"masternodeprivkey", "rpcpassword"}
+	cmdLine := "ExecStart=/home/user/pastel/pasteld --datadir=/home/user/.pastel --masternodeprivkey=5Kb8kLf9 -rpcpassword secret -txindex=1"
+	assert.Equal(t, "ExecStart=/home/user/pastel/pasteld --datadir=/home/user/.pastel --masternodeprivkey=<redacted> -rpcpassword <redacted> -txindex=1",
+		string(RedactArgs([]byte(cmdLine), names)))
+
+	args := `["pasteld","--masternodeprivkey=5Kb8kLf9","--rpcpasswordhint=x"]`
+	assert.Equal(t, `["pasteld","--masternodeprivkey=<redacted>","--rpcpasswordhint=x"]`,
+		string(RedactArgs([]byte(args), names)))
+
+	log := "2022-03-15 12:00:00 Command-line arg: masternodeprivkey=5Kb8kLf9\nrpcpassword is not set\n"
+	assert.Equal(t, "2022-03-15 12:00:00 Command-line arg: masternodeprivkey=<redacted>\nrpcpassword is not set\n",
+		string(RedactArgs([]byte(log), names)))
+}
+
+func TestRedactArgList(t *testing.T) {
+	args := []string{"pastelup", "update", "--user-pw", "secret", "--release=v1.2.3", "-rpcpassword=secret", "--user-pw"}
+	assert.Equal(t, []string{"pastelup", "update", "--user-pw", "<redacted>", "--release=v1.2.3", "-rpcpassword=<redacted>", "--user-pw"},
+		RedactArgList(args, []string{"user-pw", "rpcpassword"}))
+}
+
+func TestTailFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "test.log")
+
+	var lines []string
+	for i := 0; i < 20000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	assert.Nil(t, ioutil.WriteFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+
+	data, err := TailFile(filePath, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, "line 19997\nline 19998\nline 19999\n", string(data))
+
+	data, err = TailFile(filePath, 30000)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Join(lines, "\n")+"\n", string(data))
+
+	// no new line at the end
+	assert.Nil(t, ioutil.WriteFile(filePath, []byte("a\nb\nc"), 0644))
+	data, err = TailFile(filePath, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "b\nc", string(data))
+}
+
+func TestParseLogTime(t *testing.T) {
+	now := time.Date(2022, 3, 15, 12, 0, 0, 0, time.Local)
+
+	ts, ok := ParseLogTime("[Mar 15 11:59:58.123]  INFO p2p: started", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 3, 15, 11, 59, 58, 123000000, time.Local), ts)
+
+	// no year in the timestamp, the record is from the last year
+	ts, ok = ParseLogTime("[Dec 31 23:00:00.000] ERROR failed", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2021, ts.Year())
+
+	ts, ok = ParseLogTime("2022-03-15 10:00:01 UpdateTip: new best=...", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 3, 15, 10, 0, 1, 0, time.UTC), ts)
+
+	ts, ok = ParseLogTime("2022-03-15T10:00:01+0200 host dd-service[123]: started", now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 3, 15, 8, 0, 1, 0, time.UTC), ts.UTC())
+
+	_, ok = ParseLogTime("goroutine 1 [running]:", now)
+	assert.False(t, ok)
+}
+
+func TestParseLogTimeZones(t *testing.T) {
+	// gonode components log local time, pasteld logs UTC, records of both must be ordered by the real time
+	local := time.Local
+	time.Local = time.FixedZone("UTC+3", 3*60*60)
+	defer func() { time.Local = local }()
+
+	now := time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC)
+	gonode, ok := ParseLogTime("[Mar 15 13:00:00.000]  INFO started", now)
+	assert.True(t, ok)
+	pasteld, ok := ParseLogTime("2022-03-15 10:00:00 UpdateTip: new best=...", now)
+	assert.True(t, ok)
+	assert.True(t, gonode.Equal(pasteld))
+	assert.Equal(t, time.Date(2022, 3, 15, 10, 0, 0, 0, time.UTC), gonode.UTC())
+
+	journal, ok := ParseLogTime("2022-03-15T13:00:00+03:00 host supernode[123]: started", now)
+	assert.True(t, ok)
+	assert.True(t, journal.Equal(pasteld))
+
+	// record of the next day in the local time is not moved to the last year
+	ts, ok := ParseLogTime("[Mar 16 01:00:00.000]  INFO started", now)
+	assert.True(t, ok)
+	assert.Equal(t, 2022, ts.Year())
+
+	// new year, the record from December is from the previous year
+	ts, ok = ParseLogTime("[Dec 31 23:59:59.999]  INFO started", time.Date(2023, 1, 1, 0, 30, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2022, 12, 31, 23, 59, 59, 999000000, time.Local), ts)
+}
+
+func TestLogParser(t *testing.T) {
+	p := &LogParser{now: time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC)}
+	assert.Nil(t, p.Flush())
+
+	assert.Nil(t, p.Add("[Mar 15 11:00:00.000] ERROR failed\n"))
+	assert.Nil(t, p.Add("goroutine 1 [running]:"))
+	assert.Nil(t, p.Add("\tmain.go:10\r\n"))
+
+	record := p.Add("2022-03-15 10:00:01 WARNING: low disk space")
+	assert.NotNil(t, record)
+	assert.Equal(t, "[Mar 15 11:00:00.000] ERROR failed\ngoroutine 1 [running]:\n\tmain.go:10", record.Text)
+	assert.Equal(t, 4, record.Level)
+
+	record = p.Add("2022-03-15 10:00:02 UpdateTip: new best=...")
+	assert.NotNil(t, record)
+	assert.Equal(t, 3, record.Level)
+	assert.Equal(t, time.Date(2022, 3, 15, 10, 0, 1, 0, time.UTC), record.Time)
+
+	record = p.Flush()
+	assert.NotNil(t, record)
+	// lines without level are info
+	assert.Equal(t, 2, record.Level)
+	assert.Nil(t, p.Flush())
+}
+
+func TestReadLogRecords(t *testing.T) {
+	log := "panic: continued\n" +
+		"[Mar 15 11:59:58.123]  INFO started\n" +
+		"[Mar 15 11:59:59.000] ERROR failed\n" +
+		"goroutine 1 [running]:\n" +
+		"main.main()\n" +
+		"2022-03-15 10:00:01 ERROR: AcceptBlock\n"
+	records, err := ReadLogRecords(strings.NewReader(log))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(records))
+
+	assert.True(t, records[0].Time.IsZero())
+	assert.Equal(t, "[Mar 15 11:59:58.123]  INFO started", records[1].Text)
+	assert.Equal(t, 2, records[1].Level)
+	assert.Equal(t, "[Mar 15 11:59:59.000] ERROR failed\ngoroutine 1 [running]:\nmain.main()", records[2].Text)
+	assert.Equal(t, 4, records[2].Level)
+	assert.Equal(t, 4, records[3].Level)
+
+	level, err := ParseLogLevel("Warn")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, level)
+	_, err = ParseLogLevel("verbose")
+	assert.NotNil(t, err)
+}
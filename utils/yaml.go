@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// MergeYAML deep merges override YAML document onto the base one, keeping order of the base keys.
+// Mappings are merged recursively, any other override value replaces the base one,
+// and null override value removes the key from the result
+func MergeYAML(base []byte, override []byte) ([]byte, error) {
+	var baseDoc, overrideDoc yaml.MapSlice
+	if err := yaml.Unmarshal(base, &baseDoc); err != nil {
+		return nil, errors.Errorf("failed to parse base yaml: %v", err)
+	}
+	if err := yaml.Unmarshal(override, &overrideDoc); err != nil {
+		return nil, errors.Errorf("failed to parse override yaml: %v", err)
+	}
+
+	return yaml.Marshal(mergeMapSlice(baseDoc, overrideDoc))
+}
+
+func mergeMapSlice(base yaml.MapSlice, override yaml.MapSlice) yaml.MapSlice {
+	result := make(yaml.MapSlice, 0, len(base)+len(override))
+	result = append(result, base...)
+
+	for _, item := range override {
+		idx := -1
+		for i := range result {
+			if result[i].Key == item.Key {
+				idx = i
+				break
+			}
+		}
+
+		if item.Value == nil {
+			if idx >= 0 {
+				result = append(result[:idx], result[idx+1:]...)
+			}
+			continue
+		}
+		if idx < 0 {
+			result = append(result, item)
+			continue
+		}
+
+		baseValue, baseIsMap := result[idx].Value.(yaml.MapSlice)
+		overrideValue, overrideIsMap := item.Value.(yaml.MapSlice)
+		if baseIsMap && overrideIsMap {
+			result[idx].Value = mergeMapSlice(baseValue, overrideValue)
+		} else {
+			result[idx].Value = item.Value
+		}
+	}
+	return result
+}
+
+// KeyRename describes YAML key moved from one dotted path to another, e.g. "log-level" -> "log-config.log-level"
+type KeyRename struct {
+	From string
+	To   string
+}
+
+// MigrateYAML moves renamed keys of the YAML document and adds keys which are missing in it from the defaults document.
+// Existing values are never changed. Returns migrated document and list of made changes
+func MigrateYAML(doc []byte, defaults []byte, renames []KeyRename) ([]byte, []string, error) {
+	var current, defaultDoc yaml.MapSlice
+	if err := yaml.Unmarshal(doc, &current); err != nil {
+		return nil, nil, errors.Errorf("failed to parse yaml: %v", err)
+	}
+	if err := yaml.Unmarshal(defaults, &defaultDoc); err != nil {
+		return nil, nil, errors.Errorf("failed to parse defaults yaml: %v", err)
+	}
+
+	var changes []string
+	for _, rename := range renames {
+		from := strings.Split(rename.From, ".")
+		to := strings.Split(rename.To, ".")
+		value, ok := getMapSlicePath(current, from)
+		if !ok {
+			continue
+		}
+		if _, exists := getMapSlicePath(current, to); exists {
+			continue
+		}
+		current = deleteMapSlicePath(current, from)
+		current = setMapSlicePath(current, to, value)
+		changes = append(changes, fmt.Sprintf("renamed %s -> %s", rename.From, rename.To))
+	}
+
+	current = fillMapSliceDefaults(current, defaultDoc, "", &changes)
+
+	out, err := yaml.Marshal(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, changes, nil
+}
+
+func getMapSlicePath(ms yaml.MapSlice, path []string) (interface{}, bool) {
+	for _, item := range ms {
+		if fmt.Sprint(item.Key) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return item.Value, true
+		}
+		child, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return nil, false
+		}
+		return getMapSlicePath(child, path[1:])
+	}
+	return nil, false
+}
+
+func deleteMapSlicePath(ms yaml.MapSlice, path []string) yaml.MapSlice {
+	for i, item := range ms {
+		if fmt.Sprint(item.Key) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return append(ms[:i], ms[i+1:]...)
+		}
+		if child, ok := item.Value.(yaml.MapSlice); ok {
+			ms[i].Value = deleteMapSlicePath(child, path[1:])
+		}
+		return ms
+	}
+	return ms
+}
+
+func setMapSlicePath(ms yaml.MapSlice, path []string, value interface{}) yaml.MapSlice {
+	for i, item := range ms {
+		if fmt.Sprint(item.Key) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			ms[i].Value = value
+			return ms
+		}
+		// non mapping value (e.g. empty key) on the way is replaced with the mapping
+		child, _ := item.Value.(yaml.MapSlice)
+		ms[i].Value = setMapSlicePath(child, path[1:], value)
+		return ms
+	}
+	if len(path) == 1 {
+		return append(ms, yaml.MapItem{Key: path[0], Value: value})
+	}
+	return append(ms, yaml.MapItem{Key: path[0], Value: setMapSlicePath(nil, path[1:], value)})
+}
+
+func fillMapSliceDefaults(ms yaml.MapSlice, defaults yaml.MapSlice, prefix string, changes *[]string) yaml.MapSlice {
+	for _, item := range defaults {
+		key := prefix + fmt.Sprint(item.Key)
+		found := false
+		for i := range ms {
+			if ms[i].Key != item.Key {
+				continue
+			}
+			found = true
+			child, isMap := ms[i].Value.(yaml.MapSlice)
+			defaultChild, defaultIsMap := item.Value.(yaml.MapSlice)
+			if isMap && defaultIsMap {
+				ms[i].Value = fillMapSliceDefaults(child, defaultChild, key+".", changes)
+			}
+			break
+		}
+		if !found {
+			ms = append(ms, item)
+			*changes = append(*changes, fmt.Sprintf("added %s", key))
+		}
+	}
+	return ms
+}
+
+// DiffLines returns line based diff between old and new text,
+// removed lines are prefixed with "-", added with "+" and unchanged with " "
+func DiffLines(oldText string, newText string) []string {
+	a := strings.Split(strings.TrimRight(oldText, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(newText, "\n"), "\n")
+
+	// lcs[i][j] is length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			diff = append(diff, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			diff = append(diff, "-"+a[i])
+			i++
+		default:
+			diff = append(diff, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		diff = append(diff, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		diff = append(diff, "+"+b[j])
+	}
+	return diff
+}
+
+// SetYAMLValues sets values of the YAML document by dotted paths, keeping order of the existing keys
+func SetYAMLValues(doc []byte, values map[string]interface{}) ([]byte, error) {
+	var current yaml.MapSlice
+	if err := yaml.Unmarshal(doc, &current); err != nil {
+		return nil, errors.Errorf("failed to parse yaml: %v", err)
+	}
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		current = setMapSlicePath(current, strings.Split(path, "."), values[path])
+	}
+	return yaml.Marshal(current)
+}